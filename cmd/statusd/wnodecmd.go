@@ -188,6 +188,7 @@ func makeWhisperNodeConfig(ctx *cli.Context) (*params.NodeConfig, error) {
 	whisperConfig.ForwarderNode = ctx.Bool(WhisperForwarderNodeFlag.Name)
 	whisperConfig.NotificationServerNode = ctx.Bool(WhisperNotificationServerNodeFlag.Name)
 	whisperConfig.MailServerNode = ctx.Bool(WhisperMailserverNodeFlag.Name)
+	whisperConfig.MailServerConfig.Enabled = whisperConfig.MailServerNode
 	whisperConfig.Port = ctx.Int(WhisperPortFlag.Name)
 	whisperConfig.TTL = ctx.Int(WhisperTTLFlag.Name)
 	whisperConfig.MinimumPoW = ctx.Float64(WhisperPoWFlag.Name)
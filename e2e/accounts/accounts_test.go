@@ -3,8 +3,16 @@ package accounts
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/status-im/status-go/e2e"
 	"github.com/status-im/status-go/geth/account"
 	"github.com/status-im/status-go/geth/common"
@@ -130,6 +138,81 @@ func (s *AccountsTestSuite) TestCreateChildAccount() {
 	s.False(subAccount1 == subAccount3 || subPubKey1 == subPubKey3 || subAccount2 == subAccount3 || subPubKey2 == subPubKey3)
 }
 
+func (s *AccountsTestSuite) TestCreateAccountSign() {
+	s.StartTestBackend(params.RinkebyNetworkID)
+	defer s.StopTestBackend()
+
+	address, _, mnemonic, err := s.Backend.AccountManager().CreateAccount(TestConfig.Account1.Password)
+	s.NoError(err)
+	s.Len(strings.Fields(mnemonic), 12, "BIP39 mnemonic of 128 bits is expected")
+
+	// created account is in the node's keystore, so that it can sign once selected
+	s.NoError(s.Backend.AccountManager().SelectAccount(address, TestConfig.Account1.Password))
+	s.Equal(address, s.sign(address, []byte("status")))
+}
+
+// sign signs data with eth_sign and returns the address recovered from the signature.
+func (s *AccountsTestSuite) sign(address string, data []byte) string {
+	var signature hexutil.Bytes
+	s.NoError(s.Backend.NodeManager().RPCClient().Call(&signature, "eth_sign", address, hexutil.Bytes(data)))
+
+	return s.recoverSigner(data, signature)
+}
+
+// personalSign signs data with personal_sign and returns the address recovered from the signature.
+func (s *AccountsTestSuite) personalSign(address string, data []byte) string {
+	var signature hexutil.Bytes
+	s.NoError(s.Backend.NodeManager().RPCClient().Call(&signature, "personal_sign", hexutil.Bytes(data), address))
+
+	return s.recoverSigner(data, signature)
+}
+
+// recoverSigner returns the address which signed data prefixed with the Ethereum message header.
+func (s *AccountsTestSuite) recoverSigner(data []byte, signature []byte) string {
+	s.Len(signature, 65)
+
+	// recover signer (see personal_ecRecover)
+	signature[64] -= 27
+	msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(data), data)
+	pubKey, err := crypto.SigToPub(crypto.Keccak256([]byte(msg)), signature)
+	s.NoError(err)
+
+	return crypto.PubkeyToAddress(*pubKey).Hex()
+}
+
+func (s *AccountsTestSuite) TestImportAccount() {
+	s.StartTestBackend(params.RinkebyNetworkID)
+	defer s.StopTestBackend()
+
+	const (
+		privateKey      = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+		expectedAddress = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	)
+
+	address, pubKey, err := s.Backend.AccountManager().ImportAccount(privateKey, TestConfig.Account1.Password)
+	s.NoError(err)
+	s.Equal(expectedAddress, address)
+	s.NotEmpty(pubKey)
+
+	// keystore is kept between test runs
+	keyStore, err := s.Backend.NodeManager().AccountKeyStore()
+	s.NoError(err)
+	account, err := common.ParseAccountString(address)
+	s.NoError(err)
+	defer keyStore.Delete(account, TestConfig.Account1.Password) // nolint: errcheck
+
+	// imported account can be selected
+	s.NoError(s.Backend.AccountManager().SelectAccount(address, TestConfig.Account1.Password))
+	s.True(s.WhisperService().HasKeyPair(pubKey), "identity not injected into whisper")
+
+	// the same key can't be imported twice
+	_, _, err = s.Backend.AccountManager().ImportAccount(privateKey, TestConfig.Account1.Password)
+	s.Error(err)
+
+	_, _, err = s.Backend.AccountManager().ImportAccount("0xinvalid", TestConfig.Account1.Password)
+	s.Error(err)
+}
+
 func (s *AccountsTestSuite) TestRecoverAccount() {
 	s.StartTestBackend(params.RinkebyNetworkID)
 	defer s.StopTestBackend()
@@ -217,6 +300,133 @@ func (s *AccountsTestSuite) TestSelectAccount() {
 	s.False(whisperService.HasKeyPair(pubKey1), "identity should be removed, but it is still present in whisper")
 }
 
+func (s *AccountsTestSuite) TestSelectedAccountInfo() {
+	s.StartTestBackend(params.RinkebyNetworkID)
+	defer s.StopTestBackend()
+
+	accountManager := s.Backend.AccountManager()
+
+	_, err := accountManager.SelectedAccountInfo()
+	s.Equal(account.ErrNoAccountSelected, err)
+
+	address, pubKey, _, err := accountManager.CreateAccount(TestConfig.Account1.Password)
+	s.NoError(err)
+
+	selectedAt := time.Now()
+	s.NoError(accountManager.SelectAccount(address, TestConfig.Account1.Password))
+
+	info, err := accountManager.SelectedAccountInfo()
+	s.NoError(err)
+	s.Equal(address, info.Address.Hex())
+	s.Equal(pubKey, info.PublicKey)
+	s.False(info.SelectedAt.Before(selectedAt))
+
+	s.NoError(accountManager.Logout())
+	_, err = accountManager.SelectedAccountInfo()
+	s.Equal(account.ErrNoAccountSelected, err)
+}
+
+func (s *AccountsTestSuite) TestSelectAccountSign() {
+	s.StartTestBackend(params.RinkebyNetworkID)
+	defer s.StopTestBackend()
+
+	address1, _, _, err := s.Backend.AccountManager().CreateAccount(TestConfig.Account1.Password)
+	s.NoError(err)
+	address2, _, _, err := s.Backend.AccountManager().CreateAccount(TestConfig.Account1.Password)
+	s.NoError(err)
+
+	client := s.Backend.NodeManager().RPCClient()
+	data := hexutil.Bytes("status")
+
+	// nothing can be signed before login
+	err = client.Call(nil, "eth_sign", address1, data)
+	s.Equal(account.ErrNoAccountSelected, err)
+
+	s.NoError(s.Backend.AccountManager().SelectAccount(address1, TestConfig.Account1.Password))
+	s.Equal(address1, s.sign(address1, data))
+
+	// only the selected account can sign
+	err = client.Call(nil, "eth_sign", address2, data)
+	s.Equal(account.ErrSignerNotSelected, err)
+	err = client.Call(nil, "personal_sign", data, address2)
+	s.Equal(account.ErrSignerNotSelected, err)
+
+	// raw calls are signed too
+	resp := client.CallRaw(`{"jsonrpc":"2.0","method":"eth_sign","params":["` + address1 + `","0x01"],"id":1}`)
+	s.Contains(resp, `"result":"0x`)
+
+	s.NoError(s.Backend.AccountManager().Logout())
+	err = client.Call(nil, "eth_sign", address1, data)
+	s.Equal(account.ErrNoAccountSelected, err)
+}
+
+func (s *AccountsTestSuite) TestPersonalSign() {
+	s.StartTestBackend(params.RinkebyNetworkID)
+	defer s.StopTestBackend()
+
+	address, _, _, err := s.Backend.AccountManager().CreateAccount(TestConfig.Account1.Password)
+	s.NoError(err)
+
+	client := s.Backend.NodeManager().RPCClient()
+	data := hexutil.Bytes("Hello, Status!")
+
+	err = client.Call(nil, "personal_sign", data, address)
+	s.Equal(account.ErrNoAccountSelected, err)
+
+	s.NoError(s.Backend.AccountManager().SelectAccount(address, TestConfig.Account1.Password))
+	s.Equal(address, s.personalSign(address, data))
+
+	// the password used by web3 is optional and ignored
+	var signature hexutil.Bytes
+	s.NoError(client.Call(&signature, "personal_sign", data, address, "ignored"))
+	s.Equal(address, s.recoverSigner(data, signature))
+
+	// arguments in the order of eth_sign are rejected
+	err = client.Call(nil, "personal_sign", address, data)
+	s.Error(err)
+	s.Contains(err.Error(), account.ErrInvalidPersonalSignParams.Error())
+}
+
+func (s *AccountsTestSuite) TestPersonalNewAndUnlockAccount() {
+	s.StartTestBackend(params.RinkebyNetworkID)
+	defer s.StopTestBackend()
+
+	client := s.Backend.NodeManager().RPCClient()
+	data := hexutil.Bytes("status")
+
+	var address gethcommon.Address
+	s.NoError(client.Call(&address, "personal_newAccount", TestConfig.Account1.Password))
+
+	var addresses []gethcommon.Address
+	s.NoError(client.Call(&addresses, "personal_listAccounts"))
+	s.Contains(addresses, address)
+
+	// locked accounts can't sign
+	err := client.Call(nil, "eth_sign", address, data)
+	s.Equal(account.ErrNoAccountSelected, err)
+
+	var unlocked bool
+	err = client.Call(&unlocked, "personal_unlockAccount", address, "wrong password")
+	s.EqualError(err, keystore.ErrDecrypt.Error())
+
+	s.NoError(client.Call(&unlocked, "personal_unlockAccount", address, TestConfig.Account1.Password, 2))
+	s.True(unlocked)
+	s.Equal(address.Hex(), s.sign(address.Hex(), data))
+	s.Equal(address.Hex(), s.personalSign(address.Hex(), data))
+
+	// the account is relocked once the duration passes
+	time.Sleep(3 * time.Second)
+	err = client.Call(nil, "eth_sign", address, data)
+	s.Equal(account.ErrNoAccountSelected, err)
+
+	// or on logout
+	s.NoError(client.Call(&unlocked, "personal_unlockAccount", address, TestConfig.Account1.Password))
+	s.Equal(address.Hex(), s.sign(address.Hex(), data))
+	s.NoError(s.Backend.AccountManager().Logout())
+	err = client.Call(nil, "eth_sign", address, data)
+	s.Equal(account.ErrNoAccountSelected, err)
+}
+
 func (s *AccountsTestSuite) TestSelectedAccountOnRestart() {
 	s.StartTestBackend(params.RinkebyNetworkID)
 
@@ -300,3 +510,37 @@ func (s *AccountsTestSuite) TestSelectedAccountOnRestart() {
 	s.EqualError(account.ErrNoAccountSelected, err.Error())
 	s.Nil(selectedAccount)
 }
+
+func (s *AccountsTestSuite) TestCustomKeyStoreDir() {
+	keyStoreDir, err := ioutil.TempDir("", "status-keystore")
+	s.NoError(err)
+	defer os.RemoveAll(keyStoreDir) // nolint: errcheck
+
+	s.StartTestBackend(params.RopstenNetworkID, func(config *params.NodeConfig) {
+		config.KeyStoreDir = keyStoreDir
+	})
+	defer s.StopTestBackend()
+
+	address, _, _, err := s.Backend.AccountManager().CreateAccount(TestConfig.Account1.Password)
+	s.NoError(err)
+
+	// key files are named after addresses they hold keys of
+	hasKeyFile := func() bool {
+		files, err := ioutil.ReadDir(keyStoreDir)
+		s.NoError(err)
+		for _, file := range files {
+			if strings.HasSuffix(file.Name(), strings.ToLower(address[2:])) {
+				return true
+			}
+		}
+		return false
+	}
+	s.True(hasKeyFile(), "account key should be stored in the custom keystore dir")
+
+	nodeReady, err := s.Backend.ResetChainData()
+	s.NoError(err)
+	<-nodeReady
+
+	s.True(hasKeyFile(), "account key should survive chain data reset")
+	s.NoError(s.Backend.AccountManager().SelectAccount(address, TestConfig.Account1.Password))
+}
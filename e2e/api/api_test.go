@@ -12,6 +12,7 @@ import (
 	"github.com/status-im/status-go/geth/api"
 	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -51,6 +52,14 @@ func (s *APITestSuite) TestCHTUpdate() {
 	// TODO(tiabc): Test that CHT is really updated.
 }
 
+func (s *APITestSuite) TestCallRPCErrBeforeStartNode() {
+	resp, err := s.api.CallRPCErr(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	s.Equal(rpc.ErrNoLocalNode, err)
+	s.JSONEq(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"node not started"}}`, resp)
+
+	s.JSONEq(resp, s.api.CallRPC(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`))
+}
+
 func (s *APITestSuite) TestRaceConditions() {
 	cnt := 25
 	progress := make(chan struct{}, cnt)
@@ -1,17 +1,20 @@
 package api_test
 
 import (
+	"encoding/json"
 	"math/rand"
 	"testing"
 	"time"
 
 	"github.com/status-im/status-go/e2e"
 	"github.com/status-im/status-go/geth/account"
+	"github.com/status-im/status-go/geth/api"
 	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/jail"
 	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/node"
 	"github.com/status-im/status-go/geth/params"
+	. "github.com/status-im/status-go/testing"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -275,3 +278,49 @@ func (s *APIBackendTestSuite) TestRestartNode() {
 	s.NoError(err)
 	s.Equal("0x6341fd3daf94b748c72ced5a5b26028f2474f5f00d824504e4fa37a75767e177", firstHash)
 }
+
+func (s *APIBackendTestSuite) ping() api.PingResult {
+	resp := s.Backend.CallRPC(`{"jsonrpc":"2.0","method":"status_ping","params":[],"id":1}`)
+
+	var result struct {
+		Result api.PingResult `json:"result"`
+	}
+	s.NoError(json.Unmarshal([]byte(resp), &result), resp)
+
+	return result.Result
+}
+
+func (s *APIBackendTestSuite) TestPing() {
+	s.Equal(api.PingResult{}, s.ping())
+
+	// other methods are not served without a node
+	resp := s.Backend.CallRPC(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	s.Contains(resp, `"error"`)
+
+	s.StartTestBackend(params.RopstenNetworkID)
+	s.Equal(api.PingResult{NodeRunning: true, NetworkID: params.RopstenNetworkID}, s.ping())
+
+	err := s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password)
+	s.NoError(err)
+	s.Equal(api.PingResult{NodeRunning: true, AccountSelected: true, NetworkID: params.RopstenNetworkID}, s.ping())
+
+	s.StopTestBackend()
+	s.False(s.ping().NodeRunning)
+}
+
+func (s *APIBackendTestSuite) TestCallInto() {
+	var hash string
+	err := s.Backend.CallInto("web3_sha3", []interface{}{"0x68656c6c6f20776f726c64"}, &hash)
+	s.Equal(node.ErrNoRunningNode, err)
+
+	s.StartTestBackend(params.RopstenNetworkID)
+	defer s.StopTestBackend()
+
+	err = s.Backend.CallInto("web3_sha3", []interface{}{"0x68656c6c6f20776f726c64"}, &hash)
+	s.NoError(err)
+	s.Equal("0x47173285a8d7341e5e972fc677286384f802f8ef42a5ec5f03bbfa254cb01fad", hash)
+
+	// JSON-RPC errors are returned
+	err = s.Backend.CallInto("web3_sha3", []interface{}{"not hex"}, &hash)
+	s.Error(err)
+}
@@ -1,22 +1,38 @@
 package node_test
 
 import (
+	"context"
+	"encoding/binary"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/les"
 	gethnode "github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/rpc"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
 	"github.com/status-im/status-go/e2e"
+	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/node"
 	"github.com/status-im/status-go/geth/params"
+	statusrpc "github.com/status-im/status-go/geth/rpc"
 	"github.com/status-im/status-go/geth/signal"
+	. "github.com/status-im/status-go/testing"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -41,7 +57,7 @@ func (s *ManagerTestSuite) TestReferencesWithoutStartedNode() {
 		{
 			"non-null manager, no running node, RestartNode()",
 			func() (interface{}, error) {
-				return s.NodeManager.RestartNode()
+				return s.NodeManager.RestartNode(nil)
 			},
 			node.ErrNoRunningNode,
 		},
@@ -66,6 +82,20 @@ func (s *ManagerTestSuite) TestReferencesWithoutStartedNode() {
 			},
 			node.ErrNoRunningNode,
 		},
+		{
+			"non-null manager, no running node, RemovePeer()",
+			func() (interface{}, error) {
+				return nil, s.NodeManager.RemovePeer("enode://da3bf389a031f33fb55c9f5f54fde8473912402d27fffaa50efd74c0d0515f3a61daf6d52151f2876b19c15828e6f670352bff432b5ec457652e74755e8c864f@51.15.62.116:30303")
+			},
+			node.ErrNoRunningNode,
+		},
+		{
+			"non-null manager, no running node, get Peers",
+			func() (interface{}, error) {
+				return s.NodeManager.Peers()
+			},
+			node.ErrNoRunningNode,
+		},
 		{
 			"non-null manager, no running node, get NodeConfig",
 			func() (interface{}, error) {
@@ -108,6 +138,13 @@ func (s *ManagerTestSuite) TestReferencesWithoutStartedNode() {
 			},
 			node.ErrNoRunningNode,
 		},
+		{
+			"non-null manager, no running node, SyncProgress()",
+			func() (interface{}, error) {
+				return s.NodeManager.SyncProgress()
+			},
+			node.ErrNoRunningNode,
+		},
 		{
 			"non-null manager, no running node, get RPC Client",
 			func() (interface{}, error) {
@@ -232,6 +269,286 @@ func (s *ManagerTestSuite) TestNodeStartStop() {
 	<-nodeStopped
 }
 
+func (s *ManagerTestSuite) TestStartNodeInvalidConfig() {
+	nodeConfig, err := e2e.MakeTestNodeConfig(params.RopstenNetworkID)
+	s.NoError(err)
+
+	nodeConfig.UpstreamConfig.Enabled = true
+	nodeConfig.UpstreamConfig.URL = ""
+
+	_, err = s.NodeManager.StartNode(nodeConfig)
+	s.Equal(params.ErrMissingUpstreamURL, err)
+	s.False(s.NodeManager.IsNodeRunning())
+}
+
+func (s *ManagerTestSuite) TestRestartNodeInvalidConfig() {
+	s.StartTestNode(params.RopstenNetworkID)
+	defer s.StopTestNode()
+
+	nodeConfig, err := e2e.MakeTestNodeConfig(params.RopstenNetworkID)
+	s.NoError(err)
+
+	nodeConfig.UpstreamConfig.Enabled = true
+	nodeConfig.UpstreamConfig.URL = ""
+
+	_, err = s.NodeManager.RestartNode(nodeConfig)
+	s.Equal(params.ErrMissingUpstreamURL, err)
+
+	// running node is left intact
+	s.True(s.NodeManager.IsNodeRunning())
+}
+
+func (s *ManagerTestSuite) TestStartNodeWithCustomGenesis() {
+	const (
+		networkID = 1337
+		account   = "0x8f8ed2a2f1b5d0fef4730e0d8f3f1362af0e89b6"
+		balance   = "0xde0b6b3a7640000"
+	)
+
+	nodeConfig, err := params.LoadNodeConfig(`{
+		"NetworkId": ` + strconv.Itoa(networkID) + `,
+		"DataDir": "` + filepath.Join(TestDataDir, "private") + `",
+		"LogLevel": "ERROR",
+		"BootClusterConfig": {"Enabled": false},
+		"LightEthConfig": {
+			"Enabled": true,
+			"DatabaseCache": 16,
+			"Genesis": "{\"config\":{\"chainId\":` + strconv.Itoa(networkID) + `,\"homesteadBlock\":0,\"eip155Block\":0,\"eip158Block\":0},\"difficulty\":\"0x1\",\"gasLimit\":\"0x47b760\",\"alloc\":{\"` + account + `\":{\"balance\":\"` + balance + `\"}}}"
+		}
+	}`)
+	s.NoError(err)
+
+	nodeStarted, err := s.NodeManager.StartNode(nodeConfig)
+	s.NoError(err)
+	<-nodeStarted
+	defer s.StopTestNode()
+
+	client := s.NodeManager.RPCClient()
+	s.NotNil(client)
+
+	resp := client.CallRaw(`{"jsonrpc":"2.0","method":"eth_getBalance","params":["` + account + `", "latest"],"id":1}`)
+	s.Equal(`{"jsonrpc":"2.0","id":1,"result":"`+balance+`"}`, resp)
+}
+
+func (s *ManagerTestSuite) TestStartNodeWithBootNodes() {
+	bootNodes := []string{
+		"enode://7ab298cedc4185a894d21d8a4615262ec6bdce66c9b6783878258e0d5b31013d30c9038932432f70e5b2b6a5cd323bf820554fcb22fbc7b45367889522e9c449@51.15.63.93:30303",
+		"enode://f59e8701f18c79c5cbc7618dc7bb928d44dc2f5405c7d693dad97da2d8585975942ec6fd36d3fe608bfdc7270a34a4dd00f38cfe96b2baa24f7cd0ac28d382a1@51.15.79.88:30303",
+	}
+
+	s.StartTestNode(params.RopstenNetworkID, func(config *params.NodeConfig) {
+		config.BootNodes = bootNodes
+	})
+	defer s.StopTestNode()
+
+	gethNode, err := s.NodeManager.Node()
+	s.NoError(err)
+
+	var bootstrapNodes, bootstrapNodesV5 []string
+	for _, n := range gethNode.Server().BootstrapNodes {
+		bootstrapNodes = append(bootstrapNodes, n.String())
+	}
+	for _, n := range gethNode.Server().BootstrapNodesV5 {
+		bootstrapNodesV5 = append(bootstrapNodesV5, n.String())
+	}
+	s.Equal(bootNodes, bootstrapNodes)
+	s.Equal(bootNodes, bootstrapNodesV5)
+}
+
+func (s *ManagerTestSuite) TestMakeNodeInvalidBootNodes() {
+	nodeConfig, err := e2e.MakeTestNodeConfig(params.RopstenNetworkID)
+	s.NoError(err)
+	nodeConfig.BootNodes = []string{"enode://foobar@51.15.62.116:30303"}
+
+	// invalid boot node is reported, even if config was not validated
+	_, err = node.MakeNode(nodeConfig)
+	s.Error(err)
+	s.Contains(err.Error(), params.ErrInvalidBootNode.Error())
+}
+
+func (s *ManagerTestSuite) TestPeers() {
+	_, err := s.NodeManager.PeerCount()
+	s.Equal(node.ErrNoRunningNode, err)
+
+	s.StartTestNode(params.RopstenNetworkID)
+	defer s.StopTestNode()
+
+	count, err := s.NodeManager.PeerCount()
+	s.NoError(err)
+
+	peers, err := s.NodeManager.Peers()
+	s.NoError(err)
+	s.Len(peers, count)
+}
+
+func (s *ManagerTestSuite) TestAddRemovePeer() {
+	s.StartTestNode(params.RopstenNetworkID)
+	defer s.StopTestNode()
+
+	enode := "enode://da3bf389a031f33fb55c9f5f54fde8473912402d27fffaa50efd74c0d0515f3a61daf6d52151f2876b19c15828e6f670352bff432b5ec457652e74755e8c864f@51.15.62.116:30303"
+	s.NoError(s.NodeManager.AddPeer(enode))
+	s.NoError(s.NodeManager.RemovePeer(enode))
+
+	invalidEnode := "enode://foobar@51.15.62.116:30303"
+	err := s.NodeManager.AddPeer(invalidEnode)
+	s.EqualError(err, "invalid enode URL: invalid node ID (encoding/hex: invalid byte: U+006F 'o')")
+	err = s.NodeManager.RemovePeer(invalidEnode)
+	s.EqualError(err, "invalid enode URL: invalid node ID (encoding/hex: invalid byte: U+006F 'o')")
+}
+
+func (s *ManagerTestSuite) TestNodeReady() {
+	nodeConfig, err := e2e.MakeTestNodeConfig(params.RopstenNetworkID)
+	s.NoError(err)
+
+	_, err = s.NodeManager.NodeReady()
+	s.Equal(node.ErrNoRunningNode, err)
+	s.False(s.NodeManager.IsNodeRunning())
+
+	_, err = s.NodeManager.StartNode(nodeConfig)
+	s.NoError(err)
+
+	nodeReady, err := s.NodeManager.NodeReady()
+	s.NoError(err)
+	<-nodeReady
+	s.True(s.NodeManager.IsNodeRunning())
+
+	nodeStopped, err := s.NodeManager.StopNode()
+	s.NoError(err)
+	s.False(s.NodeManager.IsNodeRunning(), "node should not be running once stop is requested")
+	<-nodeStopped
+	s.False(s.NodeManager.IsNodeRunning())
+
+	_, err = s.NodeManager.NodeReady()
+	s.Equal(node.ErrNoRunningNode, err)
+}
+
+func (s *ManagerTestSuite) TestStopNodeGracefully() {
+	s.StartTestNode(params.RopstenNetworkID)
+
+	client := s.NodeManager.RPCClient()
+	s.NotNil(client)
+
+	started := make(chan struct{})
+	client.RegisterHandler("slow_method", func(context.Context, ...interface{}) (interface{}, error) {
+		close(started)
+		time.Sleep(500 * time.Millisecond)
+		return "done", nil
+	})
+
+	finished := make(chan string, 1)
+	go func() {
+		finished <- client.CallRaw(`{"jsonrpc":"2.0","method":"slow_method","params":[],"id":1}`)
+	}()
+	<-started
+
+	s.NoError(s.NodeManager.StopNodeGracefully(5 * time.Second))
+	s.False(s.NodeManager.IsNodeRunning())
+
+	select {
+	case resp := <-finished:
+		s.Equal(`{"jsonrpc":"2.0","id":1,"result":"done"}`, resp)
+	case <-time.After(time.Second):
+		s.Fail("slow call should be finished before node is stopped")
+	}
+}
+
+func (s *ManagerTestSuite) TestSubscribeLifecycle() {
+	var (
+		first  = make(chan common.NodeEvent, 10)
+		second = make(chan common.NodeEvent, 10)
+	)
+	s.NodeManager.SubscribeLifecycle(func(e common.NodeEvent) { first <- e })
+	unsubscribe := s.NodeManager.SubscribeLifecycle(func(e common.NodeEvent) { second <- e })
+
+	s.StartTestNode(params.RopstenNetworkID)
+
+	for _, events := range []chan common.NodeEvent{first, second} {
+		s.Equal(common.StateStarting, (<-events).State)
+		s.Equal(common.StateReady, (<-events).State)
+	}
+
+	unsubscribe()
+	s.StopTestNode()
+
+	s.Equal(common.StateStopping, (<-first).State)
+	s.Equal(common.StateStopped, (<-first).State)
+	s.Len(second, 0, "unsubscribed listener should not be called")
+}
+
+func (s *ManagerTestSuite) TestStartNodeAsync() {
+	nodeConfig, err := e2e.MakeTestNodeConfig(params.RopstenNetworkID)
+	s.NoError(err)
+
+	events, err := s.NodeManager.StartNodeAsync(nodeConfig)
+	s.NoError(err)
+	s.Equal(common.NodeEvent{State: common.StateStarting}, <-events)
+	s.Equal(common.NodeEvent{State: common.StateReady}, <-events)
+	_, ok := <-events
+	s.False(ok, "events channel should be closed")
+	s.True(s.NodeManager.IsNodeRunning())
+
+	_, err = s.NodeManager.StartNodeAsync(nodeConfig)
+	s.Equal(node.ErrNodeExists, err)
+
+	nodeStopped, err := s.NodeManager.StopNode()
+	s.NoError(err)
+	<-nodeStopped
+}
+
+func (s *ManagerTestSuite) TestConcurrentStartNode() {
+	nodeConfig, err := e2e.MakeTestNodeConfig(params.RopstenNetworkID)
+	s.NoError(err)
+
+	var wg sync.WaitGroup
+	results := make(chan error, 2)
+	nodeStarted := make(chan (<-chan struct{}), 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			started, err := s.NodeManager.StartNode(nodeConfig)
+			if err == nil {
+				nodeStarted <- started
+			}
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	var errs []error
+	for err := range results {
+		errs = append(errs, err)
+	}
+	s.Contains(errs, nil, "one of the attempts should succeed")
+	s.Contains(errs, node.ErrNodeExists, "the other attempt should fail")
+
+	<-<-nodeStarted
+	s.True(s.NodeManager.IsNodeRunning())
+
+	nodeStopped, err := s.NodeManager.StopNode()
+	s.NoError(err)
+	<-nodeStopped
+}
+
+func (s *ManagerTestSuite) TestStartNodeAsyncFailure() {
+	nodeConfig, err := e2e.MakeTestNodeConfig(params.RopstenNetworkID)
+	s.NoError(err)
+
+	// data dir can't be created inside of a file
+	nodeConfig.DataDir = "/dev/null/status"
+
+	events, err := s.NodeManager.StartNodeAsync(nodeConfig)
+	s.NoError(err)
+	s.Equal(common.StateStarting, (<-events).State)
+
+	event := <-events
+	s.Equal(common.StateError, event.State)
+	s.Error(event.Error)
+	s.False(s.NodeManager.IsNodeRunning())
+}
+
 func (s *ManagerTestSuite) TestNetworkSwitching() {
 	// get Ropsten config
 	nodeConfig, err := e2e.MakeTestNodeConfig(params.RopstenNetworkID)
@@ -288,6 +605,232 @@ func (s *ManagerTestSuite) TestStartNodeWithUpstreamEnabled() {
 	<-nodeStopped
 }
 
+func (s *ManagerTestSuite) TestSyncProgress() {
+	s.StartTestNode(params.RinkebyNetworkID)
+	defer s.StopTestNode()
+
+	status, err := s.NodeManager.SyncProgress()
+	s.NoError(err)
+	s.NotNil(status)
+	s.True(status.HighestBlock >= status.CurrentBlock)
+	if status.Synced {
+		s.Equal(status.HighestBlock, status.CurrentBlock)
+	}
+}
+
+func (s *ManagerTestSuite) TestStartNodeWithPeersConfig() {
+	s.StartTestNode(params.RinkebyNetworkID, func(config *params.NodeConfig) {
+		config.MaxPeers = 3
+		config.NoDiscovery = true
+	})
+
+	n, err := s.NodeManager.Node()
+	s.NoError(err)
+	s.Equal(3, n.Server().MaxPeers)
+	s.False(n.Server().DiscoveryV5)
+	s.False(n.Server().NoDial)
+	s.StopTestNode()
+
+	// discovery is enabled by default
+	s.StartTestNode(params.RinkebyNetworkID)
+	n, err = s.NodeManager.Node()
+	s.NoError(err)
+	s.True(n.Server().DiscoveryV5)
+	s.StopTestNode()
+
+	// no peers at all, so there is nothing to discover or dial
+	s.StartTestNode(params.RinkebyNetworkID, func(config *params.NodeConfig) {
+		config.MaxPeers = 0
+	})
+	defer s.StopTestNode()
+
+	n, err = s.NodeManager.Node()
+	s.NoError(err)
+	s.Equal(0, n.Server().MaxPeers)
+	s.False(n.Server().DiscoveryV5)
+	s.True(n.Server().NoDial)
+}
+
+func (s *ManagerTestSuite) TestStartNodeInLightMode() {
+	s.StartTestNode(params.RinkebyNetworkID, func(config *params.NodeConfig) {
+		config.LightEthConfig.Enabled = false
+		config.LightMode = true
+	})
+	defer s.StopTestNode()
+
+	n, err := s.NodeManager.Node()
+	s.NoError(err)
+
+	var protocols []string
+	for _, protocol := range n.Server().Protocols {
+		protocols = append(protocols, protocol.Name)
+	}
+	s.Contains(protocols, "les")
+	s.True(n.Server().MaxPeers <= params.LightModeMaxPeers)
+
+	err = s.NodeManager.RPCClient().Call(nil, "eth_mining")
+	s.Equal(node.ErrUnsupportedInLightMode, err)
+}
+
+// whisper message codes used to talk to the mail server
+const (
+	whisperStatusCode     = 0
+	whisperP2PCode        = 2
+	whisperP2PRequestCode = 3
+)
+
+func (s *ManagerTestSuite) TestStartNodeWithMailServer() {
+	var mailServerDataDir string
+	s.StartTestNode(params.RopstenNetworkID, func(config *params.NodeConfig) {
+		config.LightEthConfig.Enabled = false // not needed, and LES stopped right after start fails -race
+		config.WhisperConfig.MailServerConfig.Enabled = true
+		config.WhisperConfig.MailServerConfig.Password = "status-offline-inbox"
+		s.Equal(filepath.Join(config.DataDir, params.WhisperDataDir), config.WhisperConfig.MailServerConfig.DataDir)
+		mailServerDataDir = config.WhisperConfig.MailServerConfig.DataDir
+	})
+	defer s.StopTestNode()
+
+	w, err := s.NodeManager.WhisperService()
+	s.Require().NoError(err)
+
+	// the envelope is archived once it's added to the pool
+	keyID, err := w.GenerateSymKey()
+	s.Require().NoError(err)
+	symKey, err := w.GetSymKey(keyID)
+	s.Require().NoError(err)
+	topic := whisper.BytesToTopic(symKey) // unique, as the archive survives between runs
+	archived := s.sealEnvelope(&whisper.MessageParams{
+		TTL:      60,
+		KeySym:   symKey,
+		Topic:    topic,
+		WorkTime: 5,
+		PoW:      0.01,
+		Payload:  []byte("hello"),
+	})
+	s.Require().NoError(w.Send(archived))
+	_, err = os.Stat(filepath.Join(mailServerDataDir, "CURRENT"))
+	s.NoError(err, "mail server database should be created")
+
+	// a peer requests envelopes of the topic sent within the last minute
+	peerKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	local, remote := p2p.MsgPipe()
+	defer remote.Close() // nolint: errcheck
+
+	peer := p2p.NewPeer(discover.PubkeyID(&peerKey.PublicKey), "stub", nil)
+	go w.Protocols()[0].Run(peer, local) // nolint: errcheck
+
+	msg, err := remote.ReadMsg()
+	s.Require().NoError(err)
+	s.Require().EqualValues(whisperStatusCode, msg.Code)
+	s.Require().NoError(msg.Discard())
+	s.Require().NoError(p2p.Send(remote, whisperStatusCode, whisper.ProtocolVersion))
+
+	mailServerKeyID, err := w.AddSymKeyFromPassword("status-offline-inbox")
+	s.Require().NoError(err)
+	mailServerKey, err := w.GetSymKey(mailServerKeyID)
+	s.Require().NoError(err)
+
+	now := uint32(time.Now().Unix())
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint32(payload, now-60)
+	binary.BigEndian.PutUint32(payload[4:], now+60)
+	request := s.sealEnvelope(&whisper.MessageParams{
+		TTL:      10,
+		Src:      peerKey,
+		KeySym:   mailServerKey,
+		WorkTime: 5,
+		PoW:      0.01,
+		Payload:  append(payload, topic[:]...),
+	})
+	s.Require().NoError(p2p.Send(remote, whisperP2PRequestCode, request))
+
+	for {
+		msg, err := remote.ReadMsg()
+		s.Require().NoError(err)
+		if msg.Code != whisperP2PCode {
+			s.Require().NoError(msg.Discard())
+			continue
+		}
+
+		var served whisper.Envelope
+		s.Require().NoError(msg.Decode(&served))
+		s.Equal(archived.Hash(), served.Hash())
+		return
+	}
+}
+
+func (s *ManagerTestSuite) sealEnvelope(messageParams *whisper.MessageParams) *whisper.Envelope {
+	sent, err := whisper.NewSentMessage(messageParams)
+	s.Require().NoError(err)
+	env, err := sent.Wrap(messageParams)
+	s.Require().NoError(err)
+	return env
+}
+
+func (s *ManagerTestSuite) TestHTTPCorsAndModules() {
+	s.StartTestNode(params.RopstenNetworkID, func(config *params.NodeConfig) {
+		config.LightEthConfig.Enabled = false // not needed, and LES stopped right after start fails -race
+		config.RPCEnabled = true
+		config.HTTPHost = "127.0.0.1"
+		config.HTTPCors = []string{"http://allowed.example"}
+		config.HTTPModules = []string{"eth", "net", "web3"}
+	})
+	defer s.StopTestNode()
+
+	call := func(origin, method string) (*http.Response, string) {
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"%s","params":[]}`, method)
+		req, err := http.NewRequest("POST", fmt.Sprintf("http://127.0.0.1:%d", TestConfig.Node.HTTPPort), strings.NewReader(body))
+		s.Require().NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", origin)
+
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		defer resp.Body.Close() // nolint: errcheck
+		data, err := ioutil.ReadAll(resp.Body)
+		s.Require().NoError(err)
+
+		return resp, string(data)
+	}
+
+	resp, body := call("http://allowed.example", "web3_clientVersion")
+	s.Equal("http://allowed.example", resp.Header.Get("Access-Control-Allow-Origin"))
+	s.Contains(body, `"result"`)
+
+	resp, _ = call("http://evil.example", "web3_clientVersion")
+	s.Empty(resp.Header.Get("Access-Control-Allow-Origin"))
+
+	// admin module is not exposed via HTTP unless listed
+	_, body = call("http://allowed.example", "admin_nodeInfo")
+	s.Contains(body, `"error"`)
+}
+
+func (s *ManagerTestSuite) TestWSEndpoint() {
+	s.StartTestNode(params.RopstenNetworkID, func(config *params.NodeConfig) {
+		config.LightEthConfig.Enabled = false // not needed, and LES stopped right after start fails -race
+		config.WSEnabled = true
+		config.WSHost = "127.0.0.1"
+		config.WSOrigins = []string{"http://allowed.example"}
+	})
+	defer s.StopTestNode()
+
+	endpoint := fmt.Sprintf("ws://127.0.0.1:%d", TestConfig.Node.WSPort)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := rpc.DialWebsocket(ctx, endpoint, "http://allowed.example")
+	s.Require().NoError(err)
+	defer client.Close()
+
+	var version string
+	s.NoError(client.CallContext(ctx, &version, "shh_version"))
+	s.Equal(whisper.ProtocolVersionStr, version)
+
+	_, err = rpc.DialWebsocket(ctx, endpoint, "http://evil.example")
+	s.Error(err)
+}
+
 // TODO(adam): fix this test to not use a different directory for blockchain data
 func (s *ManagerTestSuite) TestResetChainData() {
 	s.T().Skip()
@@ -311,12 +854,47 @@ func (s *ManagerTestSuite) TestResetChainData() {
 	s.Equal("0x6341fd3daf94b748c72ced5a5b26028f2474f5f00d824504e4fa37a75767e177", firstHash)
 }
 
+func (s *ManagerTestSuite) TestResetChainDataKeepsKeystore() {
+	dataDir, err := ioutil.TempDir("", "status-reset-chain-data")
+	s.NoError(err)
+	defer os.RemoveAll(dataDir) // nolint: errcheck
+
+	nodeConfig, err := e2e.MakeTestNodeConfigWith(params.RopstenNetworkID, func(config *params.NodeConfig) {
+		config.DataDir = dataDir
+		config.KeyStoreDir = filepath.Join(dataDir, "keystore")
+	})
+	s.NoError(err)
+
+	nodeStarted, err := s.NodeManager.StartNode(nodeConfig)
+	s.NoError(err)
+	<-nodeStarted
+
+	marker := filepath.Join(dataDir, nodeConfig.Name, "lightchaindata", "marker")
+	s.NoError(ioutil.WriteFile(marker, []byte("test"), 0600))
+	key := filepath.Join(nodeConfig.KeyStoreDir, "key")
+	s.NoError(ioutil.WriteFile(key, []byte("test"), 0600))
+
+	nodeReady, err := s.NodeManager.ResetChainData()
+	s.NoError(err)
+	<-nodeReady
+	s.True(s.NodeManager.IsNodeRunning())
+
+	_, err = os.Stat(marker)
+	s.True(os.IsNotExist(err), "chain data should be removed")
+	_, err = os.Stat(key)
+	s.NoError(err, "keystore should survive")
+
+	nodeStopped, err := s.NodeManager.StopNode()
+	s.NoError(err)
+	<-nodeStopped
+}
+
 func (s *ManagerTestSuite) TestRestartNode() {
 	s.StartTestNode(params.RinkebyNetworkID)
 	defer s.StopTestNode()
 
 	s.True(s.NodeManager.IsNodeRunning())
-	nodeReady, err := s.NodeManager.RestartNode()
+	nodeReady, err := s.NodeManager.RestartNode(nil)
 	s.NoError(err)
 	// new node, with previous config should be running
 	<-nodeReady
@@ -328,6 +906,86 @@ func (s *ManagerTestSuite) TestRestartNode() {
 	s.Equal("0x6341fd3daf94b748c72ced5a5b26028f2474f5f00d824504e4fa37a75767e177", firstHash)
 }
 
+func (s *ManagerTestSuite) TestRestartNodeDrainsRPCCalls() {
+	s.StartTestNode(params.RinkebyNetworkID)
+	defer s.StopTestNode()
+
+	client := s.NodeManager.RPCClient()
+	s.NotNil(client)
+
+	started := make(chan struct{})
+	client.RegisterHandler("slow_method", func(context.Context, ...interface{}) (interface{}, error) {
+		close(started)
+		time.Sleep(500 * time.Millisecond)
+		return "done", nil
+	})
+
+	finished := make(chan string, 1)
+	go func() {
+		finished <- client.CallRaw(`{"jsonrpc":"2.0","method":"slow_method","params":[],"id":1}`)
+	}()
+	<-started
+
+	nodeReady, err := s.NodeManager.RestartNode(nil)
+	s.NoError(err)
+
+	// in-flight call completes on the previous node
+	select {
+	case resp := <-finished:
+		s.Equal(`{"jsonrpc":"2.0","id":1,"result":"done"}`, resp)
+	case <-time.After(time.Second):
+		s.Fail("slow call should be finished before node is restarted")
+	}
+
+	// and new calls to the previous client are rejected
+	_, err = client.CallRawContext(context.Background(), `{"jsonrpc":"2.0","method":"net_version","params":[],"id":2}`)
+	s.Equal(statusrpc.ErrClientDraining, err)
+
+	<-nodeReady
+	s.True(s.NodeManager.IsNodeRunning())
+}
+
+func (s *ManagerTestSuite) TestRestartNodeWithConfig() {
+	s.StartTestNode(params.RinkebyNetworkID)
+	defer s.StopTestNode()
+
+	client := s.NodeManager.RPCClient()
+	s.NotNil(client)
+	s.Equal(`{"jsonrpc":"2.0","id":1,"result":"4"}`, client.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`))
+
+	nodeConfig, err := e2e.MakeTestNodeConfig(params.RopstenNetworkID)
+	s.NoError(err)
+	nodeReady, err := s.NodeManager.RestartNode(nodeConfig)
+	s.NoError(err)
+	<-nodeReady
+	s.True(s.NodeManager.IsNodeRunning())
+
+	client = s.NodeManager.RPCClient()
+	s.NotNil(client)
+	s.Equal(`{"jsonrpc":"2.0","id":1,"result":"3"}`, client.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`))
+}
+
+func (s *ManagerTestSuite) TestAllowedRPCMethodsAfterRestart() {
+	s.StartTestNode(params.RinkebyNetworkID, func(config *params.NodeConfig) {
+		config.AllowedRPCMethods = []string{"net_version"}
+	})
+	defer s.StopTestNode()
+
+	notAllowed := `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the method web3_clientVersion does not exist/is not available"}}`
+
+	client := s.NodeManager.RPCClient()
+	s.Equal(`{"jsonrpc":"2.0","id":1,"result":"4"}`, client.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`))
+	s.Equal(notAllowed, client.CallRaw(`{"jsonrpc":"2.0","method":"web3_clientVersion","params":[],"id":1}`))
+
+	nodeReady, err := s.NodeManager.RestartNode(nil)
+	s.NoError(err)
+	<-nodeReady
+
+	// new client still restricts calls
+	client = s.NodeManager.RPCClient()
+	s.Equal(notAllowed, client.CallRaw(`{"jsonrpc":"2.0","method":"web3_clientVersion","params":[],"id":1}`))
+}
+
 // TODO(adam): race conditions should be tested with -race flag and unit tests, if possible.
 // Research if it's possible to do the same with unit tests.
 func (s *ManagerTestSuite) TestRaceConditions() {
@@ -382,7 +1040,7 @@ func (s *ManagerTestSuite) TestRaceConditions() {
 		// },
 		func(config *params.NodeConfig) {
 			log.Info("RestartNode()")
-			_, err := s.NodeManager.RestartNode()
+			_, err := s.NodeManager.RestartNode(nil)
 			s.T().Logf("RestartNode(), error: %v", err)
 			progress <- struct{}{}
 		},
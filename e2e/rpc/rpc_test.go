@@ -2,8 +2,6 @@ package rpc
 
 import (
 	"context"
-	"encoding/json"
-	"net/http"
 	"sync"
 	"testing"
 	"time"
@@ -12,24 +10,10 @@ import (
 	"github.com/status-im/status-go/e2e"
 	"github.com/status-im/status-go/geth/node"
 	"github.com/status-im/status-go/geth/params"
+	. "github.com/status-im/status-go/testing"
 	"github.com/stretchr/testify/suite"
 )
 
-type txRequest struct {
-	Method  string          `json:"method"`
-	Version string          `json:"jsonrpc"`
-	ID      int             `json:"id,omitempty"`
-	Payload json.RawMessage `json:"params,omitempty"`
-}
-
-type service struct {
-	Handler http.HandlerFunc
-}
-
-func (s service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	s.Handler(w, r)
-}
-
 func TestRPCTestSuite(t *testing.T) {
 	suite.Run(t, new(RPCTestSuite))
 }
@@ -45,17 +29,17 @@ func (s *RPCTestSuite) SetupTest() {
 
 func (s *RPCTestSuite) TestCallRPC() {
 	for _, upstreamEnabled := range []bool{false, true} {
-		nodeConfig, err := e2e.MakeTestNodeConfig(params.RinkebyNetworkID)
-		s.NoError(err)
+		nodeConfig, err := e2e.MakeTestNodeConfigWith(params.RinkebyNetworkID, func(config *params.NodeConfig) {
+			config.IPCEnabled = false
+			config.WSEnabled = false
+			config.HTTPHost = "" // to make sure that no HTTP interface is started
 
-		nodeConfig.IPCEnabled = false
-		nodeConfig.WSEnabled = false
-		nodeConfig.HTTPHost = "" // to make sure that no HTTP interface is started
-
-		if upstreamEnabled {
-			nodeConfig.UpstreamConfig.Enabled = true
-			nodeConfig.UpstreamConfig.URL = "https://rinkeby.infura.io/nKmXgiFgc2KqtoQ8BCGJ"
-		}
+			if upstreamEnabled {
+				config.UpstreamConfig.Enabled = true
+				config.UpstreamConfig.URL = "https://rinkeby.infura.io/nKmXgiFgc2KqtoQ8BCGJ"
+			}
+		})
+		s.NoError(err)
 
 		nodeStarted, err := s.NodeManager.StartNode(nodeConfig)
 		s.NoError(err)
@@ -152,6 +136,20 @@ func (s *RPCTestSuite) TestCallRawResult() {
 	s.NodeManager.StopNode()
 }
 
+// TestCallMap checks if fields of a response can be checked separately.
+func (s *RPCTestSuite) TestCallMap() {
+	s.StartTestNode(params.RinkebyNetworkID)
+	defer s.StopTestNode()
+
+	client := s.NodeManager.RPCClient()
+	s.NotNil(client)
+
+	resp, err := client.CallMap(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":67}`)
+	s.NoError(err)
+	s.Equal("4", resp["result"])
+	s.EqualValues(67, resp["id"])
+}
+
 // TestCallContextResult checks if result passed to CallContext
 // is set accordingly to its underlying memory layout.
 func (s *RPCTestSuite) TestCallContextResult() {
@@ -172,3 +170,124 @@ func (s *RPCTestSuite) TestCallContextResult() {
 	s.NoError(err)
 	s.True(blockNumber > 0, "blockNumber should be higher than 0")
 }
+
+// TestRouteLocal checks if methods matching local rules are served
+// by the embedded node even if upstream is enabled.
+func (s *RPCTestSuite) TestRouteLocal() {
+	upstream := NewMockUpstream(map[string]string{
+		"net_version": `"upstream"`,
+		"shh_info":    `"upstream"`,
+		"shh_version": `"upstream"`,
+	})
+	defer upstream.Close()
+
+	s.StartTestNode(params.RopstenNetworkID, e2e.WithUpstream(upstream.URL()))
+	defer s.StopTestNode()
+
+	client := s.NodeManager.RPCClient()
+	s.NotNil(client)
+
+	client.RouteUpstream("shh_")
+
+	jsonResult := client.CallRaw(`{"jsonrpc":"2.0","method":"shh_version","params":[],"id":67}`)
+	s.Equal(`{"jsonrpc":"2.0","id":67,"result":"upstream"}`, jsonResult)
+
+	client.RouteLocal("shh_version")
+
+	jsonResult = client.CallRaw(`{"jsonrpc":"2.0","method":"shh_version","params":[],"id":67}`)
+	s.Equal(`{"jsonrpc":"2.0","id":67,"result":"5.0"}`, jsonResult)
+}
+
+// TestCallRawLocal checks if CallRawLocal is served by the embedded node
+// even if upstream is enabled and the method is routed to the upstream.
+func (s *RPCTestSuite) TestCallRawLocal() {
+	upstream := NewMockUpstream(map[string]string{
+		"shh_version": `"upstream"`,
+	})
+	defer upstream.Close()
+
+	s.StartTestNode(params.RopstenNetworkID, e2e.WithUpstream(upstream.URL()), func(config *params.NodeConfig) {
+		config.UpstreamConfig.RouteUpstream = []string{"shh_"}
+	})
+	defer s.StopTestNode()
+
+	client := s.NodeManager.RPCClient()
+	s.NotNil(client)
+
+	jsonResult := client.CallRaw(`{"jsonrpc":"2.0","method":"shh_version","params":[],"id":67}`)
+	s.Equal(`{"jsonrpc":"2.0","id":67,"result":"upstream"}`, jsonResult)
+
+	jsonResult = client.CallRawLocal(`{"jsonrpc":"2.0","method":"shh_version","params":[],"id":67}`)
+	s.Equal(`{"jsonrpc":"2.0","id":67,"result":"5.0"}`, jsonResult)
+	s.Len(upstream.Requests(), 1)
+}
+
+// TestRouteRulesAfterRestart checks if routing rules given in the config
+// are applied to the client of a restarted node.
+func (s *RPCTestSuite) TestRouteRulesAfterRestart() {
+	upstream := NewMockUpstream(map[string]string{
+		"net_version": `"upstream"`,
+		"shh_info":    `"upstream"`,
+		"shh_version": `"upstream"`,
+	})
+	defer upstream.Close()
+
+	s.StartTestNode(params.RopstenNetworkID, e2e.WithUpstream(upstream.URL()), func(config *params.NodeConfig) {
+		config.UpstreamConfig.RouteUpstream = []string{"shh_"}
+		config.UpstreamConfig.RouteLocal = []string{"shh_version"}
+	})
+	defer s.StopTestNode()
+
+	checkRoutes := func() {
+		client := s.NodeManager.RPCClient()
+		s.NotNil(client)
+
+		jsonResult := client.CallRaw(`{"jsonrpc":"2.0","method":"shh_info","params":[],"id":67}`)
+		s.Equal(`{"jsonrpc":"2.0","id":67,"result":"upstream"}`, jsonResult)
+
+		jsonResult = client.CallRaw(`{"jsonrpc":"2.0","method":"shh_version","params":[],"id":67}`)
+		s.Equal(`{"jsonrpc":"2.0","id":67,"result":"5.0"}`, jsonResult)
+	}
+
+	checkRoutes()
+
+	nodeReady, err := s.NodeManager.RestartNode(nil)
+	s.NoError(err)
+	<-nodeReady
+
+	checkRoutes()
+}
+
+// TestRateLimitsAfterRestart checks if rate limits given in the config
+// are applied to the client of a restarted node.
+func (s *RPCTestSuite) TestRateLimitsAfterRestart() {
+	upstream := NewMockUpstream(map[string]string{
+		"eth_blockNumber": `"upstream"`,
+	})
+	defer upstream.Close()
+
+	s.StartTestNode(params.RopstenNetworkID, e2e.WithUpstream(upstream.URL()), func(config *params.NodeConfig) {
+		config.UpstreamConfig.RateLimits = map[string]int{"eth_blockNumber": 1}
+		config.UpstreamConfig.FailOnRateLimit = true
+	})
+	defer s.StopTestNode()
+
+	checkLimits := func() {
+		client := s.NodeManager.RPCClient()
+		s.NotNil(client)
+
+		jsonResult := client.CallRaw(`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":67}`)
+		s.Equal(`{"jsonrpc":"2.0","id":67,"result":"upstream"}`, jsonResult)
+
+		jsonResult = client.CallRaw(`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":67}`)
+		s.Equal(`{"jsonrpc":"2.0","id":67,"error":{"code":-32005,"message":"rate limit of method eth_blockNumber exceeded"}}`, jsonResult)
+	}
+
+	checkLimits()
+
+	nodeReady, err := s.NodeManager.RestartNode(nil)
+	s.NoError(err)
+	<-nodeReady
+
+	checkLimits()
+}
@@ -1,14 +1,20 @@
 package e2e
 
 import (
+	"time"
+
 	"github.com/ethereum/go-ethereum/les"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
 	"github.com/status-im/status-go/geth/api"
 	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/signal"
+	. "github.com/status-im/status-go/testing"
 	"github.com/stretchr/testify/suite"
 )
 
+// nodeStartTimeout is how long test suites wait for a started node.
+const nodeStartTimeout = time.Minute
+
 // NodeManagerTestSuite defines a test suit with NodeManager.
 type NodeManagerTestSuite struct {
 	suite.Suite
@@ -33,7 +39,7 @@ func (s *NodeManagerTestSuite) StartTestNode(networkID int, opts ...TestNodeOpti
 	nodeStarted, err := s.NodeManager.StartNode(nodeConfig)
 	s.NoError(err)
 	s.NotNil(nodeStarted)
-	<-nodeStarted
+	s.NoError(WaitForNodeStart(nodeStarted, nodeStartTimeout))
 	s.True(s.NodeManager.IsNodeRunning())
 }
 
@@ -82,7 +88,7 @@ func (s *BackendTestSuite) StartTestBackend(networkID int, opts ...TestNodeOptio
 	s.False(s.Backend.IsNodeRunning())
 	nodeStarted, err := s.Backend.StartNode(nodeConfig)
 	s.NoError(err)
-	<-nodeStarted
+	s.NoError(WaitForNodeStart(nodeStarted, nodeStartTimeout))
 	s.True(s.Backend.IsNodeRunning())
 }
 
@@ -55,6 +55,18 @@ func MakeTestNodeConfig(networkID int) (*params.NodeConfig, error) {
 	return nodeConfig, nil
 }
 
+// MakeTestNodeConfigWith works as MakeTestNodeConfig, with the returned config
+// altered by override.
+func MakeTestNodeConfigWith(networkID int, override func(*params.NodeConfig)) (*params.NodeConfig, error) {
+	nodeConfig, err := MakeTestNodeConfig(networkID)
+	if err != nil {
+		return nil, err
+	}
+
+	override(nodeConfig)
+	return nodeConfig, nil
+}
+
 // FirstBlockHash validates Attach operation for the NodeManager.
 func FirstBlockHash(nodeManager common.NodeManager) (string, error) {
 	// obtain RPC client for running node
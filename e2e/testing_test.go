@@ -0,0 +1,28 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeTestNodeConfigWith(t *testing.T) {
+	config, err := MakeTestNodeConfigWith(params.RinkebyNetworkID, func(config *params.NodeConfig) {
+		config.IPCEnabled = false
+		config.WSEnabled = false
+		config.HTTPHost = ""
+	})
+	require.NoError(t, err)
+
+	require.False(t, config.IPCEnabled)
+	require.False(t, config.WSEnabled)
+	require.Empty(t, config.HTTPHost)
+
+	// everything else is left as in the baseline config
+	baseline, err := MakeTestNodeConfig(params.RinkebyNetworkID)
+	require.NoError(t, err)
+	require.Equal(t, baseline.NetworkID, config.NetworkID)
+	require.Equal(t, baseline.DataDir, config.DataDir)
+	require.Equal(t, baseline.HTTPPort, config.HTTPPort)
+}
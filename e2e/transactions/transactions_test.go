@@ -757,7 +757,7 @@ func (s *TransactionsTestSuite) TestNonExistentQueuedTransactions() {
 	s.EqualError(err, txqueue.ErrQueuedTxIDNotFound.Error())
 }
 
-func (s *TransactionsTestSuite) TestEvictionOfQueuedTransactions() {
+func (s *TransactionsTestSuite) TestQueuedTransactionsCapacity() {
 	s.StartTestBackend(params.RopstenNetworkID)
 	defer s.StopTestBackend()
 
@@ -796,7 +796,9 @@ func (s *TransactionsTestSuite) TestEvictionOfQueuedTransactions() {
 	}
 	time.Sleep(3 * time.Second)
 
-	s.True(txQueue.Count() <= txqueue.DefaultTxQueueCap, "transaction count should be %d (or %d): got %d", txqueue.DefaultTxQueueCap, txqueue.DefaultTxQueueCap-1, txQueue.Count())
+	// transactions above the capacity are rejected
+	s.Equal(txqueue.DefaultTxQueueCap, txQueue.Count(), "transaction count should be %d: got %d", txqueue.DefaultTxQueueCap, txQueue.Count())
+	s.Equal(txqueue.DefaultTxQueueCap, i)
 
 	for _, txID := range txIDs {
 		txQueue.Remove(txID)
@@ -3,11 +3,14 @@ package whisper
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
 	"github.com/status-im/status-go/e2e"
 	"github.com/status-im/status-go/geth/account"
+	"github.com/status-im/status-go/geth/delivery"
+	"github.com/status-im/status-go/geth/messaging"
 	"github.com/status-im/status-go/geth/node"
 	"github.com/status-im/status-go/geth/params"
 	. "github.com/status-im/status-go/testing"
@@ -116,3 +119,37 @@ func (s *WhisperTestSuite) TestLogout() {
 	s.NoError(accountManager.Logout())
 	s.False(whisperService.HasKeyPair(pubKey), "identity not cleared from whisper")
 }
+
+func (s *WhisperTestSuite) TestPostReportsQueued() {
+	s.StartTestNode(params.RinkebyNetworkID)
+	defer s.StopTestNode()
+
+	whisperService, err := s.NodeManager.WhisperService()
+	s.NoError(err)
+	n, err := s.NodeManager.Node()
+	s.NoError(err)
+
+	notifier := s.NodeManager.DeliveryNotification()
+	s.NotNil(notifier)
+	states, id := notifier.SubscribeChan()
+	defer notifier.Unsubscribe(id)
+
+	key, err := crypto.GenerateKey()
+	s.NoError(err)
+
+	messenger := messaging.New(whisperService, n.Server(), notifier)
+	hash, err := messenger.Post(messaging.WhisperMessage{
+		Topic:     whisper.BytesToTopic([]byte("test")),
+		Payload:   []byte("hello"),
+		PublicKey: &key.PublicKey,
+	})
+	s.NoError(err)
+
+	select {
+	case state := <-states:
+		s.Equal(delivery.StatusQueued, state.Status)
+		s.Equal(hash, state.Envelope.Hash().Hex())
+	case <-time.After(time.Second):
+		s.Fail("timed out waiting for queued status")
+	}
+}
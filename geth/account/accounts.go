@@ -6,12 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/status-im/status-go/extkeys"
 	"github.com/status-im/status-go/geth/common"
@@ -26,18 +31,42 @@ var (
 	ErrWhisperClearIdentitiesFailure   = errors.New("failed to clear whisper identities")
 	ErrNoAccountSelected               = errors.New("no account has been selected, please login")
 	ErrInvalidMasterKeyCreated         = errors.New("can not create master extended key")
+	ErrInvalidPrivateKey               = errors.New("invalid private key")
+	ErrInvalidSignParams               = errors.New("eth_sign expects an address and data to sign")
+	ErrInvalidPersonalSignParams       = errors.New("personal_sign expects data to sign and an address")
+	ErrInvalidNewAccountParams         = errors.New("personal_newAccount expects a password")
+	ErrInvalidUnlockParams             = errors.New("personal_unlockAccount expects an address, a password and an optional duration")
+	ErrUnlockDurationTooLarge          = errors.New("unlock duration too large")
+	ErrSignerNotSelected               = errors.New("only the selected account or unlocked accounts can sign")
 )
 
+// defaultUnlockDuration is how long personal_unlockAccount unlocks an account for,
+// if no duration is given.
+const defaultUnlockDuration = 300 * time.Second
+
+// AccountNotFoundError is returned if there is no key file of an account in a keystore.
+type AccountNotFoundError struct {
+	Address gethcommon.Address
+}
+
+func (e AccountNotFoundError) Error() string {
+	return fmt.Sprintf("cannot locate account for address: %s", e.Address.Hex())
+}
+
 // Manager represents account manager interface
 type Manager struct {
 	nodeManager     common.NodeManager
 	selectedAccount *common.SelectedExtKey // account that was processed during the last call to SelectAccount()
+
+	mu       sync.Mutex // guards unlocked
+	unlocked map[gethcommon.Address]struct{}
 }
 
 // NewManager returns new node account manager
 func NewManager(nodeManager common.NodeManager) *Manager {
 	return &Manager{
 		nodeManager: nodeManager,
+		unlocked:    make(map[gethcommon.Address]struct{}),
 	}
 }
 
@@ -144,8 +173,34 @@ func (m *Manager) RecoverAccount(password, mnemonic string) (address, pubKey str
 	return address, pubKey, nil
 }
 
+// ImportAccount imports an account given by a hex-encoded private key into keystore,
+// encrypting its key file with a given password. As there is no extended key behind
+// an imported account, sub-accounts can't be derived from it.
+func (m *Manager) ImportAccount(privateKeyHex, password string) (address, pubKey string, err error) {
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return "", "", err
+	}
+
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %v", ErrInvalidPrivateKey.Error(), err)
+	}
+
+	account, err := keyStore.ImportECDSA(privateKey, password)
+	if err != nil {
+		return "", "", err
+	}
+
+	return account.Address.Hex(), gethcommon.ToHex(crypto.FromECDSAPub(&privateKey.PublicKey)), nil
+}
+
 // VerifyAccountPassword tries to decrypt a given account key file, with a provided password.
-// If no error is returned, then account is considered verified.
+// If no error is returned, then account is considered verified. Key file is read directly,
+// so that keystore is not affected (e.g. no account is unlocked).
+//
+// AccountNotFoundError is returned if there is no key file of the account,
+// and keystore.ErrDecrypt if the password is wrong.
 func (m *Manager) VerifyAccountPassword(keyStoreDir, address, password string) (*keystore.Key, error) {
 	var err error
 	var foundKeyFile []byte
@@ -186,7 +241,7 @@ func (m *Manager) VerifyAccountPassword(keyStoreDir, address, password string) (
 	}
 
 	if len(foundKeyFile) == 0 {
-		return nil, fmt.Errorf("cannot locate account for address: %s", addressObj.Hex())
+		return nil, AccountNotFoundError{Address: addressObj}
 	}
 
 	key, err := keystore.DecryptKey(foundKeyFile, password)
@@ -204,7 +259,7 @@ func (m *Manager) VerifyAccountPassword(keyStoreDir, address, password string) (
 
 // SelectAccount selects current account, by verifying that address has corresponding account which can be decrypted
 // using provided password. Once verification is done, decrypted key is injected into Whisper (as a single identity,
-// all previous identities are removed).
+// all previous identities are removed), and used to sign data with eth_sign and personal_sign (see SignRPCHandler).
 func (m *Manager) SelectAccount(address, password string) error {
 	keyStore, err := m.nodeManager.AccountKeyStore()
 	if err != nil {
@@ -239,6 +294,7 @@ func (m *Manager) SelectAccount(address, password string) error {
 		Address:     account.Address,
 		AccountKey:  accountKey,
 		SubAccounts: subAccounts,
+		SelectedAt:  time.Now(),
 	}
 
 	return nil
@@ -252,6 +308,26 @@ func (m *Manager) SelectedAccount() (*common.SelectedExtKey, error) {
 	return m.selectedAccount, nil
 }
 
+// SelectedAccountInfo returns address, public key and selection time of currently
+// selected account, or ErrNoAccountSelected if none is selected (e.g. after Logout).
+// Unlike SelectedAccount, it doesn't expose keys, so it's safe to pass it to UIs.
+func (m *Manager) SelectedAccountInfo() (*common.SelectedAccount, error) {
+	selectedAccount, err := m.SelectedAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &common.SelectedAccount{
+		Address:    selectedAccount.Address,
+		SelectedAt: selectedAccount.SelectedAt,
+	}
+	if key := selectedAccount.AccountKey; key != nil {
+		info.PublicKey = gethcommon.ToHex(crypto.FromECDSAPub(&key.PrivateKey.PublicKey))
+	}
+
+	return info, nil
+}
+
 // ReSelectAccount selects previously selected account, often, after node restart.
 func (m *Manager) ReSelectAccount() error {
 	selectedAccount := m.selectedAccount
@@ -271,8 +347,13 @@ func (m *Manager) ReSelectAccount() error {
 	return nil
 }
 
-// Logout clears whisper identities
+// Logout clears whisper identities and the selected account, and locks accounts
+// unlocked with personal_unlockAccount, so that nothing can be signed
 func (m *Manager) Logout() error {
+	if err := m.lockUnlockedAccounts(); err != nil {
+		return err
+	}
+
 	whisperService, err := m.nodeManager.WhisperService()
 	if err != nil {
 		return err
@@ -359,6 +440,213 @@ func (m *Manager) AccountsRPCHandler() rpc.Handler {
 	}
 }
 
+// SignRPCHandler returns RPC Handler for the eth_sign method. Data is signed with
+// the key of the currently selected account, so it fails with ErrNoAccountSelected
+// unless an account is selected, and keys in the node's keystore don't have to be unlocked.
+// Other accounts can sign only while unlocked with personal_unlockAccount.
+func (m *Manager) SignRPCHandler() rpc.Handler {
+	return func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		var (
+			address gethcommon.Address
+			data    hexutil.Bytes
+		)
+		if err := unmarshalArgs(args, &address, &data); err != nil {
+			return nil, fmt.Errorf("%s: %v", ErrInvalidSignParams.Error(), err)
+		}
+
+		return m.sign(address, data)
+	}
+}
+
+// PersonalSignRPCHandler returns RPC Handler for the personal_sign method. It signs
+// the same way as eth_sign, but takes data first and the address second. The optional
+// password is ignored, as the key of the selected account is already decrypted.
+func (m *Manager) PersonalSignRPCHandler() rpc.Handler {
+	return func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		var (
+			data    hexutil.Bytes
+			address gethcommon.Address
+		)
+		if len(args) == 3 {
+			args = args[:2]
+		}
+		if err := unmarshalArgs(args, &data, &address); err != nil {
+			return nil, fmt.Errorf("%s: %v", ErrInvalidPersonalSignParams.Error(), err)
+		}
+
+		return m.sign(address, data)
+	}
+}
+
+// sign signs data prefixed with the Ethereum message header using the key of
+// the given address, which has to be either the selected account or an account
+// unlocked in the keystore (see UnlockAccountRPCHandler).
+func (m *Manager) sign(address gethcommon.Address, data []byte) (hexutil.Bytes, error) {
+	hash := signHash(data)
+
+	selectedAccount, selectedErr := m.SelectedAccount()
+
+	var (
+		signature []byte
+		err       error
+	)
+	if selectedErr == nil && selectedAccount.Address == address {
+		signature, err = crypto.Sign(hash, selectedAccount.AccountKey.PrivateKey)
+	} else {
+		keyStore, ksErr := m.nodeManager.AccountKeyStore()
+		if ksErr != nil {
+			return nil, ksErr
+		}
+
+		signature, err = keyStore.SignHash(accounts.Account{Address: address}, hash)
+		if err == keystore.ErrLocked {
+			if selectedErr != nil {
+				return nil, selectedErr
+			}
+			return nil, ErrSignerNotSelected
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27 // transform V from 0/1 to 27/28 according to the yellow paper
+
+	return hexutil.Bytes(signature), nil
+}
+
+// NewAccountRPCHandler returns RPC Handler for the personal_newAccount method.
+// The account is created the same way as by CreateAccount, and its address is returned.
+func (m *Manager) NewAccountRPCHandler() rpc.Handler {
+	return func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		var password string
+		if err := unmarshalArgs(args, &password); err != nil {
+			return nil, fmt.Errorf("%s: %v", ErrInvalidNewAccountParams.Error(), err)
+		}
+
+		address, _, _, err := m.CreateAccount(password)
+		if err != nil {
+			return nil, err
+		}
+
+		return gethcommon.HexToAddress(address), nil
+	}
+}
+
+// UnlockAccountRPCHandler returns RPC Handler for the personal_unlockAccount method.
+// The account is unlocked in the node's keystore for a duration in seconds (300 if not
+// given, until the node is stopped if 0), so that it can sign with eth_sign and
+// personal_sign, even if it is not selected. It is relocked automatically afterwards,
+// or on Logout.
+func (m *Manager) UnlockAccountRPCHandler() rpc.Handler {
+	return func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		var (
+			address  gethcommon.Address
+			password string
+			duration *uint64
+		)
+		values := []interface{}{&address, &password, &duration}
+		if len(args) == 2 {
+			values = values[:2]
+		}
+		if err := unmarshalArgs(args, values...); err != nil {
+			return nil, fmt.Errorf("%s: %v", ErrInvalidUnlockParams.Error(), err)
+		}
+
+		timeout := defaultUnlockDuration
+		if duration != nil {
+			if *duration > uint64(math.MaxInt64/int64(time.Second)) {
+				return nil, ErrUnlockDurationTooLarge
+			}
+			timeout = time.Duration(*duration) * time.Second
+		}
+
+		keyStore, err := m.nodeManager.AccountKeyStore()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := keyStore.TimedUnlock(accounts.Account{Address: address}, password, timeout); err != nil {
+			return nil, err
+		}
+
+		m.mu.Lock()
+		m.unlocked[address] = struct{}{}
+		m.mu.Unlock()
+
+		return true, nil
+	}
+}
+
+// lockUnlockedAccounts locks accounts unlocked with personal_unlockAccount in the node's keystore.
+func (m *Manager) lockUnlockedAccounts() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.unlocked) == 0 {
+		return nil
+	}
+
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return err
+	}
+
+	for address := range m.unlocked {
+		if err := keyStore.Lock(address); err != nil {
+			return err
+		}
+		delete(m.unlocked, address)
+	}
+
+	return nil
+}
+
+// ListAccountsRPCHandler returns RPC Handler for the personal_listAccounts method.
+// Unlike eth_accounts, it lists all accounts in the node's keystore.
+func (m *Manager) ListAccountsRPCHandler() rpc.Handler {
+	return func(context.Context, ...interface{}) (interface{}, error) {
+		keyStore, err := m.nodeManager.AccountKeyStore()
+		if err != nil {
+			return nil, err
+		}
+
+		addresses := make([]gethcommon.Address, 0)
+		for _, account := range keyStore.Accounts() {
+			addresses = append(addresses, account.Address)
+		}
+
+		return addresses, nil
+	}
+}
+
+// signHash is a helper function that calculates a hash for the given message that can be
+// safely used to calculate a signature from, the same way as eth_sign of go-ethereum does.
+func signHash(data []byte) []byte {
+	msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(data), data)
+	return crypto.Keccak256([]byte(msg))
+}
+
+// unmarshalArgs decodes RPC handler arguments into given values. Arguments are either
+// Go values (if called with Call) or decoded JSON (if called with CallRaw), so they are
+// re-encoded to JSON first.
+func unmarshalArgs(args []interface{}, values ...interface{}) error {
+	if len(args) != len(values) {
+		return fmt.Errorf("expected %d arguments, got %d", len(values), len(args))
+	}
+
+	for i, arg := range args {
+		raw, err := json.Marshal(arg)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, values[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // refreshSelectedAccount re-populates list of sub-accounts of the currently selected account (if any)
 func (m *Manager) refreshSelectedAccount() {
 	if m.selectedAccount == nil {
@@ -379,6 +667,7 @@ func (m *Manager) refreshSelectedAccount() {
 		Address:     m.selectedAccount.Address,
 		AccountKey:  m.selectedAccount.AccountKey,
 		SubAccounts: subAccounts,
+		SelectedAt:  m.selectedAccount.SelectedAt,
 	}
 }
 
@@ -392,7 +681,7 @@ func (m *Manager) findSubAccounts(extKey *extkeys.ExtendedKey, subAccountIndex u
 	}
 
 	subAccounts := make([]accounts.Account, 0)
-	if extKey.Depth == 5 { // CKD#2 level
+	if extKey != nil && extKey.Depth == 5 { // CKD#2 level, imported accounts have no extended key
 		// gather possible sub-account addresses
 		subAccountAddresses := make([]gethcommon.Address, 0)
 		for i := uint32(0); i < subAccountIndex; i++ {
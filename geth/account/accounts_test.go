@@ -1,7 +1,6 @@
 package account_test
 
 import (
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -9,7 +8,10 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/golang/mock/gomock"
 	"github.com/status-im/status-go/geth/account"
 	"github.com/status-im/status-go/geth/common"
 	. "github.com/status-im/status-go/testing"
@@ -58,21 +60,21 @@ func TestVerifyAccountPassword(t *testing.T) {
 			emptyKeyStoreDir,
 			TestConfig.Account1.Address,
 			TestConfig.Account1.Password,
-			fmt.Errorf("cannot locate account for address: %s", account1Address.Hex()),
+			account.AccountNotFoundError{Address: account1Address},
 		},
 		{
 			"wrong address, correct password",
 			keyStoreDir,
 			"0x79791d3e8f2daa1f7fec29649d152c0ada3cc535",
 			TestConfig.Account1.Password,
-			fmt.Errorf("cannot locate account for address: %s", "0x79791d3E8F2dAa1F7FeC29649d152c0aDA3cc535"),
+			account.AccountNotFoundError{Address: gethcommon.HexToAddress("0x79791d3E8F2dAa1F7FeC29649d152c0aDA3cc535")},
 		},
 		{
 			"correct address, wrong password",
 			keyStoreDir,
 			TestConfig.Account1.Address,
 			"wrong password", // wrong password
-			errors.New("could not decrypt key with given passphrase"),
+			keystore.ErrDecrypt,
 		},
 	}
 	for _, testCase := range testCases {
@@ -109,3 +111,56 @@ func TestVerifyAccountPasswordWithAccountBeforeEIP55(t *testing.T) {
 	_, err = acctManager.VerifyAccountPassword(keyStoreDir, address.Hex(), TestConfig.Account1.Password)
 	require.NoError(t, err)
 }
+
+// TestRecoverAccountIntoFreshKeyStore checks if an account recovered from
+// its mnemonic on another device (i.e. with a different keystore) is the same.
+func TestRecoverAccountIntoFreshKeyStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newManager := func() (*account.Manager, func()) {
+		keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+		require.NoError(t, err)
+
+		nodeManager := common.NewMockNodeManager(ctrl)
+		nodeManager.EXPECT().AccountKeyStore().Return(
+			keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP), nil,
+		).AnyTimes()
+
+		return account.NewManager(nodeManager), func() { os.RemoveAll(keyStoreDir) } // nolint: errcheck
+	}
+
+	acctManager, cleanup := newManager()
+	defer cleanup()
+
+	address, pubKey, mnemonic, err := acctManager.CreateAccount(TestConfig.Account1.Password)
+	require.NoError(t, err)
+
+	recoveringManager, cleanup := newManager()
+	defer cleanup()
+
+	for i := 0; i < 2; i++ {
+		recoveredAddress, recoveredPubKey, err := recoveringManager.RecoverAccount(TestConfig.Account1.Password, mnemonic)
+		require.NoError(t, err)
+		require.Equal(t, address, recoveredAddress)
+		require.Equal(t, pubKey, recoveredPubKey)
+	}
+}
+
+// TestVerifyAccountPasswordKeepsAccountLocked checks that verifying a password
+// doesn't unlock the account in keystore.
+func TestVerifyAccountPasswordKeepsAccountLocked(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir)
+
+	require.NoError(t, common.ImportTestAccount(keyStoreDir, "test-account1.pk"))
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	acctManager := account.NewManager(nil)
+	_, err = acctManager.VerifyAccountPassword(keyStoreDir, TestConfig.Account1.Address, TestConfig.Account1.Password)
+	require.NoError(t, err)
+
+	_, err = keyStore.SignHash(accounts.Account{Address: gethcommon.HexToAddress(TestConfig.Account1.Address)}, make([]byte, 32))
+	require.Equal(t, keystore.ErrLocked, err)
+}
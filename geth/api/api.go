@@ -115,6 +115,19 @@ func (api *StatusAPI) CallRPC(inputJSON string) string {
 	return api.b.CallRPC(inputJSON)
 }
 
+// CallRPCErr executes RPC request on node's in-proc RPC server and returns
+// an error if the request is malformed, could not be sent or the node is not started,
+// in which case rpc.ErrNoLocalNode is returned.
+func (api *StatusAPI) CallRPCErr(inputJSON string) (string, error) {
+	return api.b.CallRPCErr(inputJSON)
+}
+
+// CallInto executes a call of the RPC method with given params on node's in-proc RPC server
+// and unmarshals its result into out, so that callers don't need to handle JSON-RPC messages.
+func (api *StatusAPI) CallInto(method string, params []interface{}, out interface{}) error {
+	return api.b.CallInto(method, params, out)
+}
+
 // CreateAccount creates an internal geth account
 // BIP44-compatible keys are generated: CKD#1 is stored as account key, CKD#2 stored as sub-account root
 // Public key of CKD#1 is returned, with CKD#2 securely encoded into account key file (to be used for
@@ -179,6 +192,11 @@ func (api *StatusAPI) DiscardTransactions(ids []common.QueuedTxID) map[common.Qu
 	return api.b.txQueueManager.DiscardTransactions(ids)
 }
 
+// TransactionHistory returns transactions sent from a given address
+func (api *StatusAPI) TransactionHistory(address string) []common.TransactionRecord {
+	return api.b.txQueueManager.TransactionHistory(address)
+}
+
 // JailParse creates a new jail cell context, with the given chatID as identifier.
 // New context executes provided JavaScript code, right after the initialization.
 func (api *StatusAPI) JailParse(chatID string, js string) string {
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"sync"
+	"time"
 
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/status-im/status-go/geth/account"
@@ -11,6 +12,7 @@ import (
 	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/node"
 	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
 	"github.com/status-im/status-go/geth/signal"
 	"github.com/status-im/status-go/geth/txqueue"
 )
@@ -23,9 +25,18 @@ type StatusBackend struct {
 	accountManager common.AccountManager
 	txQueueManager common.TxQueueManager
 	jailManager    common.JailManager
+	offlineClient  *rpc.Client // serves status_ping while the node is not running
 	// TODO(oskarth): notifer here
 }
 
+// PingResult is the result of status_ping calls, which check that the backend
+// is alive without touching the chain.
+type PingResult struct {
+	NodeRunning     bool   `json:"nodeRunning"`
+	AccountSelected bool   `json:"accountSelected"`
+	NetworkID       uint64 `json:"networkId"` // zero if the node is not running
+}
+
 // NewStatusBackend create a new NewStatusBackend instance
 func NewStatusBackend() *StatusBackend {
 	defer log.Info("Status backend initialized")
@@ -35,12 +46,16 @@ func NewStatusBackend() *StatusBackend {
 	txQueueManager := txqueue.NewManager(nodeManager, accountManager)
 	jailManager := jail.New(nodeManager)
 
-	return &StatusBackend{
+	backend := &StatusBackend{
 		nodeManager:    nodeManager,
 		accountManager: accountManager,
 		jailManager:    jailManager,
 		txQueueManager: txQueueManager,
+		offlineClient:  rpc.NewOfflineClient(),
 	}
+	backend.offlineClient.RegisterHandler("status_ping", backend.pingRPCHandler)
+
+	return backend
 }
 
 // NodeManager returns reference to node manager
@@ -82,6 +97,8 @@ func (m *StatusBackend) StartNode(config *params.NodeConfig) (<-chan struct{}, e
 		return nil, err
 	}
 
+	m.txQueueManager.SetCompletionTimeout(time.Duration(config.TxCompletionTimeout) * time.Second)
+	m.txQueueManager.SetQueueCapacity(config.TxQueueCapacity)
 	m.txQueueManager.Start()
 
 	m.nodeReady = make(chan struct{}, 1)
@@ -148,7 +165,7 @@ func (m *StatusBackend) RestartNode() (<-chan struct{}, error) {
 	}
 	<-m.nodeReady
 
-	nodeRestarted, err := m.nodeManager.RestartNode()
+	nodeRestarted, err := m.nodeManager.RestartNode(nil)
 	if err != nil {
 		return nil, err
 	}
@@ -181,12 +198,43 @@ func (m *StatusBackend) ResetChainData() (<-chan struct{}, error) {
 	return m.nodeReady, err
 }
 
-// CallRPC executes RPC request on node's in-proc RPC server
+// CallRPC executes RPC request on node's in-proc RPC server.
+// If the node is not running, only status_ping is served.
 func (m *StatusBackend) CallRPC(inputJSON string) string {
 	client := m.nodeManager.RPCClient()
+	if client == nil {
+		client = m.offlineClient
+	}
+
 	return client.CallRaw(inputJSON)
 }
 
+// CallRPCErr executes RPC request on node's in-proc RPC server. Unlike CallRPC,
+// it returns an error if the request is malformed, could not be sent or the node
+// is not started. JSON-RPC errors are only reported in the response.
+//
+// If the node is not started, rpc.ErrNoLocalNode is returned and reported in
+// the response as a JSON-RPC error as well, unless status_ping is called.
+func (m *StatusBackend) CallRPCErr(inputJSON string) (string, error) {
+	client := m.nodeManager.RPCClient()
+	if client == nil {
+		client = m.offlineClient
+	}
+
+	return client.CallRawContext(context.Background(), inputJSON)
+}
+
+// CallInto executes a call of the RPC method with given params and unmarshals its
+// result into out, unless out is nil. JSON-RPC errors are returned as errors.
+func (m *StatusBackend) CallInto(method string, params []interface{}, out interface{}) error {
+	client := m.nodeManager.RPCClient()
+	if client == nil {
+		return node.ErrNoRunningNode
+	}
+
+	return client.Call(out, method, params...)
+}
+
 // SendTransaction creates a new transaction and waits until it's complete.
 func (m *StatusBackend) SendTransaction(ctx context.Context, args common.SendTxArgs) (gethcommon.Hash, error) {
 	if ctx == nil {
@@ -226,11 +274,22 @@ func (m *StatusBackend) DiscardTransactions(ids []common.QueuedTxID) map[common.
 	return m.txQueueManager.DiscardTransactions(ids)
 }
 
+// TransactionHistory returns transactions sent from a given address
+func (m *StatusBackend) TransactionHistory(address string) []common.TransactionRecord {
+	return m.txQueueManager.TransactionHistory(address)
+}
+
 // registerHandlers attaches Status callback handlers to running node
 func (m *StatusBackend) registerHandlers() error {
 	rpcClient := m.NodeManager().RPCClient()
 	rpcClient.RegisterHandler("eth_accounts", m.accountManager.AccountsRPCHandler())
+	rpcClient.RegisterHandler("eth_sign", m.accountManager.SignRPCHandler())
+	rpcClient.RegisterHandler("personal_sign", m.accountManager.PersonalSignRPCHandler())
+	rpcClient.RegisterHandler("personal_newAccount", m.accountManager.NewAccountRPCHandler())
+	rpcClient.RegisterHandler("personal_unlockAccount", m.accountManager.UnlockAccountRPCHandler())
+	rpcClient.RegisterHandler("personal_listAccounts", m.accountManager.ListAccountsRPCHandler())
 	rpcClient.RegisterHandler("eth_sendTransaction", m.txQueueManager.SendTransactionRPCHandler)
+	rpcClient.RegisterHandler("status_ping", m.pingRPCHandler)
 
 	m.txQueueManager.SetTransactionQueueHandler(m.txQueueManager.TransactionQueueHandler())
 	log.Info("Registered handler", "fn", "TransactionQueueHandler")
@@ -240,3 +299,23 @@ func (m *StatusBackend) registerHandlers() error {
 
 	return nil
 }
+
+// pingRPCHandler serves status_ping calls, reporting whether the node is running,
+// an account is selected and the network id. It never reaches the node.
+func (m *StatusBackend) pingRPCHandler(context.Context, ...interface{}) (interface{}, error) {
+	result := PingResult{
+		NodeRunning: m.nodeManager.IsNodeRunning(),
+	}
+
+	if account, err := m.accountManager.SelectedAccount(); err == nil && account != nil {
+		result.AccountSelected = true
+	}
+
+	if result.NodeRunning {
+		if config, err := m.nodeManager.NodeConfig(); err == nil {
+			result.NetworkID = config.NetworkID
+		}
+	}
+
+	return result, nil
+}
@@ -134,6 +134,19 @@ func (r RPCCall) ParseGasPrice() *hexutil.Big {
 	return (*hexutil.Big)(parsedValue)
 }
 
+// ParseSimulate returns true if the call asks to simulate a transaction
+// rather than send it.
+func (r RPCCall) ParseSimulate() bool {
+	params, ok := r.Params[0].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	simulate, _ := params["simulate"].(bool)
+
+	return simulate
+}
+
 // ToSendTxArgs converts RPCCall to SendTxArgs.
 func (r RPCCall) ToSendTxArgs() SendTxArgs {
 	var err error
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 	"time"
 
@@ -13,10 +14,12 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/les"
 	"github.com/ethereum/go-ethereum/node"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
 	"github.com/robertkrimen/otto"
+	"github.com/status-im/status-go/geth/delivery"
 	"github.com/status-im/status-go/geth/params"
 	"github.com/status-im/status-go/geth/rpc"
 	"github.com/status-im/status-go/static"
@@ -32,6 +35,14 @@ type SelectedExtKey struct {
 	Address     common.Address
 	AccountKey  *keystore.Key
 	SubAccounts []accounts.Account
+	SelectedAt  time.Time // when the account was selected with SelectAccount
+}
+
+// SelectedAccount describes currently selected account, without its keys.
+type SelectedAccount struct {
+	Address    common.Address `json:"address"`
+	PublicKey  string         `json:"pubkey"`     // hex encoded
+	SelectedAt time.Time      `json:"selectedAt"` // when the account was selected
 }
 
 // Hex dumps address of a given extended key as hex string
@@ -43,17 +54,66 @@ func (k *SelectedExtKey) Hex() string {
 	return k.Address.Hex()
 }
 
+// NodeState is a lifecycle state of Status node
+type NodeState int
+
+// node lifecycle states
+const (
+	StateStarting NodeState = iota
+	StateReady
+	StateError
+	StateStopping
+	StateStopped
+)
+
+// NodeEvent is a lifecycle event of Status node
+type NodeEvent struct {
+	State NodeState
+	Error error // set for StateError only
+}
+
+// PeerInfo describes a peer connected to Status node
+type PeerInfo struct {
+	Enode     string   // enode URL of the peer
+	Name      string   // name of the peer, including client type, version, OS, custom data
+	Protocols []string // sub-protocols advertised by the peer, e.g. "les/2"
+}
+
+// SyncStatus describes progress of the chain synchronisation of Status node
+type SyncStatus struct {
+	CurrentBlock uint64 // number of the latest synchronised block
+	HighestBlock uint64 // number of the highest known block, equal to CurrentBlock once synced
+	Synced       bool   // true if the node is not syncing
+}
+
+// LogFilter selects event logs of a range of blocks
+type LogFilter struct {
+	FromBlock uint64           // the first block of the range
+	ToBlock   uint64           // the last block of the range, the latest block if zero
+	Addresses []common.Address // contracts emitting logs, any contract if empty
+	Topics    [][]common.Hash  // topics at each position, nil matches any topic
+}
+
 // NodeManager defines expected methods for managing Status node
 type NodeManager interface {
 	// StartNode start Status node, fails if node is already started
 	StartNode(config *params.NodeConfig) (<-chan struct{}, error)
 
+	// StartNodeAsync starts Status node and returns a channel of its lifecycle
+	// events, fails if node is already started
+	StartNodeAsync(config *params.NodeConfig) (<-chan NodeEvent, error)
+
 	// StopNode stop the running Status node.
 	// Stopped node cannot be resumed, one starts a new node instead.
 	StopNode() (<-chan struct{}, error)
 
-	// RestartNode restart running Status node, fails if node is not running
-	RestartNode() (<-chan struct{}, error)
+	// StopNodeGracefully waits up to timeout for RPC calls in flight to complete,
+	// and stops the running Status node.
+	StopNodeGracefully(timeout time.Duration) error
+
+	// RestartNode stops running Status node (if any) and starts a new one with given config.
+	// If config is nil, configuration of the running node is reused, and it fails if node is not running.
+	RestartNode(config *params.NodeConfig) (<-chan struct{}, error)
 
 	// ResetChainData remove chain data from data directory.
 	// Node is stopped, and new node is started, with clean data directory.
@@ -62,6 +122,14 @@ type NodeManager interface {
 	// IsNodeRunning confirm that node is running
 	IsNodeRunning() bool
 
+	// SubscribeLifecycle registers fn to be called on every node lifecycle
+	// transition and returns function unsubscribing it
+	SubscribeLifecycle(fn func(NodeEvent)) (unsubscribe func())
+
+	// NodeReady returns channel closed once the started node is ready (or failed to start),
+	// fails if node is not started
+	NodeReady() (<-chan struct{}, error)
+
 	// NodeConfig returns reference to running node's configuration
 	NodeConfig() (*params.NodeConfig, error)
 
@@ -74,12 +142,24 @@ type NodeManager interface {
 	// AddPeer adds URL of static peer
 	AddPeer(url string) error
 
+	// RemovePeer disconnects static peer given its URL
+	RemovePeer(url string) error
+
+	// PeerCount returns number of peers connected to the running node
+	PeerCount() (int, error)
+
+	// Peers returns information about peers connected to the running node
+	Peers() ([]PeerInfo, error)
+
 	// LightEthereumService exposes reference to LES service running on top of the node
 	LightEthereumService() (*les.LightEthereum, error)
 
 	// WhisperService returns reference to running Whisper service
 	WhisperService() (*whisper.Whisper, error)
 
+	// DeliveryNotification returns the shared notification of whisper envelopes delivery states
+	DeliveryNotification() *delivery.DeliveryNotification
+
 	// AccountManager returns reference to node's account manager
 	AccountManager() (*accounts.Manager, error)
 
@@ -88,6 +168,18 @@ type NodeManager interface {
 
 	// RPCClient exposes reference to RPC client connected to the running node
 	RPCClient() *rpc.Client
+
+	// SuggestGasPrice suggests a gas price for transactions based on recent blocks
+	SuggestGasPrice() (*big.Int, error)
+
+	// SyncProgress returns progress of the chain synchronisation
+	SyncProgress() (*SyncStatus, error)
+
+	// GetLogs returns event logs matching the filter, querying large block ranges in chunks
+	GetLogs(filter LogFilter) ([]types.Log, error)
+
+	// WaitForReceipt polls for the receipt of a transaction until it is mined or the context is done
+	WaitForReceipt(ctx context.Context, txHash common.Hash, pollInterval time.Duration) (*types.Receipt, error)
 }
 
 // AccountManager defines expected methods for managing Status accounts
@@ -107,6 +199,10 @@ type AccountManager interface {
 	// Once master key is re-generated, it is inserted into keystore (if not already there).
 	RecoverAccount(password, mnemonic string) (address, pubKey string, err error)
 
+	// ImportAccount imports an account given by a hex-encoded private key into keystore,
+	// encrypting its key file with a given password.
+	ImportAccount(privateKeyHex, password string) (address, pubKey string, err error)
+
 	// VerifyAccountPassword tries to decrypt a given account key file, with a provided password.
 	// If no error is returned, then account is considered verified.
 	VerifyAccountPassword(keyStoreDir, address, password string) (*keystore.Key, error)
@@ -122,6 +218,9 @@ type AccountManager interface {
 	// SelectedAccount returns currently selected account
 	SelectedAccount() (*SelectedExtKey, error)
 
+	// SelectedAccountInfo returns address, public key and selection time of currently selected account
+	SelectedAccountInfo() (*SelectedAccount, error)
+
 	// Logout clears whisper identities
 	Logout() error
 
@@ -131,6 +230,21 @@ type AccountManager interface {
 	// AccountsRPCHandler returns RPC wrapper for Accounts()
 	AccountsRPCHandler() rpc.Handler
 
+	// SignRPCHandler returns RPC handler for eth_sign, signing with the selected account
+	SignRPCHandler() rpc.Handler
+
+	// PersonalSignRPCHandler returns RPC handler for personal_sign, signing with the selected account
+	PersonalSignRPCHandler() rpc.Handler
+
+	// NewAccountRPCHandler returns RPC handler for personal_newAccount
+	NewAccountRPCHandler() rpc.Handler
+
+	// UnlockAccountRPCHandler returns RPC handler for personal_unlockAccount
+	UnlockAccountRPCHandler() rpc.Handler
+
+	// ListAccountsRPCHandler returns RPC handler for personal_listAccounts
+	ListAccountsRPCHandler() rpc.Handler
+
 	// AddressToDecryptedAccount tries to load decrypted key for a given account.
 	// The running node, has a keystore directory which is loaded on start. Key file
 	// for a given address is expected to be in that directory prior to node start.
@@ -163,6 +277,27 @@ type QueuedTx struct {
 	Err        error
 }
 
+// TransactionStatus is a status of a transaction sent through the transaction queue.
+type TransactionStatus string
+
+// transaction statuses
+const (
+	TransactionPending TransactionStatus = "pending" // sent, but not mined yet
+	TransactionSuccess TransactionStatus = "success" // mined successfully
+	TransactionFailed  TransactionStatus = "failed"  // mined, but failed
+	TransactionDropped TransactionStatus = "dropped" // dropped before being mined
+)
+
+// TransactionRecord describes a transaction sent through the transaction queue.
+type TransactionRecord struct {
+	Hash      common.Hash       `json:"hash"`
+	From      common.Address    `json:"from"`
+	To        *common.Address   `json:"to"` // nil for contract creation
+	Value     *hexutil.Big      `json:"value"`
+	Timestamp time.Time         `json:"timestamp"` // when the transaction was sent
+	Status    TransactionStatus `json:"status"`
+}
+
 // SendTxArgs represents the arguments to submit a new transaction into the transaction pool.
 type SendTxArgs struct {
 	From     common.Address  `json:"from"`
@@ -215,6 +350,12 @@ type TxQueueManager interface {
 	// WaitForTransactions blocks until transaction is completed, discarded or timed out.
 	WaitForTransaction(tx *QueuedTx) error
 
+	// SetCompletionTimeout sets how long WaitForTransaction waits before the transaction times out.
+	SetCompletionTimeout(timeout time.Duration)
+
+	// SetQueueCapacity sets how many transactions can be queued at once.
+	SetQueueCapacity(capacity int)
+
 	// NotifyOnQueuedTxReturn notifies a handler when a transaction returns.
 	NotifyOnQueuedTxReturn(queuedTx *QueuedTx, err error)
 
@@ -227,6 +368,10 @@ type TxQueueManager interface {
 	// TODO(adam): might be not needed
 	SetTransactionReturnHandler(fn EnqueuedTxReturnHandler)
 
+	// SubscribeTransactionQueued registers a handler called when a new transaction is enqueued.
+	// Returned function unsubscribes it.
+	SubscribeTransactionQueued(fn EnqueuedTxHandler) (unsubscribe func())
+
 	SendTransactionRPCHandler(ctx context.Context, args ...interface{}) (interface{}, error)
 
 	// TransactionReturnHandler returns handler that processes responses from internal tx manager
@@ -243,6 +388,9 @@ type TxQueueManager interface {
 
 	// DiscardTransactions discards given multiple transactions from transaction queue
 	DiscardTransactions(ids []QueuedTxID) map[QueuedTxID]RawDiscardTransactionResult
+
+	// TransactionHistory returns transactions sent from a given address, oldest first.
+	TransactionHistory(address string) []TransactionRecord
 }
 
 // JailCell represents single jail cell, which is basically a JavaScript VM.
@@ -9,14 +9,18 @@ import (
 	accounts "github.com/ethereum/go-ethereum/accounts"
 	keystore "github.com/ethereum/go-ethereum/accounts/keystore"
 	common "github.com/ethereum/go-ethereum/common"
+	types "github.com/ethereum/go-ethereum/core/types"
 	les "github.com/ethereum/go-ethereum/les"
 	node "github.com/ethereum/go-ethereum/node"
 	whisperv5 "github.com/ethereum/go-ethereum/whisper/whisperv5"
 	gomock "github.com/golang/mock/gomock"
 	otto "github.com/robertkrimen/otto"
+	delivery "github.com/status-im/status-go/geth/delivery"
 	params "github.com/status-im/status-go/geth/params"
 	rpc "github.com/status-im/status-go/geth/rpc"
+	big "math/big"
 	reflect "reflect"
+	time "time"
 )
 
 // MockNodeManager is a mock of NodeManager interface
@@ -55,6 +59,19 @@ func (mr *MockNodeManagerMockRecorder) StartNode(config interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartNode", reflect.TypeOf((*MockNodeManager)(nil).StartNode), config)
 }
 
+// StartNodeAsync mocks base method
+func (m *MockNodeManager) StartNodeAsync(config *params.NodeConfig) (<-chan NodeEvent, error) {
+	ret := m.ctrl.Call(m, "StartNodeAsync", config)
+	ret0, _ := ret[0].(<-chan NodeEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartNodeAsync indicates an expected call of StartNodeAsync
+func (mr *MockNodeManagerMockRecorder) StartNodeAsync(config interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartNodeAsync", reflect.TypeOf((*MockNodeManager)(nil).StartNodeAsync), config)
+}
+
 // StopNode mocks base method
 func (m *MockNodeManager) StopNode() (<-chan struct{}, error) {
 	ret := m.ctrl.Call(m, "StopNode")
@@ -68,17 +85,29 @@ func (mr *MockNodeManagerMockRecorder) StopNode() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopNode", reflect.TypeOf((*MockNodeManager)(nil).StopNode))
 }
 
+// StopNodeGracefully mocks base method
+func (m *MockNodeManager) StopNodeGracefully(timeout time.Duration) error {
+	ret := m.ctrl.Call(m, "StopNodeGracefully", timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopNodeGracefully indicates an expected call of StopNodeGracefully
+func (mr *MockNodeManagerMockRecorder) StopNodeGracefully(timeout interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopNodeGracefully", reflect.TypeOf((*MockNodeManager)(nil).StopNodeGracefully), timeout)
+}
+
 // RestartNode mocks base method
-func (m *MockNodeManager) RestartNode() (<-chan struct{}, error) {
-	ret := m.ctrl.Call(m, "RestartNode")
+func (m *MockNodeManager) RestartNode(config *params.NodeConfig) (<-chan struct{}, error) {
+	ret := m.ctrl.Call(m, "RestartNode", config)
 	ret0, _ := ret[0].(<-chan struct{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // RestartNode indicates an expected call of RestartNode
-func (mr *MockNodeManagerMockRecorder) RestartNode() *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartNode", reflect.TypeOf((*MockNodeManager)(nil).RestartNode))
+func (mr *MockNodeManagerMockRecorder) RestartNode(config interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartNode", reflect.TypeOf((*MockNodeManager)(nil).RestartNode), config)
 }
 
 // ResetChainData mocks base method
@@ -106,6 +135,31 @@ func (mr *MockNodeManagerMockRecorder) IsNodeRunning() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsNodeRunning", reflect.TypeOf((*MockNodeManager)(nil).IsNodeRunning))
 }
 
+// SubscribeLifecycle mocks base method
+func (m *MockNodeManager) SubscribeLifecycle(fn func(NodeEvent)) func() {
+	ret := m.ctrl.Call(m, "SubscribeLifecycle", fn)
+	ret0, _ := ret[0].(func())
+	return ret0
+}
+
+// SubscribeLifecycle indicates an expected call of SubscribeLifecycle
+func (mr *MockNodeManagerMockRecorder) SubscribeLifecycle(fn interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeLifecycle", reflect.TypeOf((*MockNodeManager)(nil).SubscribeLifecycle), fn)
+}
+
+// NodeReady mocks base method
+func (m *MockNodeManager) NodeReady() (<-chan struct{}, error) {
+	ret := m.ctrl.Call(m, "NodeReady")
+	ret0, _ := ret[0].(<-chan struct{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NodeReady indicates an expected call of NodeReady
+func (mr *MockNodeManagerMockRecorder) NodeReady() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeReady", reflect.TypeOf((*MockNodeManager)(nil).NodeReady))
+}
+
 // NodeConfig mocks base method
 func (m *MockNodeManager) NodeConfig() (*params.NodeConfig, error) {
 	ret := m.ctrl.Call(m, "NodeConfig")
@@ -156,6 +210,44 @@ func (mr *MockNodeManagerMockRecorder) AddPeer(url interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPeer", reflect.TypeOf((*MockNodeManager)(nil).AddPeer), url)
 }
 
+// RemovePeer mocks base method
+func (m *MockNodeManager) RemovePeer(url string) error {
+	ret := m.ctrl.Call(m, "RemovePeer", url)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePeer indicates an expected call of RemovePeer
+func (mr *MockNodeManagerMockRecorder) RemovePeer(url interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePeer", reflect.TypeOf((*MockNodeManager)(nil).RemovePeer), url)
+}
+
+// PeerCount mocks base method
+func (m *MockNodeManager) PeerCount() (int, error) {
+	ret := m.ctrl.Call(m, "PeerCount")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PeerCount indicates an expected call of PeerCount
+func (mr *MockNodeManagerMockRecorder) PeerCount() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeerCount", reflect.TypeOf((*MockNodeManager)(nil).PeerCount))
+}
+
+// Peers mocks base method
+func (m *MockNodeManager) Peers() ([]PeerInfo, error) {
+	ret := m.ctrl.Call(m, "Peers")
+	ret0, _ := ret[0].([]PeerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Peers indicates an expected call of Peers
+func (mr *MockNodeManagerMockRecorder) Peers() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Peers", reflect.TypeOf((*MockNodeManager)(nil).Peers))
+}
+
 // LightEthereumService mocks base method
 func (m *MockNodeManager) LightEthereumService() (*les.LightEthereum, error) {
 	ret := m.ctrl.Call(m, "LightEthereumService")
@@ -182,6 +274,18 @@ func (mr *MockNodeManagerMockRecorder) WhisperService() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WhisperService", reflect.TypeOf((*MockNodeManager)(nil).WhisperService))
 }
 
+// DeliveryNotification mocks base method
+func (m *MockNodeManager) DeliveryNotification() *delivery.DeliveryNotification {
+	ret := m.ctrl.Call(m, "DeliveryNotification")
+	ret0, _ := ret[0].(*delivery.DeliveryNotification)
+	return ret0
+}
+
+// DeliveryNotification indicates an expected call of DeliveryNotification
+func (mr *MockNodeManagerMockRecorder) DeliveryNotification() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeliveryNotification", reflect.TypeOf((*MockNodeManager)(nil).DeliveryNotification))
+}
+
 // AccountManager mocks base method
 func (m *MockNodeManager) AccountManager() (*accounts.Manager, error) {
 	ret := m.ctrl.Call(m, "AccountManager")
@@ -220,6 +324,58 @@ func (mr *MockNodeManagerMockRecorder) RPCClient() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RPCClient", reflect.TypeOf((*MockNodeManager)(nil).RPCClient))
 }
 
+// SuggestGasPrice mocks base method
+func (m *MockNodeManager) SuggestGasPrice() (*big.Int, error) {
+	ret := m.ctrl.Call(m, "SuggestGasPrice")
+	ret0, _ := ret[0].(*big.Int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuggestGasPrice indicates an expected call of SuggestGasPrice
+func (mr *MockNodeManagerMockRecorder) SuggestGasPrice() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuggestGasPrice", reflect.TypeOf((*MockNodeManager)(nil).SuggestGasPrice))
+}
+
+// SyncProgress mocks base method
+func (m *MockNodeManager) SyncProgress() (*SyncStatus, error) {
+	ret := m.ctrl.Call(m, "SyncProgress")
+	ret0, _ := ret[0].(*SyncStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncProgress indicates an expected call of SyncProgress
+func (mr *MockNodeManagerMockRecorder) SyncProgress() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncProgress", reflect.TypeOf((*MockNodeManager)(nil).SyncProgress))
+}
+
+// GetLogs mocks base method
+func (m *MockNodeManager) GetLogs(filter LogFilter) ([]types.Log, error) {
+	ret := m.ctrl.Call(m, "GetLogs", filter)
+	ret0, _ := ret[0].([]types.Log)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLogs indicates an expected call of GetLogs
+func (mr *MockNodeManagerMockRecorder) GetLogs(filter interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogs", reflect.TypeOf((*MockNodeManager)(nil).GetLogs), filter)
+}
+
+// WaitForReceipt mocks base method
+func (m *MockNodeManager) WaitForReceipt(ctx context.Context, txHash common.Hash, pollInterval time.Duration) (*types.Receipt, error) {
+	ret := m.ctrl.Call(m, "WaitForReceipt", ctx, txHash, pollInterval)
+	ret0, _ := ret[0].(*types.Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WaitForReceipt indicates an expected call of WaitForReceipt
+func (mr *MockNodeManagerMockRecorder) WaitForReceipt(ctx, txHash, pollInterval interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForReceipt", reflect.TypeOf((*MockNodeManager)(nil).WaitForReceipt), ctx, txHash, pollInterval)
+}
+
 // MockAccountManager is a mock of AccountManager interface
 type MockAccountManager struct {
 	ctrl     *gomock.Controller
@@ -286,6 +442,20 @@ func (mr *MockAccountManagerMockRecorder) RecoverAccount(password, mnemonic inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoverAccount", reflect.TypeOf((*MockAccountManager)(nil).RecoverAccount), password, mnemonic)
 }
 
+// ImportAccount mocks base method
+func (m *MockAccountManager) ImportAccount(privateKeyHex, password string) (string, string, error) {
+	ret := m.ctrl.Call(m, "ImportAccount", privateKeyHex, password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ImportAccount indicates an expected call of ImportAccount
+func (mr *MockAccountManagerMockRecorder) ImportAccount(privateKeyHex, password interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportAccount", reflect.TypeOf((*MockAccountManager)(nil).ImportAccount), privateKeyHex, password)
+}
+
 // VerifyAccountPassword mocks base method
 func (m *MockAccountManager) VerifyAccountPassword(keyStoreDir, address, password string) (*keystore.Key, error) {
 	ret := m.ctrl.Call(m, "VerifyAccountPassword", keyStoreDir, address, password)
@@ -336,6 +506,19 @@ func (mr *MockAccountManagerMockRecorder) SelectedAccount() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectedAccount", reflect.TypeOf((*MockAccountManager)(nil).SelectedAccount))
 }
 
+// SelectedAccountInfo mocks base method
+func (m *MockAccountManager) SelectedAccountInfo() (*SelectedAccount, error) {
+	ret := m.ctrl.Call(m, "SelectedAccountInfo")
+	ret0, _ := ret[0].(*SelectedAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectedAccountInfo indicates an expected call of SelectedAccountInfo
+func (mr *MockAccountManagerMockRecorder) SelectedAccountInfo() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectedAccountInfo", reflect.TypeOf((*MockAccountManager)(nil).SelectedAccountInfo))
+}
+
 // Logout mocks base method
 func (m *MockAccountManager) Logout() error {
 	ret := m.ctrl.Call(m, "Logout")
@@ -373,6 +556,66 @@ func (mr *MockAccountManagerMockRecorder) AccountsRPCHandler() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountsRPCHandler", reflect.TypeOf((*MockAccountManager)(nil).AccountsRPCHandler))
 }
 
+// SignRPCHandler mocks base method
+func (m *MockAccountManager) SignRPCHandler() rpc.Handler {
+	ret := m.ctrl.Call(m, "SignRPCHandler")
+	ret0, _ := ret[0].(rpc.Handler)
+	return ret0
+}
+
+// SignRPCHandler indicates an expected call of SignRPCHandler
+func (mr *MockAccountManagerMockRecorder) SignRPCHandler() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignRPCHandler", reflect.TypeOf((*MockAccountManager)(nil).SignRPCHandler))
+}
+
+// PersonalSignRPCHandler mocks base method
+func (m *MockAccountManager) PersonalSignRPCHandler() rpc.Handler {
+	ret := m.ctrl.Call(m, "PersonalSignRPCHandler")
+	ret0, _ := ret[0].(rpc.Handler)
+	return ret0
+}
+
+// PersonalSignRPCHandler indicates an expected call of PersonalSignRPCHandler
+func (mr *MockAccountManagerMockRecorder) PersonalSignRPCHandler() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PersonalSignRPCHandler", reflect.TypeOf((*MockAccountManager)(nil).PersonalSignRPCHandler))
+}
+
+// NewAccountRPCHandler mocks base method
+func (m *MockAccountManager) NewAccountRPCHandler() rpc.Handler {
+	ret := m.ctrl.Call(m, "NewAccountRPCHandler")
+	ret0, _ := ret[0].(rpc.Handler)
+	return ret0
+}
+
+// NewAccountRPCHandler indicates an expected call of NewAccountRPCHandler
+func (mr *MockAccountManagerMockRecorder) NewAccountRPCHandler() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewAccountRPCHandler", reflect.TypeOf((*MockAccountManager)(nil).NewAccountRPCHandler))
+}
+
+// UnlockAccountRPCHandler mocks base method
+func (m *MockAccountManager) UnlockAccountRPCHandler() rpc.Handler {
+	ret := m.ctrl.Call(m, "UnlockAccountRPCHandler")
+	ret0, _ := ret[0].(rpc.Handler)
+	return ret0
+}
+
+// UnlockAccountRPCHandler indicates an expected call of UnlockAccountRPCHandler
+func (mr *MockAccountManagerMockRecorder) UnlockAccountRPCHandler() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockAccountRPCHandler", reflect.TypeOf((*MockAccountManager)(nil).UnlockAccountRPCHandler))
+}
+
+// ListAccountsRPCHandler mocks base method
+func (m *MockAccountManager) ListAccountsRPCHandler() rpc.Handler {
+	ret := m.ctrl.Call(m, "ListAccountsRPCHandler")
+	ret0, _ := ret[0].(rpc.Handler)
+	return ret0
+}
+
+// ListAccountsRPCHandler indicates an expected call of ListAccountsRPCHandler
+func (mr *MockAccountManagerMockRecorder) ListAccountsRPCHandler() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountsRPCHandler", reflect.TypeOf((*MockAccountManager)(nil).ListAccountsRPCHandler))
+}
+
 // AddressToDecryptedAccount mocks base method
 func (m *MockAccountManager) AddressToDecryptedAccount(address, password string) (accounts.Account, *keystore.Key, error) {
 	ret := m.ctrl.Call(m, "AddressToDecryptedAccount", address, password)
@@ -545,6 +788,26 @@ func (mr *MockTxQueueManagerMockRecorder) WaitForTransaction(tx interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForTransaction", reflect.TypeOf((*MockTxQueueManager)(nil).WaitForTransaction), tx)
 }
 
+// SetCompletionTimeout mocks base method
+func (m *MockTxQueueManager) SetCompletionTimeout(timeout time.Duration) {
+	m.ctrl.Call(m, "SetCompletionTimeout", timeout)
+}
+
+// SetCompletionTimeout indicates an expected call of SetCompletionTimeout
+func (mr *MockTxQueueManagerMockRecorder) SetCompletionTimeout(timeout interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCompletionTimeout", reflect.TypeOf((*MockTxQueueManager)(nil).SetCompletionTimeout), timeout)
+}
+
+// SetQueueCapacity mocks base method
+func (m *MockTxQueueManager) SetQueueCapacity(capacity int) {
+	m.ctrl.Call(m, "SetQueueCapacity", capacity)
+}
+
+// SetQueueCapacity indicates an expected call of SetQueueCapacity
+func (mr *MockTxQueueManagerMockRecorder) SetQueueCapacity(capacity interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQueueCapacity", reflect.TypeOf((*MockTxQueueManager)(nil).SetQueueCapacity), capacity)
+}
+
 // NotifyOnQueuedTxReturn mocks base method
 func (m *MockTxQueueManager) NotifyOnQueuedTxReturn(queuedTx *QueuedTx, err error) {
 	m.ctrl.Call(m, "NotifyOnQueuedTxReturn", queuedTx, err)
@@ -577,6 +840,18 @@ func (mr *MockTxQueueManagerMockRecorder) SetTransactionQueueHandler(fn interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTransactionQueueHandler", reflect.TypeOf((*MockTxQueueManager)(nil).SetTransactionQueueHandler), fn)
 }
 
+// SubscribeTransactionQueued mocks base method
+func (m *MockTxQueueManager) SubscribeTransactionQueued(fn EnqueuedTxHandler) func() {
+	ret := m.ctrl.Call(m, "SubscribeTransactionQueued", fn)
+	ret0, _ := ret[0].(func())
+	return ret0
+}
+
+// SubscribeTransactionQueued indicates an expected call of SubscribeTransactionQueued
+func (mr *MockTxQueueManagerMockRecorder) SubscribeTransactionQueued(fn interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeTransactionQueued", reflect.TypeOf((*MockTxQueueManager)(nil).SubscribeTransactionQueued), fn)
+}
+
 // SetTransactionReturnHandler mocks base method
 func (m *MockTxQueueManager) SetTransactionReturnHandler(fn EnqueuedTxReturnHandler) {
 	m.ctrl.Call(m, "SetTransactionReturnHandler", fn)
@@ -666,6 +941,18 @@ func (mr *MockTxQueueManagerMockRecorder) DiscardTransactions(ids interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscardTransactions", reflect.TypeOf((*MockTxQueueManager)(nil).DiscardTransactions), ids)
 }
 
+// TransactionHistory mocks base method
+func (m *MockTxQueueManager) TransactionHistory(address string) []TransactionRecord {
+	ret := m.ctrl.Call(m, "TransactionHistory", address)
+	ret0, _ := ret[0].([]TransactionRecord)
+	return ret0
+}
+
+// TransactionHistory indicates an expected call of TransactionHistory
+func (mr *MockTxQueueManagerMockRecorder) TransactionHistory(address interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransactionHistory", reflect.TypeOf((*MockTxQueueManager)(nil).TransactionHistory), address)
+}
+
 // MockJailCell is a mock of JailCell interface
 type MockJailCell struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,607 @@
+package delivery
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// DefaultChanBufferSize is a buffer size of channels created with SubscribeChan.
+const DefaultChanBufferSize = 16
+
+// Status is a delivery status of a whisper envelope.
+type Status int
+
+// delivery statuses
+const (
+	StatusUnknown Status = iota
+	StatusQueued
+	StatusSent
+	StatusDelivered
+	StatusFailed
+)
+
+var statusNames = map[Status]string{
+	StatusUnknown:   "unknown",
+	StatusQueued:    "queued",
+	StatusSent:      "sent",
+	StatusDelivered: "delivered",
+	StatusFailed:    "failed",
+}
+
+// String returns a human-readable name of the status.
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// MessageDeliveryState holds the delivery status of a given whisper envelope.
+type MessageDeliveryState struct {
+	Status   Status
+	Envelope *whisper.Envelope
+}
+
+// DeliverySubscriber is a callback notified on every delivery state change.
+type DeliverySubscriber func(MessageDeliveryState)
+
+// KeyStore resolves ids of whisper keys to the keys, e.g. *whisper.Whisper.
+type KeyStore interface {
+	GetSymKey(id string) ([]byte, error)
+	GetPrivateKey(id string) (*ecdsa.PrivateKey, error)
+}
+
+// SubscriptionID is an opaque handle identifying a registered subscriber.
+type SubscriptionID uint64
+
+// NoSubscription is returned instead of a handle if the subscriber is rejected,
+// see SetMaxSubscribers.
+const NoSubscription SubscriptionID = 0
+
+// DeliveryNotification dispatches delivery states of whisper envelopes
+// to registered subscribers.
+type DeliveryNotification struct {
+	sml     sync.RWMutex // sml guards subs, closers, lastID, maxSubs, async, stats, keys, dedup and tracker
+	subs    map[SubscriptionID]DeliverySubscriber
+	closers map[SubscriptionID]func() // called on Unsubscribe
+	lastID  SubscriptionID
+	maxSubs int               // limit of len(subs), zero means no limit
+	async   bool              // dispatch each subscriber in its own goroutine
+	stats   map[Status]uint64 // number of Send calls per status
+	keys    KeyStore          // resolves keys of FilterRecipient subscribers
+	dedup   dedupFilter       // suppresses repeated states
+	tracker statusTracker     // rejects backward transitions
+
+	qml     sync.Mutex             // qml guards pending, wake, stopped and closed
+	pending []MessageDeliveryState // queued with SendAsync
+	wake    chan struct{}          // signals the worker that states are queued
+	stopped chan struct{}          // closed once the worker returns, nil until it's started
+	closed  bool                   // set by Close
+}
+
+// SetMaxSubscribers limits the number of registered subscribers, so that callers
+// subscribing without ever unsubscribing are caught early. Once the limit is
+// reached, new subscribers are rejected until others unsubscribe. Zero or less
+// means no limit, which is the default. Already registered subscribers are kept.
+func (d *DeliveryNotification) SetMaxSubscribers(n int) {
+	d.sml.Lock()
+	defer d.sml.Unlock()
+
+	d.maxSubs = n
+}
+
+// SubscriberCount returns the number of registered subscribers.
+func (d *DeliveryNotification) SubscriberCount() int {
+	d.sml.RLock()
+	defer d.sml.RUnlock()
+
+	return len(d.subs)
+}
+
+// SetKeyStore sets the store keys of FilterRecipient subscribers are resolved with.
+func (d *DeliveryNotification) SetKeyStore(keys KeyStore) {
+	d.sml.Lock()
+	defer d.sml.Unlock()
+
+	d.keys = keys
+}
+
+// SetDedupWindow makes states repeating one sent within the window, i.e. with
+// the same status of the same envelope, to be suppressed, e.g. when envelopes
+// are retransmitted. Different statuses of an envelope are sent regardless.
+// Suppressed states are not counted in Stats. Zero or less disables
+// deduplication, which is the default.
+func (d *DeliveryNotification) SetDedupWindow(window time.Duration) {
+	d.sml.Lock()
+	defer d.sml.Unlock()
+
+	d.dedup = dedupFilter{window: window}
+}
+
+// SetEnforceTransitions toggles rejecting states which don't progress the status
+// of their envelope forward, i.e. queued, sent and then either delivered or failed.
+// Rejected states are logged and not sent to subscribers, nor counted in Stats.
+// Disabling it forgets statuses of envelopes.
+func (d *DeliveryNotification) SetEnforceTransitions(enforce bool) {
+	d.sml.Lock()
+	defer d.sml.Unlock()
+
+	d.tracker = statusTracker{enabled: enforce}
+}
+
+// EnvelopeStatus returns the current status of an envelope with a given hash,
+// or StatusUnknown if it is not known. Statuses are only tracked while
+// transitions are enforced, see SetEnforceTransitions, until envelopes expire.
+func (d *DeliveryNotification) EnvelopeStatus(hash gethcommon.Hash) Status {
+	d.sml.RLock()
+	defer d.sml.RUnlock()
+
+	return d.tracker.statuses[hash].status
+}
+
+// SetAsync toggles dispatching of subscribers in separate goroutines,
+// so that a slow subscriber does not stall Send.
+func (d *DeliveryNotification) SetAsync(async bool) {
+	d.sml.Lock()
+	defer d.sml.Unlock()
+
+	d.async = async
+}
+
+// Subscribe registers a subscriber and returns its handle, which can be
+// passed to Unsubscribe to remove it. If the limit of subscribers is reached,
+// the subscriber is rejected and NoSubscription is returned. The same applies
+// to SubscribeChan and filters.
+func (d *DeliveryNotification) Subscribe(sub DeliverySubscriber) SubscriptionID {
+	d.sml.Lock()
+	defer d.sml.Unlock()
+
+	if d.maxSubs > 0 && len(d.subs) >= d.maxSubs {
+		log.Error("Delivery subscriber rejected, too many subscribers", "limit", d.maxSubs)
+		return NoSubscription
+	}
+
+	if d.subs == nil {
+		d.subs = make(map[SubscriptionID]DeliverySubscriber)
+	}
+
+	d.lastID++
+	d.subs[d.lastID] = sub
+
+	return d.lastID
+}
+
+// Unsubscribe removes the subscriber registered under the given handle.
+// If the subscription was created with SubscribeChan, its channel is closed.
+func (d *DeliveryNotification) Unsubscribe(id SubscriptionID) {
+	d.sml.Lock()
+	closer := d.closers[id]
+	delete(d.subs, id)
+	delete(d.closers, id)
+	d.sml.Unlock()
+
+	if closer != nil {
+		closer()
+	}
+}
+
+// SubscribeChan registers a subscriber that pushes delivery states onto a channel
+// buffered with DefaultChanBufferSize items. States are dropped if the buffer
+// is full. The channel is closed on Unsubscribe, or right away if the subscriber
+// is rejected.
+func (d *DeliveryNotification) SubscribeChan() (<-chan MessageDeliveryState, SubscriptionID) {
+	return d.SubscribeChanBuffered(DefaultChanBufferSize)
+}
+
+// SubscribeChanBuffered works as SubscribeChan with a buffer of n items.
+// If n is zero or less, the channel is unbuffered and Send blocks until
+// the consumer receives the state (or unsubscribes), instead of dropping it.
+func (d *DeliveryNotification) SubscribeChanBuffered(n int) (<-chan MessageDeliveryState, SubscriptionID) {
+	block := n <= 0
+	if block {
+		n = 0
+	}
+
+	var (
+		mu   sync.RWMutex // guards ch from being closed while in use
+		ch   = make(chan MessageDeliveryState, n)
+		done = make(chan struct{})
+	)
+
+	id := d.Subscribe(func(m MessageDeliveryState) {
+		mu.RLock()
+		defer mu.RUnlock()
+
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		if block {
+			select {
+			case ch <- m:
+			case <-done:
+			}
+			return
+		}
+
+		select {
+		case ch <- m:
+		default:
+			log.Warn("Delivery state dropped, channel is full", "status", m.Status)
+		}
+	})
+	if id == NoSubscription {
+		close(ch)
+		return ch, id
+	}
+
+	d.sml.Lock()
+	if d.closers == nil {
+		d.closers = make(map[SubscriptionID]func())
+	}
+	d.closers[id] = func() {
+		close(done) // release blocked senders first
+		mu.Lock()
+		close(ch)
+		mu.Unlock()
+	}
+	d.sml.Unlock()
+
+	return ch, id
+}
+
+// Filter registers a subscriber that only receives states with the given status.
+func (d *DeliveryNotification) Filter(status Status, sub DeliverySubscriber) SubscriptionID {
+	return d.Subscribe(func(m MessageDeliveryState) {
+		if m.Status != status {
+			return
+		}
+
+		sub(m)
+	})
+}
+
+// FilterUntil registers a subscriber that receives every state up to and
+// including the first one with the given status.
+func (d *DeliveryNotification) FilterUntil(status Status, sub DeliverySubscriber) SubscriptionID {
+	var (
+		mu   sync.Mutex
+		done bool
+	)
+
+	return d.Subscribe(func(m MessageDeliveryState) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if done {
+			return
+		}
+		done = m.Status == status
+
+		sub(m)
+	})
+}
+
+// FilterTopic registers a subscriber that only receives states of envelopes
+// with the given topic.
+func (d *DeliveryNotification) FilterTopic(topic whisper.TopicType, sub DeliverySubscriber) SubscriptionID {
+	return d.Subscribe(topicFilter(topic, sub))
+}
+
+// FilterTopicStatus registers a subscriber that only receives states with
+// the given status of envelopes with the given topic.
+func (d *DeliveryNotification) FilterTopicStatus(topic whisper.TopicType, status Status, sub DeliverySubscriber) SubscriptionID {
+	return d.Filter(status, topicFilter(topic, sub))
+}
+
+// FilterRecipient registers a subscriber that only receives states of envelopes
+// which can be decrypted with the whisper key with the given id, e.g. the key
+// of a group chat. Either a symmetric or a private key id may be given.
+// The key is resolved with the key store on every state, see SetKeyStore.
+// States of other envelopes are dropped, as well as all of them if the key
+// is not found.
+func (d *DeliveryNotification) FilterRecipient(keyID string, sub DeliverySubscriber) SubscriptionID {
+	return d.Subscribe(func(m MessageDeliveryState) {
+		d.sml.RLock()
+		keys := d.keys
+		d.sml.RUnlock()
+
+		if m.Envelope == nil || keys == nil || !canOpen(m.Envelope, keys, keyID) {
+			return
+		}
+
+		sub(m)
+	})
+}
+
+// canOpen checks if the envelope can be decrypted with the key with the given id.
+func canOpen(env *whisper.Envelope, keys KeyStore, keyID string) bool {
+	if env.IsSymmetric() {
+		key, err := keys.GetSymKey(keyID)
+		if err != nil {
+			return false
+		}
+
+		_, err = env.OpenSymmetric(key)
+		return err == nil
+	}
+
+	key, err := keys.GetPrivateKey(keyID)
+	if err != nil {
+		return false
+	}
+
+	_, err = env.OpenAsymmetric(key)
+	return err == nil
+}
+
+// topicFilter wraps a subscriber so that it only receives states of
+// envelopes with the given topic.
+func topicFilter(topic whisper.TopicType, sub DeliverySubscriber) DeliverySubscriber {
+	return func(m MessageDeliveryState) {
+		if m.Envelope == nil || m.Envelope.Topic != topic {
+			return
+		}
+
+		sub(m)
+	}
+}
+
+// Send notifies all subscribers about the delivery status of a given envelope.
+// Subscribers are invoked outside of the internal lock and a panicking
+// subscriber does not prevent the others from being notified.
+func (d *DeliveryNotification) Send(env *whisper.Envelope, status Status) {
+	now := time.Now()
+
+	d.sml.Lock()
+	if current, ok := d.tracker.transition(env, status, now); !ok {
+		d.sml.Unlock()
+		log.Warn("Delivery state rejected, status can't go backward",
+			"hash", env.Hash().Hex(), "current", current, "status", status)
+		return
+	}
+	if d.dedup.seen(env, status, now) {
+		d.sml.Unlock()
+		return
+	}
+
+	if d.stats == nil {
+		d.stats = make(map[Status]uint64)
+	}
+	d.stats[status]++
+
+	subs := make([]DeliverySubscriber, 0, len(d.subs))
+	for _, sub := range d.subs {
+		subs = append(subs, sub)
+	}
+	async := d.async
+	d.sml.Unlock()
+
+	state := MessageDeliveryState{
+		Status:   status,
+		Envelope: env,
+	}
+
+	for _, sub := range subs {
+		if async {
+			go notify(sub, state)
+			continue
+		}
+
+		notify(sub, state)
+	}
+}
+
+// SendAsync queues the delivery status of a given envelope and returns right away.
+// Queued states are sent to subscribers in order by a single worker goroutine,
+// started with the first call. Unlike Send, it never blocks on slow subscribers.
+// Once the notification is closed, states are sent synchronously.
+func (d *DeliveryNotification) SendAsync(env *whisper.Envelope, status Status) {
+	d.qml.Lock()
+	if d.closed {
+		d.qml.Unlock()
+		d.Send(env, status)
+		return
+	}
+
+	if d.stopped == nil {
+		d.wake = make(chan struct{}, 1)
+		d.stopped = make(chan struct{})
+		go d.work()
+	}
+	d.pending = append(d.pending, MessageDeliveryState{Status: status, Envelope: env})
+	d.qml.Unlock()
+
+	d.signal()
+}
+
+// Close sends all the states queued with SendAsync and stops the worker.
+// It returns once they are sent.
+func (d *DeliveryNotification) Close() {
+	d.qml.Lock()
+	d.closed = true
+	stopped := d.stopped
+	d.qml.Unlock()
+
+	if stopped == nil {
+		return
+	}
+
+	d.signal()
+	<-stopped
+}
+
+// signal wakes up the worker, unless it's about to wake up already.
+func (d *DeliveryNotification) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// work sends states queued with SendAsync until the notification is closed.
+func (d *DeliveryNotification) work() {
+	for {
+		d.qml.Lock()
+		states, closed := d.pending, d.closed
+		d.pending = nil
+		d.qml.Unlock()
+
+		for _, state := range states {
+			d.Send(state.Envelope, state.Status)
+		}
+
+		if len(states) > 0 {
+			continue
+		}
+		if closed {
+			close(d.stopped)
+			return
+		}
+		<-d.wake
+	}
+}
+
+// Stats returns the cumulative number of Send calls per delivery status.
+func (d *DeliveryNotification) Stats() map[Status]uint64 {
+	d.sml.RLock()
+	defer d.sml.RUnlock()
+
+	stats := make(map[Status]uint64, len(d.stats))
+	for status, count := range d.stats {
+		stats[status] = count
+	}
+
+	return stats
+}
+
+// ResetStats clears the counters returned by Stats.
+func (d *DeliveryNotification) ResetStats() {
+	d.sml.Lock()
+	defer d.sml.Unlock()
+
+	d.stats = nil
+}
+
+// dedupKey identifies a state of an envelope.
+type dedupKey struct {
+	hash   gethcommon.Hash
+	status Status
+}
+
+// dedupFilter remembers when states were sent for the window.
+// Zero value disables deduplication.
+type dedupFilter struct {
+	window    time.Duration
+	sent      map[dedupKey]time.Time
+	nextPrune time.Time
+}
+
+// seen returns true if the state was sent within the window before now.
+// Otherwise the state is recorded as sent now.
+func (f *dedupFilter) seen(env *whisper.Envelope, status Status, now time.Time) bool {
+	if f.window <= 0 || env == nil {
+		return false
+	}
+
+	if now.After(f.nextPrune) {
+		for key, sent := range f.sent {
+			if now.Sub(sent) >= f.window {
+				delete(f.sent, key)
+			}
+		}
+		f.nextPrune = now.Add(f.window)
+	}
+
+	key := dedupKey{env.Hash(), status}
+	if sent, ok := f.sent[key]; ok && now.Sub(sent) < f.window {
+		return true
+	}
+
+	if f.sent == nil {
+		f.sent = make(map[dedupKey]time.Time)
+	}
+	f.sent[key] = now
+
+	return false
+}
+
+// trackedStatus is the current status of an envelope.
+type trackedStatus struct {
+	status Status
+	expiry time.Time // the status is forgotten afterwards
+}
+
+// statusTracker keeps current statuses of envelopes.
+// Zero value tracks nothing and allows all transitions.
+type statusTracker struct {
+	enabled   bool
+	statuses  map[gethcommon.Hash]trackedStatus
+	nextPrune time.Time
+}
+
+// statusPruneInterval is how often statuses of expired envelopes are forgotten.
+const statusPruneInterval = time.Minute
+
+// transition updates the status of the envelope, unless it would go backward.
+// It returns the current status of the envelope and true if it was updated.
+func (t *statusTracker) transition(env *whisper.Envelope, status Status, now time.Time) (Status, bool) {
+	if !t.enabled || env == nil {
+		return StatusUnknown, true
+	}
+
+	if now.After(t.nextPrune) {
+		for hash, tracked := range t.statuses {
+			if now.After(tracked.expiry) {
+				delete(t.statuses, hash)
+			}
+		}
+		t.nextPrune = now.Add(statusPruneInterval)
+	}
+
+	hash := env.Hash()
+	current := t.statuses[hash].status
+	if !isForward(current, status) {
+		return current, false
+	}
+
+	if t.statuses == nil {
+		t.statuses = make(map[gethcommon.Hash]trackedStatus)
+	}
+	t.statuses[hash] = trackedStatus{status: status, expiry: time.Unix(int64(env.Expiry), 0)}
+
+	return current, true
+}
+
+// isForward checks if the status may follow the current one. Delivered and
+// failed statuses are final, and the same status may be repeated.
+func isForward(current, status Status) bool {
+	switch current {
+	case StatusDelivered, StatusFailed:
+		return status == current
+	case StatusUnknown:
+		return true
+	}
+
+	return status >= current
+}
+
+// notify calls a subscriber, recovering from and logging any panic.
+func notify(sub DeliverySubscriber, state MessageDeliveryState) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("Delivery subscriber panicked", "status", state.Status, "error", r)
+		}
+	}()
+
+	sub(state)
+}
@@ -0,0 +1,422 @@
+package delivery
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsubscribeBySubscribeIndex(t *testing.T) {
+	var d DeliveryNotification
+	received := make([]int, 3)
+
+	subscriber := func(i int) DeliverySubscriber {
+		return func(MessageDeliveryState) {
+			received[i]++
+		}
+	}
+
+	d.Subscribe(subscriber(0))
+	middle := d.Subscribe(subscriber(1))
+	d.Subscribe(subscriber(2))
+
+	d.Unsubscribe(middle)
+	d.Send(&whisper.Envelope{}, 1)
+
+	require.Equal(t, []int{1, 0, 1}, received)
+}
+
+func TestUnsubscribeLast(t *testing.T) {
+	var d DeliveryNotification
+	var calls int
+
+	ind := d.Subscribe(func(MessageDeliveryState) { calls++ })
+	require.NotPanics(t, func() { d.Unsubscribe(ind) })
+
+	d.Send(&whisper.Envelope{}, 1)
+	require.Zero(t, calls)
+}
+
+func TestInterleavedSubscriptions(t *testing.T) {
+	var d DeliveryNotification
+	received := make(map[string]int)
+
+	subscriber := func(name string) DeliverySubscriber {
+		return func(MessageDeliveryState) {
+			received[name]++
+		}
+	}
+
+	a := d.Subscribe(subscriber("a"))
+	b := d.Subscribe(subscriber("b"))
+	d.Unsubscribe(a)
+	c := d.Subscribe(subscriber("c"))
+	e := d.Subscribe(subscriber("e"))
+	d.Unsubscribe(c)
+	d.Unsubscribe(a) // removing twice is a no-op
+
+	d.Send(&whisper.Envelope{}, 1)
+	require.Equal(t, map[string]int{"b": 1, "e": 1}, received)
+
+	d.Unsubscribe(e)
+	d.Unsubscribe(b)
+
+	d.Send(&whisper.Envelope{}, 1)
+	require.Equal(t, map[string]int{"b": 1, "e": 1}, received)
+}
+
+func TestMaxSubscribers(t *testing.T) {
+	var d DeliveryNotification
+	d.SetMaxSubscribers(2)
+
+	var calls int
+	first := d.Subscribe(func(MessageDeliveryState) { calls++ })
+	require.NotEqual(t, NoSubscription, first)
+	require.NotEqual(t, NoSubscription, d.Filter(StatusSent, func(MessageDeliveryState) { calls++ }))
+	require.Equal(t, 2, d.SubscriberCount())
+
+	require.Equal(t, NoSubscription, d.Subscribe(func(MessageDeliveryState) { calls++ }))
+	ch, id := d.SubscribeChan()
+	require.Equal(t, NoSubscription, id)
+	_, ok := <-ch
+	require.False(t, ok, "channel of a rejected subscriber should be closed")
+	require.Equal(t, 2, d.SubscriberCount())
+
+	d.Send(&whisper.Envelope{}, StatusSent)
+	require.Equal(t, 2, calls, "rejected subscribers should not be notified")
+
+	// a slot is released by unsubscribing
+	d.Unsubscribe(first)
+	require.Equal(t, 1, d.SubscriberCount())
+	require.NotEqual(t, NoSubscription, d.Subscribe(func(MessageDeliveryState) {}))
+
+	d.SetMaxSubscribers(0)
+	require.NotEqual(t, NoSubscription, d.Subscribe(func(MessageDeliveryState) {}))
+	require.Equal(t, 3, d.SubscriberCount())
+}
+
+func TestFilter(t *testing.T) {
+	var d DeliveryNotification
+	var statuses []Status
+
+	d.Filter(2, func(m MessageDeliveryState) { statuses = append(statuses, m.Status) })
+
+	for _, status := range []Status{1, 2, 3, 2} {
+		d.Send(&whisper.Envelope{}, status)
+	}
+
+	require.Equal(t, []Status{2, 2}, statuses)
+}
+
+func TestFilterUntil(t *testing.T) {
+	var d DeliveryNotification
+	var statuses []Status
+
+	d.FilterUntil(2, func(m MessageDeliveryState) { statuses = append(statuses, m.Status) })
+
+	for _, status := range []Status{1, 2, 3} {
+		d.Send(&whisper.Envelope{}, status)
+	}
+
+	require.Equal(t, []Status{1, 2}, statuses)
+}
+
+func TestFilterTopic(t *testing.T) {
+	var d DeliveryNotification
+	var received []whisper.TopicType
+
+	topicA := whisper.BytesToTopic([]byte("aaaa"))
+	topicB := whisper.BytesToTopic([]byte("bbbb"))
+
+	d.FilterTopic(topicA, func(m MessageDeliveryState) { received = append(received, m.Envelope.Topic) })
+
+	d.Send(&whisper.Envelope{Topic: topicA}, 1)
+	d.Send(&whisper.Envelope{Topic: topicB}, 1)
+	d.Send(nil, 1)
+	d.Send(&whisper.Envelope{Topic: topicA}, 2)
+
+	require.Equal(t, []whisper.TopicType{topicA, topicA}, received)
+}
+
+func TestFilterTopicStatus(t *testing.T) {
+	var d DeliveryNotification
+	var statuses []Status
+
+	topicA := whisper.BytesToTopic([]byte("aaaa"))
+	topicB := whisper.BytesToTopic([]byte("bbbb"))
+
+	d.FilterTopicStatus(topicA, 2, func(m MessageDeliveryState) { statuses = append(statuses, m.Status) })
+
+	d.Send(&whisper.Envelope{Topic: topicA}, 1)
+	d.Send(&whisper.Envelope{Topic: topicB}, 2)
+	d.Send(&whisper.Envelope{Topic: topicA}, 2)
+
+	require.Equal(t, []Status{2}, statuses)
+}
+
+func TestFilterRecipient(t *testing.T) {
+	w := whisper.New(nil)
+
+	var keyIDs []string
+	var envs []*whisper.Envelope
+	for i := 0; i < 2; i++ {
+		keyID, err := w.GenerateSymKey()
+		require.NoError(t, err)
+		key, err := w.GetSymKey(keyID)
+		require.NoError(t, err)
+
+		params := &whisper.MessageParams{
+			TTL:      10,
+			KeySym:   key,
+			Topic:    whisper.BytesToTopic([]byte("test")),
+			WorkTime: 1,
+			PoW:      0.01,
+			Payload:  []byte("hello"),
+		}
+		msg, err := whisper.NewSentMessage(params)
+		require.NoError(t, err)
+		env, err := msg.Wrap(params)
+		require.NoError(t, err)
+
+		keyIDs = append(keyIDs, keyID)
+		envs = append(envs, env)
+	}
+
+	var d DeliveryNotification
+	d.SetKeyStore(w)
+
+	received := make([][]*whisper.Envelope, 3)
+	for i, keyID := range append(keyIDs, "unknown") {
+		i := i
+		d.FilterRecipient(keyID, func(m MessageDeliveryState) { received[i] = append(received[i], m.Envelope) })
+	}
+
+	for _, env := range envs {
+		d.Send(env, StatusDelivered)
+	}
+
+	require.Equal(t, []*whisper.Envelope{envs[0]}, received[0])
+	require.Equal(t, []*whisper.Envelope{envs[1]}, received[1])
+	require.Empty(t, received[2])
+}
+
+func TestSubscribeChan(t *testing.T) {
+	var d DeliveryNotification
+
+	ch, id := d.SubscribeChan()
+
+	for _, status := range []Status{1, 2, 3} {
+		d.Send(&whisper.Envelope{}, status)
+	}
+
+	for _, status := range []Status{1, 2, 3} {
+		m := <-ch
+		require.Equal(t, status, m.Status)
+	}
+
+	d.Unsubscribe(id)
+
+	_, ok := <-ch
+	require.False(t, ok, "channel should be closed")
+
+	// sending after unsubscribe must not panic
+	d.Send(&whisper.Envelope{}, 4)
+}
+
+func TestSubscribeChanBufferedDrops(t *testing.T) {
+	var d DeliveryNotification
+
+	ch, id := d.SubscribeChanBuffered(1)
+	defer d.Unsubscribe(id)
+
+	d.Send(&whisper.Envelope{}, 1)
+	d.Send(&whisper.Envelope{}, 2) // dropped
+
+	require.Equal(t, Status(1), (<-ch).Status)
+	require.Len(t, ch, 0)
+}
+
+func TestSubscribeChanBlockingUnsubscribe(t *testing.T) {
+	var d DeliveryNotification
+
+	ch, id := d.SubscribeChanBuffered(0)
+
+	sent := make(chan struct{})
+	go func() {
+		d.Send(&whisper.Envelope{}, 1)
+		close(sent)
+	}()
+
+	require.Equal(t, Status(1), (<-ch).Status)
+	<-sent
+
+	// Send blocked on an unread channel is released by Unsubscribe
+	go d.Send(&whisper.Envelope{}, 2)
+	time.Sleep(10 * time.Millisecond)
+	d.Unsubscribe(id)
+
+	for range ch {
+	}
+}
+
+func TestSendRecoversFromPanic(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		var d DeliveryNotification
+		d.SetAsync(async)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+
+		d.Subscribe(func(MessageDeliveryState) {
+			defer wg.Done()
+			panic("subscriber failure")
+		})
+		d.Subscribe(func(MessageDeliveryState) { wg.Done() })
+
+		require.NotPanics(t, func() { d.Send(&whisper.Envelope{}, 1) })
+		wg.Wait()
+	}
+}
+
+func TestAsyncSendDoesNotBlock(t *testing.T) {
+	var d DeliveryNotification
+	d.SetAsync(true)
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	d.Subscribe(func(MessageDeliveryState) { <-unblock })
+
+	received := make(chan struct{})
+	d.Subscribe(func(MessageDeliveryState) { close(received) })
+
+	sent := make(chan struct{})
+	go func() {
+		d.Send(&whisper.Envelope{}, 1)
+		close(sent)
+	}()
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("Send is blocked by a slow subscriber")
+	}
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("subscriber was not notified")
+	}
+}
+
+func TestSendAsync(t *testing.T) {
+	var d DeliveryNotification
+
+	var received []*whisper.Envelope
+	d.Subscribe(func(m MessageDeliveryState) { received = append(received, m.Envelope) })
+
+	envs := make([]*whisper.Envelope, 1000)
+	for i := range envs {
+		envs[i] = &whisper.Envelope{EnvNonce: uint64(i)}
+		d.SendAsync(envs[i], StatusSent)
+	}
+	d.Close()
+
+	require.Equal(t, envs, received, "all states should be delivered in order")
+	require.Equal(t, uint64(len(envs)), d.Stats()[StatusSent])
+
+	// states are sent synchronously once closed
+	env := &whisper.Envelope{}
+	d.SendAsync(env, StatusSent)
+	require.Equal(t, env, received[len(received)-1])
+	require.NotPanics(t, d.Close)
+}
+
+func TestDedupWindow(t *testing.T) {
+	var d DeliveryNotification
+	d.SetDedupWindow(50 * time.Millisecond)
+
+	var statuses []Status
+	d.Subscribe(func(m MessageDeliveryState) { statuses = append(statuses, m.Status) })
+
+	env := &whisper.Envelope{EnvNonce: 1}
+	d.Send(env, StatusSent)
+	d.Send(env, StatusSent)
+	require.Equal(t, []Status{StatusSent}, statuses, "repeated state should be suppressed")
+
+	// other statuses and envelopes pass through
+	d.Send(env, StatusDelivered)
+	d.Send(&whisper.Envelope{EnvNonce: 2}, StatusSent)
+	require.Equal(t, []Status{StatusSent, StatusDelivered, StatusSent}, statuses)
+	require.Equal(t, uint64(2), d.Stats()[StatusSent])
+
+	// the state is sent again once the window passes
+	time.Sleep(60 * time.Millisecond)
+	d.Send(env, StatusSent)
+	require.Len(t, statuses, 4)
+
+	d.SetDedupWindow(0)
+	d.Send(env, StatusSent)
+	require.Len(t, statuses, 5)
+}
+
+func TestEnforceTransitions(t *testing.T) {
+	var d DeliveryNotification
+	d.SetEnforceTransitions(true)
+
+	var statuses []Status
+	d.Subscribe(func(m MessageDeliveryState) { statuses = append(statuses, m.Status) })
+
+	env := &whisper.Envelope{EnvNonce: 1, Expiry: uint32(time.Now().Add(time.Minute).Unix())}
+	require.Equal(t, StatusUnknown, d.EnvelopeStatus(env.Hash()))
+
+	d.Send(env, StatusDelivered)
+	d.Send(env, StatusQueued)
+	require.Equal(t, []Status{StatusDelivered}, statuses, "backward transition should be dropped")
+	require.Equal(t, StatusDelivered, d.EnvelopeStatus(env.Hash()))
+
+	failed := &whisper.Envelope{EnvNonce: 2, Expiry: env.Expiry}
+	d.Send(failed, StatusQueued)
+	d.Send(failed, StatusSent)
+	d.Send(failed, StatusFailed)
+	d.Send(failed, StatusDelivered)
+	require.Equal(t, []Status{StatusDelivered, StatusQueued, StatusSent, StatusFailed}, statuses)
+	require.Equal(t, StatusFailed, d.EnvelopeStatus(failed.Hash()))
+
+	d.SetEnforceTransitions(false)
+	require.Equal(t, StatusUnknown, d.EnvelopeStatus(env.Hash()))
+	d.Send(env, StatusQueued)
+	require.Len(t, statuses, 5)
+}
+
+func TestStats(t *testing.T) {
+	var d DeliveryNotification
+	require.Empty(t, d.Stats())
+
+	var wg sync.WaitGroup
+	for _, status := range []Status{1, 1, 2, 3, 3, 3} {
+		wg.Add(1)
+		go func(status Status) {
+			defer wg.Done()
+			d.Send(&whisper.Envelope{}, status)
+			d.Stats()
+		}(status)
+	}
+	wg.Wait()
+
+	require.Equal(t, map[Status]uint64{1: 2, 2: 1, 3: 3}, d.Stats())
+
+	d.ResetStats()
+	require.Empty(t, d.Stats())
+}
+
+func TestStatusString(t *testing.T) {
+	require.Equal(t, "queued", StatusQueued.String())
+	require.Equal(t, "sent", StatusSent.String())
+	require.Equal(t, "delivered", StatusDelivered.String())
+	require.Equal(t, "failed", StatusFailed.String())
+	require.Equal(t, "Status(42)", Status(42).String())
+}
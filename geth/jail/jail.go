@@ -173,6 +173,9 @@ func (jail *Jail) Send(call otto.FunctionCall) otto.Value {
 		throwJSException(fmt.Errorf("Error getting RPC client. Node stopped?"))
 	}
 	response := rpc.CallRaw(request.String())
+	if response == "" { // notifications are not responded to
+		return otto.UndefinedValue()
+	}
 
 	// unmarshal response to pass to otto
 	var resp interface{}
@@ -88,8 +88,28 @@ func SetLogFile(filename string) error {
 	return nil
 }
 
+// SetLogFileAndStderr configures logger to write output into file, and to os.Stderr as well.
+// This call preserves current logging level.
+func SetLogFileAndStderr(filename string) error {
+	handler, err := log.FileHandler(filename, log.TerminalFormat(false))
+	if err != nil {
+		return err
+	}
+
+	handler = log.MultiHandler(handler, log.StreamHandler(os.Stderr, log.TerminalFormat(true)))
+
+	logger.handler = handler
+	setHandler(logger.level, handler)
+	return nil
+}
+
 func levelFromString(level string) log.Lvl {
-	lvl, err := log.LvlFromString(strings.ToLower(level))
+	level = strings.ToLower(level)
+	if level == "warning" {
+		level = "warn" // ethereum-go knows only the short name
+	}
+
+	lvl, err := log.LvlFromString(level)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Incorrect log level: %s, using defaults\n", level)
 		lvl = log.LvlInfo
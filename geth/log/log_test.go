@@ -71,3 +71,10 @@ func TestLogFile(t *testing.T) {
 	require.Contains(t, got, info)
 	require.NotContains(t, got, debug)
 }
+
+func TestLevelFromString(t *testing.T) {
+	require.Equal(t, log.LvlWarn, levelFromString("WARNING"))
+	require.Equal(t, log.LvlWarn, levelFromString("warn"))
+	require.Equal(t, log.LvlDebug, levelFromString("DEBUG"))
+	require.Equal(t, log.LvlInfo, levelFromString("verbose"))
+}
@@ -0,0 +1,33 @@
+package messaging
+
+// NewFilter installs a whisper filter matching given criteria and returns its ID.
+// Unlike Subscribe, messages are not passed to a handler, they are collected
+// until they are retrieved with GetFilterMessages.
+func (m *Messenger) NewFilter(criteria FilterCriteria) (string, error) {
+	filter, err := m.whisperFilter(criteria)
+	if err != nil {
+		return "", err
+	}
+
+	return m.whisper.Subscribe(filter)
+}
+
+// GetFilterMessages returns messages collected by a filter with a given ID
+// since the last call. Received ACKs are handled and left out.
+func (m *Messenger) GetFilterMessages(filterID string) ([]ReceivedMessage, error) {
+	filter := m.whisper.GetFilter(filterID)
+	if filter == nil {
+		return nil, ErrFilterNotFound
+	}
+
+	return m.retrieve(filter), nil
+}
+
+// DeleteFilter removes a filter with a given ID.
+func (m *Messenger) DeleteFilter(filterID string) error {
+	if m.whisper.GetFilter(filterID) == nil {
+		return ErrFilterNotFound
+	}
+
+	return m.whisper.Unsubscribe(filterID)
+}
@@ -0,0 +1,34 @@
+package messaging
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// AddSymKey stores a hex encoded symmetric key, e.g. one shared by members
+// of a group chat, and returns its ID. Keys are kept in whisper's key store
+// until the node stops.
+func (m *Messenger) AddSymKey(keyHex string) (string, error) {
+	key, err := hexutil.Decode(keyHex)
+	if err != nil {
+		return "", err
+	}
+
+	return m.whisper.AddSymKeyDirect(key)
+}
+
+// GenerateSymKey generates and stores a random symmetric key and returns its ID.
+func (m *Messenger) GenerateSymKey() (string, error) {
+	return m.whisper.GenerateSymKey()
+}
+
+// SymKey returns a stored symmetric key with a given ID, to be used
+// in WhisperMessage.SymKey and FilterCriteria.SymKey.
+func (m *Messenger) SymKey(keyID string) ([]byte, error) {
+	return m.whisper.GetSymKey(keyID)
+}
+
+// DeleteSymKey removes a symmetric key with a given ID.
+// It returns false if there is no such key.
+func (m *Messenger) DeleteSymKey(keyID string) bool {
+	return m.whisper.DeleteSymKey(keyID)
+}
@@ -0,0 +1,114 @@
+package messaging
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+)
+
+// mailServerRequestTTL is how long requests to mail servers live, in seconds.
+const mailServerRequestTTL = 10
+
+// errors
+var (
+	ErrNoMailServerPassword = errors.New("mail server password is not set")
+	ErrNoServer             = errors.New("p2p server is required to sign mail server requests")
+	ErrInvalidTimeRange     = errors.New("lower bound of the time range is after the upper one")
+)
+
+// SetMailServerPassword sets the password mail servers are configured with,
+// requests for historic messages are encrypted with a key derived from it.
+func (m *Messenger) SetMailServerPassword(password string) error {
+	keyID, err := m.whisper.AddSymKeyFromPassword(password)
+	if err != nil {
+		return err
+	}
+	defer m.whisper.DeleteSymKey(keyID)
+
+	key, err := m.whisper.GetSymKey(keyID)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.mailServerKey = key
+	m.mu.Unlock()
+
+	return nil
+}
+
+// RequestHistoricMessages asks a mail server with a given enode URL for envelopes
+// with given topics (all topics if empty) sent between from and to, in Unix time.
+// The mail server has to be connected as a peer. Its envelopes are delivered
+// as peer-to-peer messages to filters and subscriptions with AllowP2P set.
+//
+// Mail servers do not confirm requests, neither do they report when all envelopes
+// have been sent, so the function returns as soon as the requests are sent.
+// A request per topic is sent, as mail servers filter envelopes by a single topic.
+func (m *Messenger) RequestHistoricMessages(mailServerEnode string, topics []whisper.TopicType, from, to uint32) error {
+	if from > to {
+		return ErrInvalidTimeRange
+	}
+	if m.server == nil {
+		return ErrNoServer
+	}
+
+	m.mu.Lock()
+	key := m.mailServerKey
+	m.mu.Unlock()
+	if key == nil {
+		return ErrNoMailServerPassword
+	}
+
+	node, err := discover.ParseNode(mailServerEnode)
+	if err != nil {
+		return err
+	}
+
+	if len(topics) == 0 {
+		topics = []whisper.TopicType{{}}
+	}
+	for _, topic := range topics {
+		env, err := m.mailServerRequest(key, topic, from, to)
+		if err != nil {
+			return err
+		}
+
+		if err := m.whisper.RequestHistoricMessages(node.ID[:], env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mailServerRequest returns an envelope requesting envelopes with a given topic,
+// the zero topic meaning all of them. It is signed with the node key, which
+// the mail server checks against the ID of the peer the request comes from.
+func (m *Messenger) mailServerRequest(key []byte, topic whisper.TopicType, from, to uint32) (*whisper.Envelope, error) {
+	payload := make([]byte, 8, 8+whisper.TopicLength)
+	binary.BigEndian.PutUint32(payload, from)
+	binary.BigEndian.PutUint32(payload[4:], to)
+	if topic != (whisper.TopicType{}) {
+		payload = append(payload, topic[:]...)
+	}
+
+	params := &whisper.MessageParams{
+		TTL:      mailServerRequestTTL,
+		Src:      m.server.PrivateKey,
+		KeySym:   key,
+		Topic:    topic,
+		WorkTime: DefaultWorkTime,
+		PoW:      DefaultPoW,
+		Payload:  payload,
+	}
+
+	sent, err := whisper.NewSentMessage(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return sent.Wrap(params)
+}
@@ -0,0 +1,151 @@
+package messaging
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/stretchr/testify/require"
+)
+
+// whisper message codes of the status and mail server request messages
+const (
+	statusCode     = 0
+	p2pCode        = 2
+	p2pRequestCode = 3
+)
+
+// connectStubPeer connects a whisper peer with a given ID, which is driven
+// by the returned end of a pipe, and runs the handshake with it.
+func connectStubPeer(t *testing.T, w *whisper.Whisper, id discover.NodeID) p2p.MsgReadWriter {
+	local, remote := p2p.MsgPipe()
+	go w.Protocols()[0].Run(p2p.NewPeer(id, "stub", nil), local) // nolint: errcheck
+
+	msg, err := remote.ReadMsg()
+	require.NoError(t, err)
+	require.EqualValues(t, statusCode, msg.Code)
+	require.NoError(t, msg.Discard())
+	require.NoError(t, p2p.Send(remote, statusCode, whisper.ProtocolVersion))
+
+	return remote
+}
+
+// readRequest returns the next mail server request received by a stub peer.
+func readRequest(t *testing.T, rw p2p.MsgReadWriter) *whisper.Envelope {
+	for {
+		msg, err := rw.ReadMsg()
+		require.NoError(t, err)
+		if msg.Code != p2pRequestCode {
+			require.NoError(t, msg.Discard())
+			continue
+		}
+
+		var env whisper.Envelope
+		require.NoError(t, msg.Decode(&env))
+		return &env
+	}
+}
+
+func TestRequestHistoricMessages(t *testing.T) {
+	w := startWhisper(t)
+	defer w.Stop() // nolint: errcheck
+
+	nodeKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	server := &p2p.Server{Config: p2p.Config{PrivateKey: nodeKey}}
+
+	m := New(w, server, nil)
+	require.NoError(t, m.SetMailServerPassword("status-offline-inbox"))
+
+	mailServerKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	mailServerID := discover.PubkeyID(&mailServerKey.PublicKey)
+	mailServer := connectStubPeer(t, w, mailServerID)
+	defer mailServer.(*p2p.MsgPipeRW).Close() // nolint: errcheck
+
+	topics := []whisper.TopicType{
+		whisper.BytesToTopic([]byte("abcd")),
+		whisper.BytesToTopic([]byte("efgh")),
+	}
+	enode := fmt.Sprintf("enode://%x@127.0.0.1:30303", mailServerID[:])
+	errc := make(chan error, 1)
+	go func() {
+		errc <- m.RequestHistoricMessages(enode, topics, 100, 200)
+	}()
+
+	// the mail server derives the key from the same password
+	keyID, err := w.AddSymKeyFromPassword("status-offline-inbox")
+	require.NoError(t, err)
+	key, err := w.GetSymKey(keyID)
+	require.NoError(t, err)
+
+	for _, topic := range topics {
+		request := readRequest(t, mailServer)
+
+		msg := request.Open(&whisper.Filter{KeySym: key})
+		require.NotNil(t, msg, "request is not encrypted with the mail server key")
+		require.Equal(t, crypto.PubkeyToAddress(nodeKey.PublicKey), crypto.PubkeyToAddress(*msg.Src))
+		require.Len(t, msg.Payload, 8+whisper.TopicLength)
+		require.EqualValues(t, 100, binary.BigEndian.Uint32(msg.Payload[:4]))
+		require.EqualValues(t, 200, binary.BigEndian.Uint32(msg.Payload[4:8]))
+		require.Equal(t, topic, whisper.BytesToTopic(msg.Payload[8:]))
+	}
+
+	select {
+	case err := <-errc:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for requests to be sent")
+	}
+
+	// historic envelopes are delivered to subscriptions
+	symKey := make([]byte, 32)
+	_, err = rand.Read(symKey)
+	require.NoError(t, err)
+
+	received := make(chan ReceivedMessage, 1)
+	unsubscribe, err := m.Subscribe(FilterCriteria{
+		Topics: topics[:1],
+		SymKey: symKey,
+	}, func(msg ReceivedMessage) { received <- msg })
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	params := &whisper.MessageParams{
+		TTL:      10,
+		KeySym:   symKey,
+		Topic:    topics[0],
+		WorkTime: DefaultWorkTime,
+		PoW:      DefaultPoW,
+		Payload:  []byte("missed"),
+	}
+	sent, err := whisper.NewSentMessage(params)
+	require.NoError(t, err)
+	env, err := sent.Wrap(params)
+	require.NoError(t, err)
+	require.NoError(t, p2p.Send(mailServer, p2pCode, env))
+
+	require.Equal(t, []byte("missed"), receiveMessage(t, received).Payload)
+}
+
+func TestRequestHistoricMessagesErrors(t *testing.T) {
+	nodeKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	enode := fmt.Sprintf("enode://%x@127.0.0.1:30303", discover.PubkeyID(&nodeKey.PublicKey))
+
+	m := New(whisper.New(nil), &p2p.Server{Config: p2p.Config{PrivateKey: nodeKey}}, nil)
+	require.Equal(t, ErrNoMailServerPassword, m.RequestHistoricMessages(enode, nil, 100, 200))
+	require.Equal(t, ErrInvalidTimeRange, m.RequestHistoricMessages(enode, nil, 200, 100))
+
+	require.NoError(t, m.SetMailServerPassword("status-offline-inbox"))
+	require.Error(t, m.RequestHistoricMessages(enode, nil, 100, 200), "mail server is not a peer")
+
+	m = New(whisper.New(nil), nil, nil)
+	require.Equal(t, ErrNoServer, m.RequestHistoricMessages(enode, nil, 100, 200))
+}
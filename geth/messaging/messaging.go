@@ -0,0 +1,382 @@
+package messaging
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"sync"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/delivery"
+)
+
+// proof of work parameters of posted messages
+const (
+	DefaultPoW      = 0.01
+	DefaultWorkTime = 5 // in seconds
+)
+
+// pollInterval is how often subscriptions check their filters for new messages.
+const pollInterval = 100 * time.Millisecond
+
+// transmissionCycle is how often whisper broadcasts pooled envelopes to its peers.
+const transmissionCycle = 300 * time.Millisecond
+
+// ackPrefix starts payloads of ACK messages, followed by the hash of the acknowledged envelope.
+var ackPrefix = []byte("\x00ack")
+
+// errors
+var (
+	ErrNoEncryptionKey = errors.New("either symmetric key or public key is required")
+	ErrBothKeys        = errors.New("symmetric key and public key are mutually exclusive")
+	ErrLowPoW          = errors.New("proof of work is below the minimum accepted by whisper")
+	ErrNoDecryptionKey = errors.New("either symmetric key or private key is required")
+	ErrFilterNotFound  = errors.New("filter not found")
+)
+
+// WhisperMessage is a message to be posted to the whisper network.
+// It is encrypted either with SymKey or with PublicKey of the recipient.
+type WhisperMessage struct {
+	Topic     whisper.TopicType
+	Payload   []byte
+	SymKey    []byte
+	PublicKey *ecdsa.PublicKey
+	Sig       *ecdsa.PrivateKey // optional, signs the message
+	TTL       uint32            // in seconds, whisper.DefaultTTL if zero
+	PoW       float64           // DefaultPoW if zero
+	WorkTime  uint32            // max time to reach PoW in seconds, DefaultWorkTime if zero
+
+	// AckTimeout, if set, is how long to wait for the recipient to acknowledge
+	// the message. It is reported as failed if no ACK arrives in time.
+	AckTimeout time.Duration
+}
+
+// FilterCriteria describes messages a subscriber is interested in.
+// Messages are decrypted either with SymKey, a stored key with SymKeyID,
+// or with PrivateKey.
+type FilterCriteria struct {
+	Topics     []whisper.TopicType // all topics if empty
+	SymKey     []byte
+	SymKeyID   string // see AddSymKey and GenerateSymKey
+	PrivateKey *ecdsa.PrivateKey
+	Sig        *ecdsa.PublicKey // optional, only messages signed with its private key
+	MinPoW     float64
+
+	// AllowP2P accepts messages sent directly by peers, e.g. by mail servers.
+	// Subscribe accepts them regardless.
+	AllowP2P bool
+}
+
+// ReceivedMessage is a decrypted message received from the whisper network.
+type ReceivedMessage struct {
+	Topic        whisper.TopicType
+	Payload      []byte
+	Src          *ecdsa.PublicKey // nil if the message is not signed
+	Sent         uint32
+	TTL          uint32
+	EnvelopeHash gethcommon.Hash
+}
+
+// Messenger posts and receives whisper messages. If a delivery notification
+// is given, posted envelopes are reported as queued and then sent.
+//
+// Only the recipient can tell that an envelope is delivered, so posted envelopes
+// awaiting an ACK are reported as delivered once an ACK referencing them is
+// received by any of the messenger's subscriptions. Receiving an envelope
+// doesn't report it as delivered, as whisper passes envelopes posted locally
+// to local filters as well.
+type Messenger struct {
+	whisper  *whisper.Whisper
+	server   *p2p.Server
+	notifier *delivery.DeliveryNotification
+
+	mu            sync.Mutex // guards pending and mailServerKey
+	pending       map[gethcommon.Hash]pendingAck
+	mailServerKey []byte // see SetMailServerPassword
+}
+
+// pendingAck is a posted envelope awaiting an ACK.
+type pendingAck struct {
+	env   *whisper.Envelope
+	timer *time.Timer // reports the envelope as failed
+}
+
+// New creates a messenger on top of a given whisper service. The server is
+// used to find whisper peers posted envelopes are propagated to.
+// Both the server and the notifier may be nil.
+func New(w *whisper.Whisper, server *p2p.Server, notifier *delivery.DeliveryNotification) *Messenger {
+	return &Messenger{
+		whisper:  w,
+		server:   server,
+		notifier: notifier,
+		pending:  make(map[gethcommon.Hash]pendingAck),
+	}
+}
+
+// Post encrypts, seals and sends a message. It returns the hash of the envelope.
+// Messages with PoW below the minimum accepted by whisper are rejected.
+func (m *Messenger) Post(msg WhisperMessage) (string, error) {
+	if msg.SymKey == nil && msg.PublicKey == nil {
+		return "", ErrNoEncryptionKey
+	}
+	if msg.SymKey != nil && msg.PublicKey != nil {
+		return "", ErrBothKeys
+	}
+
+	ttl := msg.TTL
+	if ttl == 0 {
+		ttl = whisper.DefaultTTL
+	}
+	pow := msg.PoW
+	if pow == 0 {
+		pow = DefaultPoW
+	}
+	if pow < m.whisper.MinPow() {
+		return "", ErrLowPoW
+	}
+	workTime := msg.WorkTime
+	if workTime == 0 {
+		workTime = DefaultWorkTime
+	}
+
+	params := &whisper.MessageParams{
+		TTL:      ttl,
+		Src:      msg.Sig,
+		Dst:      msg.PublicKey,
+		KeySym:   msg.SymKey,
+		Topic:    msg.Topic,
+		WorkTime: workTime,
+		PoW:      pow,
+		Payload:  msg.Payload,
+	}
+
+	sent, err := whisper.NewSentMessage(params)
+	if err != nil {
+		return "", err
+	}
+
+	env, err := sent.Wrap(params)
+	if err != nil {
+		return "", err
+	}
+
+	if err := m.whisper.Send(env); err != nil {
+		return "", err
+	}
+
+	if m.notifier != nil {
+		m.notifier.Send(env, delivery.StatusQueued)
+		go m.watchSent(env)
+
+		if msg.AckTimeout > 0 {
+			m.awaitAck(env, msg.AckTimeout)
+		}
+	}
+
+	return env.Hash().Hex(), nil
+}
+
+// Ack acknowledges a received envelope by posting an ACK message referencing it.
+// The message's payload is replaced, the other fields are used as in Post.
+func (m *Messenger) Ack(msg WhisperMessage, hash gethcommon.Hash) (string, error) {
+	msg.Payload = append(append([]byte{}, ackPrefix...), hash.Bytes()...)
+	msg.AckTimeout = 0
+
+	return m.Post(msg)
+}
+
+// awaitAck records a posted envelope and reports it as failed
+// unless an ACK arrives within the timeout.
+func (m *Messenger) awaitAck(env *whisper.Envelope, timeout time.Duration) {
+	hash := env.Hash()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pending[hash] = pendingAck{
+		env: env,
+		timer: time.AfterFunc(timeout, func() {
+			if m.resolveAck(hash) != nil {
+				m.notifier.Send(env, delivery.StatusFailed)
+			}
+		}),
+	}
+}
+
+// resolveAck removes an envelope from the ones awaiting an ACK and returns it,
+// or nil if it is not awaited (anymore).
+func (m *Messenger) resolveAck(hash gethcommon.Hash) *whisper.Envelope {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending, ok := m.pending[hash]
+	if !ok {
+		return nil
+	}
+	pending.timer.Stop()
+	delete(m.pending, hash)
+
+	return pending.env
+}
+
+// handleAck reports the envelope acknowledged by a given payload as delivered.
+// It returns false if the payload is not an ACK.
+func (m *Messenger) handleAck(payload []byte) bool {
+	if len(payload) != len(ackPrefix)+gethcommon.HashLength || !bytes.HasPrefix(payload, ackPrefix) {
+		return false
+	}
+
+	hash := gethcommon.BytesToHash(payload[len(ackPrefix):])
+	if env := m.resolveAck(hash); env != nil {
+		m.notifier.Send(env, delivery.StatusDelivered)
+	}
+
+	return true
+}
+
+// watchSent reports a posted envelope as sent once it has stayed in the pool
+// for a whole transmission cycle with whisper peers connected. Whisper does not
+// tell which envelopes it broadcasts, so this is as close as we can get.
+// Nothing is reported if the envelope expires first.
+func (m *Messenger) watchSent(env *whisper.Envelope) {
+	ticker := time.NewTicker(transmissionCycle)
+	defer ticker.Stop()
+
+	expiry := time.Unix(int64(env.Expiry), 0)
+	connected := false
+	for range ticker.C {
+		if time.Now().After(expiry) {
+			return
+		}
+
+		if !m.hasWhisperPeers() {
+			connected = false
+			continue
+		}
+
+		if connected {
+			m.notifier.Send(env, delivery.StatusSent)
+			return
+		}
+		connected = true
+	}
+}
+
+// hasWhisperPeers checks if any connected peer runs the whisper protocol.
+func (m *Messenger) hasWhisperPeers() bool {
+	if m.server == nil {
+		return false
+	}
+
+	for _, peer := range m.server.Peers() {
+		for _, c := range peer.Caps() {
+			if c.Name == whisper.ProtocolName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Subscribe installs a whisper filter and calls handler for every message matching
+// the criteria, except for ACKs. The returned function removes the subscription.
+func (m *Messenger) Subscribe(criteria FilterCriteria, handler func(ReceivedMessage)) (func(), error) {
+	criteria.AllowP2P = true
+	filter, err := m.whisperFilter(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := m.whisper.Subscribe(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	quit := make(chan struct{})
+	go m.poll(filter, handler, quit)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(quit)
+			m.whisper.Unsubscribe(id) // nolint: errcheck
+		})
+	}, nil
+}
+
+// poll passes messages collected by the filter to the handler until quit is closed.
+func (m *Messenger) poll(filter *whisper.Filter, handler func(ReceivedMessage), quit chan struct{}) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			for _, msg := range m.retrieve(filter) {
+				handler(msg)
+			}
+		}
+	}
+}
+
+// whisperFilter returns a whisper filter matching given criteria.
+func (m *Messenger) whisperFilter(criteria FilterCriteria) (*whisper.Filter, error) {
+	symKey := criteria.SymKey
+	if criteria.SymKeyID != "" {
+		var err error
+		if symKey, err = m.whisper.GetSymKey(criteria.SymKeyID); err != nil {
+			return nil, err
+		}
+	}
+	if symKey == nil && criteria.PrivateKey == nil {
+		return nil, ErrNoDecryptionKey
+	}
+
+	topics := make([][]byte, len(criteria.Topics))
+	for i, topic := range criteria.Topics {
+		topics[i] = topic[:]
+	}
+
+	filter := &whisper.Filter{
+		Src:      criteria.Sig,
+		KeyAsym:  criteria.PrivateKey,
+		KeySym:   symKey,
+		Topics:   topics,
+		PoW:      criteria.MinPoW,
+		AllowP2P: criteria.AllowP2P,
+	}
+	if symKey != nil {
+		filter.SymKeyHash = crypto.Keccak256Hash(symKey)
+	}
+
+	return filter, nil
+}
+
+// retrieve returns messages collected by the filter since the last call.
+// ACKs are handled and left out.
+func (m *Messenger) retrieve(filter *whisper.Filter) []ReceivedMessage {
+	var messages []ReceivedMessage
+	for _, msg := range filter.Retrieve() {
+		if m.handleAck(msg.Payload) {
+			continue
+		}
+
+		messages = append(messages, ReceivedMessage{
+			Topic:        msg.Topic,
+			Payload:      msg.Payload,
+			Src:          msg.Src,
+			Sent:         msg.Sent,
+			TTL:          msg.TTL,
+			EnvelopeHash: msg.EnvelopeHash,
+		})
+	}
+
+	return messages
+}
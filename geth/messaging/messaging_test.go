@@ -0,0 +1,341 @@
+package messaging
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/delivery"
+	"github.com/stretchr/testify/require"
+)
+
+func startWhisper(t *testing.T) *whisper.Whisper {
+	w := whisper.New(nil)
+	require.NoError(t, w.Start(nil))
+	return w
+}
+
+func receiveMessage(t *testing.T, received <-chan ReceivedMessage) ReceivedMessage {
+	select {
+	case msg := <-received:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+	}
+	return ReceivedMessage{}
+}
+
+func TestPostSubscribeSymmetric(t *testing.T) {
+	w := startWhisper(t)
+	defer w.Stop() // nolint: errcheck
+
+	var notifier delivery.DeliveryNotification
+	states, _ := notifier.SubscribeChan()
+
+	symKey := make([]byte, 32)
+	_, err := rand.Read(symKey)
+	require.NoError(t, err)
+	topic := whisper.BytesToTopic([]byte("test"))
+
+	m := New(w, nil, &notifier)
+
+	received := make(chan ReceivedMessage, 1)
+	unsubscribe, err := m.Subscribe(FilterCriteria{
+		Topics: []whisper.TopicType{topic},
+		SymKey: symKey,
+	}, func(msg ReceivedMessage) { received <- msg })
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	hash, err := m.Post(WhisperMessage{
+		Topic:   topic,
+		Payload: []byte("hello"),
+		SymKey:  symKey,
+		TTL:     10,
+	})
+	require.NoError(t, err)
+
+	msg := receiveMessage(t, received)
+	require.Equal(t, []byte("hello"), msg.Payload)
+	require.Equal(t, topic, msg.Topic)
+	require.Equal(t, hash, msg.EnvelopeHash.Hex())
+
+	select {
+	case state := <-states:
+		require.Equal(t, delivery.StatusQueued, state.Status)
+		require.Equal(t, hash, state.Envelope.Hash().Hex())
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for queued status")
+	}
+
+	// receiving an own envelope doesn't make it delivered, only an ACK does
+	select {
+	case state := <-states:
+		t.Fatalf("unexpected %s status without an ACK", state.Status)
+	case <-time.After(3 * pollInterval):
+	}
+}
+
+func TestSymKeys(t *testing.T) {
+	w := startWhisper(t)
+	defer w.Stop() // nolint: errcheck
+
+	m := New(w, nil, nil)
+	topic := whisper.BytesToTopic([]byte("test"))
+
+	keyID, err := m.GenerateSymKey()
+	require.NoError(t, err)
+	symKey, err := m.SymKey(keyID)
+	require.NoError(t, err)
+
+	received := make(chan ReceivedMessage, 1)
+	unsubscribe, err := m.Subscribe(FilterCriteria{
+		Topics: []whisper.TopicType{topic},
+		SymKey: symKey,
+	}, func(msg ReceivedMessage) { received <- msg })
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	// a member of the group adds the same key
+	sharedKeyID, err := m.AddSymKey(hexutil.Encode(symKey))
+	require.NoError(t, err)
+	sharedKey, err := m.SymKey(sharedKeyID)
+	require.NoError(t, err)
+	require.Equal(t, symKey, sharedKey)
+
+	_, err = m.Post(WhisperMessage{
+		Topic:   topic,
+		Payload: []byte("hello"),
+		SymKey:  sharedKey,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), receiveMessage(t, received).Payload)
+
+	require.True(t, m.DeleteSymKey(keyID))
+	require.False(t, m.DeleteSymKey(keyID))
+	_, err = m.SymKey(keyID)
+	require.Error(t, err)
+
+	_, err = m.AddSymKey("0xinvalid")
+	require.Error(t, err)
+}
+
+func TestPostSubscribeAsymmetric(t *testing.T) {
+	w := startWhisper(t)
+	defer w.Stop() // nolint: errcheck
+
+	recipient, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	topic := whisper.BytesToTopic([]byte("test"))
+
+	m := New(w, nil, nil)
+
+	received := make(chan ReceivedMessage, 1)
+	unsubscribe, err := m.Subscribe(FilterCriteria{
+		Topics:     []whisper.TopicType{topic},
+		PrivateKey: recipient,
+	}, func(msg ReceivedMessage) { received <- msg })
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	_, err = m.Post(WhisperMessage{
+		Topic:     topic,
+		Payload:   []byte("hello"),
+		PublicKey: &recipient.PublicKey,
+		Sig:       sender,
+	})
+	require.NoError(t, err)
+
+	msg := receiveMessage(t, received)
+	require.Equal(t, []byte("hello"), msg.Payload)
+	require.NotNil(t, msg.Src)
+	require.Equal(t, crypto.PubkeyToAddress(sender.PublicKey), crypto.PubkeyToAddress(*msg.Src))
+}
+
+func TestPostSubscribeKeys(t *testing.T) {
+	m := New(whisper.New(nil), nil, nil)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	_, err = m.Post(WhisperMessage{Payload: []byte("hello")})
+	require.Equal(t, ErrNoEncryptionKey, err)
+
+	_, err = m.Post(WhisperMessage{
+		Payload:   []byte("hello"),
+		SymKey:    make([]byte, 32),
+		PublicKey: &key.PublicKey,
+	})
+	require.Equal(t, ErrBothKeys, err)
+
+	_, err = m.Subscribe(FilterCriteria{}, func(ReceivedMessage) {})
+	require.Equal(t, ErrNoDecryptionKey, err)
+}
+
+func TestFilters(t *testing.T) {
+	w := startWhisper(t)
+	defer w.Stop() // nolint: errcheck
+
+	m := New(w, nil, nil)
+	topic := whisper.BytesToTopic([]byte("test"))
+
+	keyID, err := m.GenerateSymKey()
+	require.NoError(t, err)
+	symKey, err := m.SymKey(keyID)
+	require.NoError(t, err)
+
+	filterID, err := m.NewFilter(FilterCriteria{
+		Topics:   []whisper.TopicType{topic},
+		SymKeyID: keyID,
+		AllowP2P: true,
+	})
+	require.NoError(t, err)
+
+	hash, err := m.Post(WhisperMessage{
+		Topic:   topic,
+		Payload: []byte("hello"),
+		SymKey:  symKey,
+	})
+	require.NoError(t, err)
+
+	var messages []ReceivedMessage
+	for deadline := time.Now().Add(5 * time.Second); len(messages) == 0 && time.Now().Before(deadline); {
+		time.Sleep(pollInterval)
+		messages, err = m.GetFilterMessages(filterID)
+		require.NoError(t, err)
+	}
+	require.Len(t, messages, 1)
+	require.Equal(t, []byte("hello"), messages[0].Payload)
+	require.Equal(t, hash, messages[0].EnvelopeHash.Hex())
+
+	// messages are returned once
+	messages, err = m.GetFilterMessages(filterID)
+	require.NoError(t, err)
+	require.Empty(t, messages)
+
+	require.NoError(t, m.DeleteFilter(filterID))
+	_, err = m.GetFilterMessages(filterID)
+	require.Equal(t, ErrFilterNotFound, err)
+	require.Equal(t, ErrFilterNotFound, m.DeleteFilter(filterID))
+
+	_, err = m.NewFilter(FilterCriteria{SymKeyID: "unknown"})
+	require.Error(t, err)
+}
+
+func TestPostMinPoW(t *testing.T) {
+	w := whisper.New(&whisper.Config{
+		MaxMessageSize:     whisper.DefaultMaxMessageSize,
+		MinimumAcceptedPOW: 0.2,
+	})
+	require.NoError(t, w.Start(nil))
+	defer w.Stop() // nolint: errcheck
+
+	symKey := make([]byte, 32)
+	_, err := rand.Read(symKey)
+	require.NoError(t, err)
+
+	m := New(w, nil, nil)
+	msg := WhisperMessage{
+		Topic:   whisper.BytesToTopic([]byte("test")),
+		Payload: []byte("hello"),
+		SymKey:  symKey,
+		TTL:     10,
+	}
+
+	// DefaultPoW is below the minimum
+	_, err = m.Post(msg)
+	require.Equal(t, ErrLowPoW, err)
+
+	msg.PoW = 0.2
+	hash, err := m.Post(msg)
+	require.NoError(t, err)
+
+	envelopes := w.Envelopes()
+	require.Len(t, envelopes, 1)
+	require.Equal(t, hash, envelopes[0].Hash().Hex())
+	require.True(t, envelopes[0].PoW() >= 0.2)
+}
+
+func TestAckDelivered(t *testing.T) {
+	w := startWhisper(t)
+	defer w.Stop() // nolint: errcheck
+
+	var notifier delivery.DeliveryNotification
+	delivered := make(chan delivery.MessageDeliveryState, 1)
+	notifier.Filter(delivery.StatusDelivered, func(state delivery.MessageDeliveryState) { delivered <- state })
+	failed := make(chan delivery.MessageDeliveryState, 1)
+	notifier.Filter(delivery.StatusFailed, func(state delivery.MessageDeliveryState) { failed <- state })
+
+	symKey := make([]byte, 32)
+	_, err := rand.Read(symKey)
+	require.NoError(t, err)
+	ackTopic := whisper.BytesToTopic([]byte("ack"))
+
+	m := New(w, nil, &notifier)
+
+	// the sender listens for ACKs only
+	unsubscribe, err := m.Subscribe(FilterCriteria{
+		Topics: []whisper.TopicType{ackTopic},
+		SymKey: symKey,
+	}, func(ReceivedMessage) { t.Error("ACK passed to the handler") })
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	hash, err := m.Post(WhisperMessage{
+		Topic:      whisper.BytesToTopic([]byte("test")),
+		Payload:    []byte("hello"),
+		SymKey:     symKey,
+		AckTimeout: 5 * time.Second,
+	})
+	require.NoError(t, err)
+
+	// simulate the recipient acknowledging the message
+	_, err = m.Ack(WhisperMessage{Topic: ackTopic, SymKey: symKey}, gethcommon.HexToHash(hash))
+	require.NoError(t, err)
+
+	select {
+	case state := <-delivered:
+		require.Equal(t, hash, state.Envelope.Hash().Hex())
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for delivered status")
+	}
+
+	select {
+	case <-failed:
+		t.Fatal("acknowledged message reported as failed")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestAckTimeout(t *testing.T) {
+	w := startWhisper(t)
+	defer w.Stop() // nolint: errcheck
+
+	var notifier delivery.DeliveryNotification
+	failed := make(chan delivery.MessageDeliveryState, 1)
+	notifier.Filter(delivery.StatusFailed, func(state delivery.MessageDeliveryState) { failed <- state })
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	m := New(w, nil, &notifier)
+	hash, err := m.Post(WhisperMessage{
+		Topic:      whisper.BytesToTopic([]byte("test")),
+		Payload:    []byte("hello"),
+		PublicKey:  &key.PublicKey,
+		AckTimeout: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	select {
+	case state := <-failed:
+		require.Equal(t, hash, state.Envelope.Hash().Hex())
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for failed status")
+	}
+}
@@ -0,0 +1,126 @@
+package node
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/params"
+)
+
+// gasPriceTimeout limits the time of retrieving recent blocks to suggest a gas price.
+const gasPriceTimeout = time.Minute
+
+// rpcCaller performs RPC calls, blocks are retrieved with it to suggest a gas price.
+type rpcCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// SuggestGasPrice suggests a gas price for transactions based on recent blocks,
+// as configured by NodeConfig.GasPriceOracle. The lowest gas price of every sampled
+// block is taken, and the configured percentile of them is suggested. If sampled
+// blocks have no transactions, the gas price suggested by the network is used.
+func (m *NodeManager) SuggestGasPrice() (*big.Int, error) {
+	config, err := m.NodeConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client := m.RPCClient()
+	if client == nil {
+		return nil, ErrRPCClient
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gasPriceTimeout)
+	defer cancel()
+
+	return suggestGasPrice(ctx, client, config.GasPriceOracle)
+}
+
+// suggestGasPrice samples gas prices of recent blocks retrieved with a given caller.
+func suggestGasPrice(ctx context.Context, caller rpcCaller, config params.GasPriceOracleConfig) (*big.Int, error) {
+	blocks := config.Blocks
+	if blocks == 0 {
+		blocks = params.DefaultGasPriceOracleBlocks
+	}
+	percentile := config.Percentile
+	if percentile == 0 {
+		percentile = params.DefaultGasPriceOraclePercentile
+	}
+
+	var head hexutil.Uint64
+	if err := caller.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+		return nil, err
+	}
+
+	var prices []*big.Int
+	for i := uint64(0); i < uint64(blocks) && i <= uint64(head); i++ {
+		price, err := lowestGasPrice(ctx, caller, uint64(head)-i)
+		if err != nil {
+			return nil, err
+		}
+		if price != nil {
+			prices = append(prices, price)
+		}
+	}
+
+	var price *big.Int
+	if len(prices) == 0 {
+		var gasPrice hexutil.Big
+		if err := caller.CallContext(ctx, &gasPrice, "eth_gasPrice"); err != nil {
+			return nil, err
+		}
+		price = (*big.Int)(&gasPrice)
+	} else {
+		price = gasPricePercentile(prices, percentile)
+	}
+
+	return clampGasPrice(price, config.MinPrice, config.MaxPrice), nil
+}
+
+// lowestGasPrice returns the lowest gas price of transactions in a block with
+// a given number, or nil if the block has no transactions.
+func lowestGasPrice(ctx context.Context, caller rpcCaller, number uint64) (*big.Int, error) {
+	var block struct {
+		Transactions []struct {
+			GasPrice *hexutil.Big `json:"gasPrice"`
+		} `json:"transactions"`
+	}
+	if err := caller.CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.Uint64(number), true); err != nil {
+		return nil, err
+	}
+
+	var lowest *big.Int
+	for _, tx := range block.Transactions {
+		if tx.GasPrice == nil {
+			continue
+		}
+		if price := (*big.Int)(tx.GasPrice); lowest == nil || price.Cmp(lowest) < 0 {
+			lowest = price
+		}
+	}
+
+	return lowest, nil
+}
+
+// gasPricePercentile returns a given percentile (0-100) of non-empty gas prices.
+// The prices are sorted in place.
+func gasPricePercentile(prices []*big.Int, percentile int) *big.Int {
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Cmp(prices[j]) < 0 })
+
+	return new(big.Int).Set(prices[(len(prices)-1)*percentile/100])
+}
+
+// clampGasPrice limits a gas price to a given range, zero limits are ignored.
+func clampGasPrice(price *big.Int, min, max uint64) *big.Int {
+	if min > 0 && price.Cmp(new(big.Int).SetUint64(min)) < 0 {
+		return new(big.Int).SetUint64(min)
+	}
+	if max > 0 && price.Cmp(new(big.Int).SetUint64(max)) > 0 {
+		return new(big.Int).SetUint64(max)
+	}
+
+	return price
+}
@@ -0,0 +1,123 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+// blocksCaller serves blocks with transactions of given gas prices,
+// the last block being the head.
+type blocksCaller struct {
+	blocks   [][]int64
+	gasPrice int64
+	calls    []string
+}
+
+func (c *blocksCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	c.calls = append(c.calls, method)
+
+	var resp interface{}
+	switch method {
+	case "eth_blockNumber":
+		resp = hexutil.Uint64(len(c.blocks) - 1)
+	case "eth_gasPrice":
+		resp = (*hexutil.Big)(big.NewInt(c.gasPrice))
+	case "eth_getBlockByNumber":
+		var txs []map[string]interface{}
+		for _, price := range c.blocks[args[0].(hexutil.Uint64)] {
+			txs = append(txs, map[string]interface{}{"gasPrice": (*hexutil.Big)(big.NewInt(price))})
+		}
+		resp = map[string]interface{}{"transactions": txs}
+	default:
+		return fmt.Errorf("unexpected method %s", method)
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+func TestGasPricePercentile(t *testing.T) {
+	prices := func(values ...int64) []*big.Int {
+		result := make([]*big.Int, len(values))
+		for i, v := range values {
+			result[i] = big.NewInt(v)
+		}
+		return result
+	}
+
+	cases := []struct {
+		prices     []*big.Int
+		percentile int
+		expected   int64
+	}{
+		{prices(7), 60, 7},
+		{prices(5, 1, 4, 2, 3), 0, 1},
+		{prices(5, 1, 4, 2, 3), 50, 3},
+		{prices(5, 1, 4, 2, 3), 60, 3},
+		{prices(5, 1, 4, 2, 3), 75, 4},
+		{prices(5, 1, 4, 2, 3), 100, 5},
+		{prices(10, 20, 30, 40, 50, 60, 70, 80, 90, 100), 60, 60},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, big.NewInt(tc.expected), gasPricePercentile(tc.prices, tc.percentile), "%v at %d", tc.prices, tc.percentile)
+	}
+}
+
+func TestSuggestGasPrice(t *testing.T) {
+	caller := &blocksCaller{
+		blocks: [][]int64{
+			{100},        // not sampled
+			{30, 10},     // lowest is 10
+			{},           // no transactions, skipped
+			{50, 40},     // lowest is 40
+			{20, 20},     // lowest is 20
+			{90, 60, 70}, // lowest is 60
+		},
+	}
+
+	// sampled lowest prices are 10, 20, 40, 60
+	price, err := suggestGasPrice(context.Background(), caller, params.GasPriceOracleConfig{Blocks: 5, Percentile: 50})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(20), price)
+
+	price, err = suggestGasPrice(context.Background(), caller, params.GasPriceOracleConfig{Blocks: 5, Percentile: 100})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(60), price)
+
+	// more blocks than the chain has
+	price, err = suggestGasPrice(context.Background(), caller, params.GasPriceOracleConfig{Blocks: 100, Percentile: 100})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(100), price)
+
+	// clamped
+	price, err = suggestGasPrice(context.Background(), caller, params.GasPriceOracleConfig{Blocks: 5, Percentile: 50, MinPrice: 25})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(25), price)
+
+	price, err = suggestGasPrice(context.Background(), caller, params.GasPriceOracleConfig{Blocks: 5, Percentile: 100, MaxPrice: 50})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(50), price)
+}
+
+func TestSuggestGasPriceEmptyBlocks(t *testing.T) {
+	caller := &blocksCaller{
+		blocks:   [][]int64{{}, {}},
+		gasPrice: 18000000000,
+	}
+
+	price, err := suggestGasPrice(context.Background(), caller, params.GasPriceOracleConfig{})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(18000000000), price)
+	require.Contains(t, caller.calls, "eth_gasPrice")
+}
@@ -0,0 +1,88 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// lifecycleListeners dispatches node lifecycle events to subscribed listeners.
+// Events are queued and delivered in order by a separate routine, so
+// listeners are never called with NodeManager lock held.
+type lifecycleListeners struct {
+	mx          sync.Mutex // mx guards all fields
+	listeners   map[int]func(common.NodeEvent)
+	lastID      int
+	queue       []common.NodeEvent
+	dispatching bool // set while dispatch routine is running
+}
+
+// subscribe registers a listener and returns function removing it.
+func (l *lifecycleListeners) subscribe(fn func(common.NodeEvent)) func() {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if l.listeners == nil {
+		l.listeners = make(map[int]func(common.NodeEvent))
+	}
+
+	l.lastID++
+	id := l.lastID
+	l.listeners[id] = fn
+
+	return func() {
+		l.mx.Lock()
+		defer l.mx.Unlock()
+
+		delete(l.listeners, id)
+	}
+}
+
+// emit queues an event for delivery to all listeners.
+func (l *lifecycleListeners) emit(state common.NodeState, err error) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.queue = append(l.queue, common.NodeEvent{State: state, Error: err})
+	if !l.dispatching {
+		l.dispatching = true
+		go l.dispatch()
+	}
+}
+
+// dispatch delivers queued events until the queue is empty.
+func (l *lifecycleListeners) dispatch() {
+	for {
+		l.mx.Lock()
+		if len(l.queue) == 0 {
+			l.dispatching = false
+			l.mx.Unlock()
+			return
+		}
+
+		event := l.queue[0]
+		l.queue = l.queue[1:]
+
+		listeners := make([]func(common.NodeEvent), 0, len(l.listeners))
+		for _, fn := range l.listeners {
+			listeners = append(listeners, fn)
+		}
+		l.mx.Unlock()
+
+		for _, fn := range listeners {
+			notifyListener(fn, event)
+		}
+	}
+}
+
+// notifyListener calls a listener, recovering from and logging any panic.
+func notifyListener(fn func(common.NodeEvent), event common.NodeEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("Node lifecycle listener panicked", "state", event.State, "error", r)
+		}
+	}()
+
+	fn(event)
+}
@@ -0,0 +1,115 @@
+package node
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/common"
+)
+
+const (
+	// getLogsTimeout limits the time of querying logs of the whole block range.
+	getLogsTimeout = 5 * time.Minute
+	// logsChunkSize is the number of blocks logs are queried for at once.
+	logsChunkSize = 1000
+)
+
+// logsLimitErrors are parts of messages of errors returned by providers
+// limiting the number of logs or blocks of a single eth_getLogs query.
+var logsLimitErrors = []string{
+	"query returned more than",
+	"block range",
+	"limit exceeded",
+	"response size exceeded",
+	"too many",
+}
+
+// GetLogs returns event logs matching the filter. The block range is queried with
+// eth_getLogs in chunks, and results are concatenated. If a chunk is rejected for
+// exceeding limits of the provider, it is split into smaller ones.
+func (m *NodeManager) GetLogs(filter common.LogFilter) ([]types.Log, error) {
+	if _, err := m.NodeConfig(); err != nil {
+		return nil, err
+	}
+
+	client := m.RPCClient()
+	if client == nil {
+		return nil, ErrRPCClient
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), getLogsTimeout)
+	defer cancel()
+
+	return getLogs(ctx, client, filter, logsChunkSize)
+}
+
+// getLogs queries logs matching the filter with a given caller, chunk blocks at once.
+func getLogs(ctx context.Context, caller rpcCaller, filter common.LogFilter, chunk uint64) ([]types.Log, error) {
+	to := filter.ToBlock
+	if to == 0 {
+		var head hexutil.Uint64
+		if err := caller.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+			return nil, err
+		}
+		to = uint64(head)
+	}
+
+	logs := []types.Log{}
+	for from := filter.FromBlock; from <= to; {
+		end := to
+		if to-from >= chunk {
+			end = from + chunk - 1
+		}
+
+		var result []types.Log
+		err := caller.CallContext(ctx, &result, "eth_getLogs", logsQuery(filter, from, end))
+		if err != nil && isLogsLimitError(err) && chunk > 1 {
+			chunk /= 2
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, result...)
+		from = end + 1
+	}
+
+	return logs, nil
+}
+
+// logsQuery returns eth_getLogs params of the filter for a given block range.
+func logsQuery(filter common.LogFilter, from, to uint64) interface{} {
+	query := map[string]interface{}{
+		"fromBlock": hexutil.Uint64(from),
+		"toBlock":   hexutil.Uint64(to),
+	}
+	if len(filter.Addresses) > 0 {
+		query["address"] = filter.Addresses
+	}
+	if len(filter.Topics) > 0 {
+		query["topics"] = filter.Topics
+	}
+
+	return query
+}
+
+// isLogsLimitError checks if a JSON-RPC error means that a query exceeded limits of the provider.
+func isLogsLimitError(err error) bool {
+	if _, ok := err.(gethrpc.Error); !ok {
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, part := range logsLimitErrors {
+		if strings.Contains(msg, part) {
+			return true
+		}
+	}
+
+	return false
+}
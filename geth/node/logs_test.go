@@ -0,0 +1,96 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/stretchr/testify/require"
+)
+
+// limitError is a JSON-RPC error returned by providers for too large queries.
+type limitError string
+
+func (e limitError) ErrorCode() int { return -32005 }
+
+func (e limitError) Error() string { return string(e) }
+
+// logsCaller serves eth_getLogs with a log per block, rejecting queries
+// of ranges larger than maxRange.
+type logsCaller struct {
+	head     uint64
+	maxRange uint64
+	queries  [][2]uint64 // block ranges of all queries
+}
+
+func (c *logsCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	switch method {
+	case "eth_blockNumber":
+		*result.(*hexutil.Uint64) = hexutil.Uint64(c.head)
+		return nil
+	case "eth_getLogs":
+	default:
+		return fmt.Errorf("unexpected method %s", method)
+	}
+
+	query := args[0].(map[string]interface{})
+	from := uint64(query["fromBlock"].(hexutil.Uint64))
+	to := uint64(query["toBlock"].(hexutil.Uint64))
+	c.queries = append(c.queries, [2]uint64{from, to})
+
+	if to-from+1 > c.maxRange {
+		return limitError(fmt.Sprintf("query returned more than %d results", c.maxRange))
+	}
+
+	logs := []types.Log{}
+	for n := from; n <= to; n++ {
+		logs = append(logs, types.Log{BlockNumber: n, Topics: []gethcommon.Hash{}})
+	}
+	data, err := json.Marshal(logs)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+func TestGetLogsSplitsRange(t *testing.T) {
+	caller := &logsCaller{maxRange: 3}
+
+	logs, err := getLogs(context.Background(), caller, common.LogFilter{FromBlock: 1, ToBlock: 10}, 8)
+	require.NoError(t, err)
+
+	require.Len(t, logs, 10)
+	for i, log := range logs {
+		require.EqualValues(t, i+1, log.BlockNumber)
+	}
+	// the range is split until chunks are accepted, and the smaller chunk is kept
+	require.Equal(t, [][2]uint64{{1, 8}, {1, 4}, {1, 2}, {3, 4}, {5, 6}, {7, 8}, {9, 10}}, caller.queries)
+}
+
+func TestGetLogsToLatestBlock(t *testing.T) {
+	caller := &logsCaller{head: 5, maxRange: 10}
+
+	logs, err := getLogs(context.Background(), caller, common.LogFilter{FromBlock: 3}, 2)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+	require.Equal(t, [][2]uint64{{3, 4}, {5, 5}}, caller.queries)
+}
+
+func TestGetLogsErrors(t *testing.T) {
+	// a single block exceeding the limit can't be split anymore
+	caller := &logsCaller{maxRange: 0}
+	_, err := getLogs(context.Background(), caller, common.LogFilter{FromBlock: 1, ToBlock: 2}, 2)
+	require.Equal(t, limitError("query returned more than 0 results"), err)
+	require.Equal(t, [][2]uint64{{1, 2}, {1, 1}}, caller.queries)
+
+	require.False(t, isLogsLimitError(errors.New("query returned more than 1000 results")), "only JSON-RPC errors are limit errors")
+	require.False(t, isLogsLimitError(limitError("unknown block")))
+	require.True(t, isLogsLimitError(limitError("Log response size exceeded")))
+}
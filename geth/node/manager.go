@@ -1,11 +1,14 @@
 package node
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
@@ -13,6 +16,8 @@ import (
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/delivery"
 	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/params"
 	"github.com/status-im/status-go/geth/rpc"
@@ -29,29 +34,56 @@ var (
 	ErrInvalidAccountManager       = errors.New("could not retrieve account manager")
 	ErrAccountKeyStoreMissing      = errors.New("account key store is not set")
 	ErrRPCClient                   = errors.New("failed to init RPC client")
+	ErrNodeRestarting              = errors.New("node restart is in progress")
+	ErrNodeStopTimeout             = errors.New("timed out waiting for RPC calls to complete")
+	ErrInvalidEnode                = errors.New("invalid enode URL")
+	ErrTransactionDropped          = errors.New("transaction is neither pending nor mined anymore")
+	ErrOutsideDataDir              = errors.New("refusing to remove a directory outside of the data directory")
+	ErrKeyStoreInChainData         = errors.New("refusing to remove chain data containing the keystore")
 )
 
+// restartDrainTimeout is how long RestartNode waits for RPC calls in flight
+// to complete before the running node is stopped.
+const restartDrainTimeout = 5 * time.Second
+
 // NodeManager manages Status node (which abstracts contained geth node)
 type NodeManager struct {
 	sync.RWMutex
-	config         *params.NodeConfig // Status node configuration
-	node           *node.Node         // reference to Geth P2P stack/node
-	nodeStarted    chan struct{}      // channel to wait for start up notifications
-	nodeStopped    chan struct{}      // channel to wait for termination notifications
-	whisperService *whisper.Whisper   // reference to Whisper service
-	lesService     *les.LightEthereum // reference to LES service
-	rpcClient      *rpc.Client        // reference to RPC client
-}
-
-// NewNodeManager makes new instance of node manager
-func NewNodeManager() *NodeManager {
-	m := &NodeManager{}
+	config         *params.NodeConfig             // Status node configuration
+	node           *node.Node                     // reference to Geth P2P stack/node
+	nodeStarted    chan struct{}                  // channel to wait for start up notifications
+	nodeStopped    chan struct{}                  // channel to wait for termination notifications
+	whisperService *whisper.Whisper               // reference to Whisper service
+	lesService     *les.LightEthereum             // reference to LES service
+	rpcClient      *rpc.Client                    // reference to RPC client
+	restarting     bool                           // set while RestartNode drains RPC calls and waits for node to stop
+	lifecycle      lifecycleListeners             // listeners of node lifecycle events
+	notifier       *delivery.DeliveryNotification // delivery states of posted whisper envelopes, shared across restarts
+
+	// set with options, see NewNodeManager
+	defaultDataDir     string
+	defaultKeyStoreDir string
+	log                Logger
+}
+
+// NewNodeManager makes new instance of node manager. Options set defaults
+// applied to configs of started nodes, see WithDefaultDataDir for example.
+func NewNodeManager(opts ...Option) *NodeManager {
+	m := &NodeManager{
+		notifier: &delivery.DeliveryNotification{},
+		log:      defaultLogger{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.notifier.Subscribe(sendEnvelopeStatusSignal)
+	m.notifier.SetKeyStore(whisperKeyStore{m})
 	go HaltOnInterruptSignal(m) // allow interrupting running nodes
 
 	return m
 }
 
-// StartNode start Status node, fails if node is already started
+// StartNode start Status node, fails if node is already started or config is invalid
 func (m *NodeManager) StartNode(config *params.NodeConfig) (<-chan struct{}, error) {
 	m.Lock()
 	defer m.Unlock()
@@ -59,48 +91,136 @@ func (m *NodeManager) StartNode(config *params.NodeConfig) (<-chan struct{}, err
 	return m.startNode(config)
 }
 
-// startNode start Status node, fails if node is already started
+// StartNodeAsync starts Status node and returns a channel of its lifecycle events,
+// fails if node is already started. StateStarting event is emitted first, then either
+// StateReady or StateError one. The channel is closed afterwards.
+func (m *NodeManager) StartNodeAsync(config *params.NodeConfig) (<-chan common.NodeEvent, error) {
+	if err := m.applyDefaults(config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.restarting {
+		return nil, ErrNodeRestarting
+	}
+	if m.node != nil || m.nodeStarted != nil {
+		return nil, ErrNodeExists
+	}
+
+	events := make(chan common.NodeEvent, 2)
+	events <- common.NodeEvent{State: common.StateStarting}
+	m.lifecycle.emit(common.StateStarting, nil)
+
+	ethNode, err := m.makeNode(config)
+	if err != nil {
+		events <- common.NodeEvent{State: common.StateError, Error: err}
+		close(events)
+		m.lifecycle.emit(common.StateError, err)
+		return events, nil
+	}
+
+	m.runNode(ethNode, config, events)
+
+	return events, nil
+}
+
+// startNode start Status node, fails if node is already started or config is invalid
 func (m *NodeManager) startNode(config *params.NodeConfig) (<-chan struct{}, error) {
+	if err := m.applyDefaults(config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if m.restarting {
+		return nil, ErrNodeRestarting
+	}
 	if m.node != nil || m.nodeStarted != nil {
 		return nil, ErrNodeExists
 	}
 
-	m.initLog(config)
+	m.lifecycle.emit(common.StateStarting, nil)
 
-	ethNode, err := MakeNode(config)
+	ethNode, err := m.makeNode(config)
 	if err != nil {
+		m.lifecycle.emit(common.StateError, err)
 		return nil, err
 	}
 
-	m.nodeStarted = make(chan struct{}, 1)
+	m.runNode(ethNode, config, nil)
+
+	return m.nodeStarted, nil
+}
+
+// applyDefaults sets directories omitted by a config to the manager's defaults.
+func (m *NodeManager) applyDefaults(config *params.NodeConfig) error {
+	return config.SetDefaultDirs(m.defaultDataDir, m.defaultKeyStoreDir)
+}
+
+// makeNode creates underlying node for given configuration.
+func (m *NodeManager) makeNode(config *params.NodeConfig) (*node.Node, error) {
+	m.initLog(config)
+
+	return MakeNode(config)
+}
+
+// runNode starts underlying node in a separate routine. Lifecycle events
+// are sent to events channel (if not nil), which is closed once node is
+// either ready or failed to start.
+//
+// The node is reserved synchronously, so that concurrent start attempts fail
+// with ErrNodeExists. The routine works with its own references to channels,
+// as fields of the manager may be replaced while it runs.
+func (m *NodeManager) runNode(ethNode *node.Node, config *params.NodeConfig, events chan<- common.NodeEvent) {
+	nodeStarted := make(chan struct{}, 1)
+	m.nodeStarted = nodeStarted
+
+	notify := func(state common.NodeState, err error) {
+		m.lifecycle.emit(state, err)
+		if events == nil {
+			return
+		}
+		events <- common.NodeEvent{State: state, Error: err}
+		close(events)
+	}
 
 	go func() {
 		defer HaltOnPanic()
 
 		// start underlying node
 		if err := ethNode.Start(); err != nil {
-			close(m.nodeStarted)
+			close(nodeStarted)
 			m.Lock()
 			m.nodeStarted = nil
 			m.Unlock()
+			startErr := fmt.Errorf("%v: %v", ErrNodeStartFailure, err)
 			signal.Send(signal.Envelope{
 				Type: signal.EventNodeCrashed,
 				Event: signal.NodeCrashEvent{
-					Error: fmt.Errorf("%v: %v", ErrNodeStartFailure, err).Error(),
+					Error: startErr.Error(),
 				},
 			})
+			notify(common.StateError, startErr)
 			return
 		}
 
+		nodeStopped := make(chan struct{}, 1)
+
 		m.Lock()
 		m.node = ethNode
-		m.nodeStopped = make(chan struct{}, 1)
+		m.nodeStopped = nodeStopped
 		m.config = config
 
 		// init RPC client for this node
+		var err error
 		m.rpcClient, err = rpc.NewClient(m.node, m.config.UpstreamConfig)
 		if err != nil {
-			log.Error("Init RPC client failed:", "error", err)
+			m.log.Error("Init RPC client failed:", "error", err)
 			m.Unlock()
 			signal.Send(signal.Envelope{
 				Type: signal.EventNodeCrashed,
@@ -108,33 +228,37 @@ func (m *NodeManager) startNode(config *params.NodeConfig) (<-chan struct{}, err
 					Error: ErrRPCClient.Error(),
 				},
 			})
+			notify(common.StateError, ErrRPCClient)
 			return
 		}
+		m.rpcClient.SetAllowedMethods(m.config.AllowedRPCMethods)
+		if m.config.LightMode {
+			registerLightModeHandlers(m.rpcClient)
+		}
 		m.Unlock()
 
 		// underlying node is started, every method can use it, we use it immediately
 		go func() {
 			if err := m.PopulateStaticPeers(); err != nil {
-				log.Error("Static peers population", "error", err)
+				m.log.Error("Static peers population", "error", err)
 			}
 		}()
 
 		// notify all subscribers that Status node is started
-		close(m.nodeStarted)
+		close(nodeStarted)
 		signal.Send(signal.Envelope{
 			Type:  signal.EventNodeStarted,
 			Event: struct{}{},
 		})
+		notify(common.StateReady, nil)
 
 		// wait up until underlying node is stopped
-		m.node.Wait()
+		ethNode.Wait()
 
 		// notify m.Stop() that node has been stopped
-		close(m.nodeStopped)
-		log.Info("Node is stopped")
+		close(nodeStopped)
+		m.log.Info("Node is stopped")
 	}()
-
-	return m.nodeStarted, nil
 }
 
 // StopNode stop Status node. Stopped node cannot be resumed.
@@ -142,6 +266,9 @@ func (m *NodeManager) StopNode() (<-chan struct{}, error) {
 	m.Lock()
 	defer m.Unlock()
 
+	if m.restarting {
+		return nil, ErrNodeRestarting
+	}
 	if err := m.isNodeAvailable(); err != nil {
 		return nil, err
 	}
@@ -154,17 +281,52 @@ func (m *NodeManager) StopNode() (<-chan struct{}, error) {
 	return m.stopNode()
 }
 
+// StopNodeGracefully stops accepting new RPC calls, waits up to timeout for calls
+// in flight to complete, and stops Status node. If timeout elapses, node is stopped
+// anyway and ErrNodeStopTimeout is returned along with the number of pending calls.
+func (m *NodeManager) StopNodeGracefully(timeout time.Duration) error {
+	m.RLock()
+	err := m.isNodeAvailable()
+	client := m.rpcClient
+	m.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	// drain without holding the lock, as calls in flight may need it
+	var pending int
+	if client != nil {
+		pending = client.Drain(timeout)
+	}
+
+	nodeStopped, err := m.StopNode()
+	if err != nil {
+		return err
+	}
+	<-nodeStopped
+
+	if pending > 0 {
+		return fmt.Errorf("%v: %d RPC calls still pending", ErrNodeStopTimeout, pending)
+	}
+
+	return nil
+}
+
 // stopNode stop Status node. Stopped node cannot be resumed.
 func (m *NodeManager) stopNode() (<-chan struct{}, error) {
+	m.lifecycle.emit(common.StateStopping, nil)
+
 	// now attempt to stop
 	if err := m.node.Stop(); err != nil {
+		m.lifecycle.emit(common.StateError, err)
 		return nil, err
 	}
 
 	nodeStopped := make(chan struct{}, 1)
 	go func() {
 		<-m.nodeStopped // Status node is stopped (code after Wait() is executed)
-		log.Info("Ready to reset node")
+		m.log.Info("Ready to reset node")
 
 		// reset node params
 		m.Lock()
@@ -177,19 +339,27 @@ func (m *NodeManager) stopNode() (<-chan struct{}, error) {
 		m.Unlock()
 
 		close(nodeStopped) // Status node is stopped, and we can create another
-		log.Info("Node manager resets node params")
+		m.log.Info("Node manager resets node params")
+		m.lifecycle.emit(common.StateStopped, nil)
 
 		// notify application that it can send more requests now
 		signal.Send(signal.Envelope{
 			Type:  signal.EventNodeStopped,
 			Event: struct{}{},
 		})
-		log.Info("Node manager notifed app, that node has stopped")
+		m.log.Info("Node manager notifed app, that node has stopped")
 	}()
 
 	return nodeStopped, nil
 }
 
+// SubscribeLifecycle registers fn to be called on every node lifecycle transition
+// and returns function unsubscribing it. Listeners are called in order of events
+// from a separate routine, outside of any NodeManager lock.
+func (m *NodeManager) SubscribeLifecycle(fn func(common.NodeEvent)) (unsubscribe func()) {
+	return m.lifecycle.subscribe(fn)
+}
+
 // IsNodeRunning confirm that node is running
 func (m *NodeManager) IsNodeRunning() bool {
 	m.RLock()
@@ -201,7 +371,21 @@ func (m *NodeManager) IsNodeRunning() bool {
 
 	<-m.nodeStarted
 
-	return true
+	// underlying server is released once node is stopped
+	return m.node.Server() != nil
+}
+
+// NodeReady returns channel closed once the started node is ready
+// (or failed to start), fails if node is not started.
+func (m *NodeManager) NodeReady() (<-chan struct{}, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if m.nodeStarted == nil {
+		return nil, ErrNoRunningNode
+	}
+
+	return m.nodeStarted, nil
 }
 
 // Node returns underlying Status node
@@ -235,17 +419,17 @@ func (m *NodeManager) PopulateStaticPeers() error {
 // populateStaticPeers connects current node with our publicly available LES/SHH/Swarm cluster
 func (m *NodeManager) populateStaticPeers() error {
 	if !m.config.BootClusterConfig.Enabled {
-		log.Info("Boot cluster is disabled")
+		m.log.Info("Boot cluster is disabled")
 		return nil
 	}
 
 	for _, enode := range m.config.BootClusterConfig.BootNodes {
 		err := m.addPeer(enode)
 		if err != nil {
-			log.Warn("Boot node addition failed", "error", err)
+			m.log.Warn("Boot node addition failed", "error", err)
 			continue
 		}
-		log.Info("Boot node added", "enode", enode)
+		m.log.Info("Boot node added", "enode", enode)
 	}
 
 	return nil
@@ -273,7 +457,7 @@ func (m *NodeManager) addPeer(url string) error {
 	}
 
 	// Try to add the url as a static peer and return
-	parsedNode, err := discover.ParseNode(url)
+	parsedNode, err := parseEnode(url)
 	if err != nil {
 		return err
 	}
@@ -282,12 +466,102 @@ func (m *NodeManager) addPeer(url string) error {
 	return nil
 }
 
+// RemovePeer disconnects static peer node
+func (m *NodeManager) RemovePeer(url string) error {
+	m.RLock()
+	defer m.RUnlock()
+
+	if err := m.isNodeAvailable(); err != nil {
+		return err
+	}
+
+	<-m.nodeStarted
+
+	return m.removePeer(url)
+}
+
+// removePeer disconnects static peer node
+func (m *NodeManager) removePeer(url string) error {
+	server := m.node.Server()
+	if server == nil {
+		return ErrNoRunningNode
+	}
+
+	parsedNode, err := parseEnode(url)
+	if err != nil {
+		return err
+	}
+	server.RemovePeer(parsedNode)
+
+	return nil
+}
+
+// parseEnode parses enode URL of a peer
+func parseEnode(url string) (*discover.Node, error) {
+	parsedNode, err := discover.ParseNode(url)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrInvalidEnode, err)
+	}
+
+	return parsedNode, nil
+}
+
+// PeerCount returns number of peers connected to the running node
+func (m *NodeManager) PeerCount() (int, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if err := m.isNodeAvailable(); err != nil {
+		return 0, err
+	}
+
+	<-m.nodeStarted
+
+	server := m.node.Server()
+	if server == nil {
+		return 0, ErrNoRunningNode
+	}
+
+	return server.PeerCount(), nil
+}
+
+// Peers returns information about peers connected to the running node
+func (m *NodeManager) Peers() ([]common.PeerInfo, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if err := m.isNodeAvailable(); err != nil {
+		return nil, err
+	}
+
+	<-m.nodeStarted
+
+	server := m.node.Server()
+	if server == nil {
+		return nil, ErrNoRunningNode
+	}
+
+	var peers []common.PeerInfo
+	for _, info := range server.PeersInfo() {
+		peers = append(peers, common.PeerInfo{
+			Enode:     fmt.Sprintf("enode://%s@%s", info.ID, info.Network.RemoteAddress),
+			Name:      info.Name,
+			Protocols: info.Caps,
+		})
+	}
+
+	return peers, nil
+}
+
 // ResetChainData remove chain data from data directory.
 // Node is stopped, and new node is started, with clean data directory.
 func (m *NodeManager) ResetChainData() (<-chan struct{}, error) {
 	m.Lock()
 	defer m.Unlock()
 
+	if m.restarting {
+		return nil, ErrNodeRestarting
+	}
 	if err := m.isNodeAvailable(); err != nil {
 		return nil, err
 	}
@@ -310,11 +584,7 @@ func (m *NodeManager) resetChainData() (<-chan struct{}, error) {
 	<-nodeStopped
 	m.Lock()
 
-	chainDataDir := filepath.Join(prevConfig.DataDir, prevConfig.Name, "lightchaindata")
-	if _, err := os.Stat(chainDataDir); os.IsNotExist(err) {
-		return nil, err
-	}
-	if err := os.RemoveAll(chainDataDir); err != nil {
+	if err := removeChainData(&prevConfig); err != nil {
 		return nil, err
 	}
 	// send signal up to native app
@@ -322,38 +592,125 @@ func (m *NodeManager) resetChainData() (<-chan struct{}, error) {
 		Type:  signal.EventChainDataRemoved,
 		Event: struct{}{},
 	})
-	log.Info("Chain data has been removed", "dir", chainDataDir)
 
 	return m.startNode(&prevConfig)
 }
 
-// RestartNode restart running Status node, fails if node is not running
-func (m *NodeManager) RestartNode() (<-chan struct{}, error) {
-	m.Lock()
-	defer m.Unlock()
+// removeChainData removes chain databases of both light and full node from
+// the instance directory of a given config. Nothing outside of the configured
+// data directory is ever removed, nor a database the keystore is kept in.
+// Missing databases are ignored.
+func removeChainData(config *params.NodeConfig) error {
+	dataDir, err := filepath.Abs(config.DataDir)
+	if err != nil {
+		return err
+	}
+	keyStoreDir, err := filepath.Abs(config.KeyStoreDir)
+	if err != nil {
+		return err
+	}
 
-	if err := m.isNodeAvailable(); err != nil {
-		return nil, err
+	chainDataDirs := []string{
+		filepath.Join(dataDir, config.Name, "lightchaindata"),
+		filepath.Join(dataDir, config.Name, "chaindata"),
 	}
 
-	<-m.nodeStarted
+	// check all directories first, so that nothing is removed if any of them is refused
+	for _, chainDataDir := range chainDataDirs {
+		if !isSubDir(dataDir, chainDataDir) {
+			return fmt.Errorf("%v: %s", ErrOutsideDataDir, chainDataDir)
+		}
+		if config.KeyStoreDir != "" && (keyStoreDir == chainDataDir || isSubDir(chainDataDir, keyStoreDir)) {
+			return fmt.Errorf("%v: %s", ErrKeyStoreInChainData, keyStoreDir)
+		}
+	}
+
+	for _, chainDataDir := range chainDataDirs {
+		if err := os.RemoveAll(chainDataDir); err != nil {
+			return err
+		}
+		log.Info("Chain data has been removed", "dir", chainDataDir)
+	}
 
-	return m.restartNode()
+	return nil
 }
 
-// restartNode restart running Status node, fails if node is not running
-func (m *NodeManager) restartNode() (<-chan struct{}, error) {
-	prevConfig := *m.config
-	nodeStopped, err := m.stopNode()
+// isSubDir checks if dir is inside of parent, both given as absolute paths.
+func isSubDir(parent, dir string) bool {
+	rel, err := filepath.Rel(parent, dir)
 	if err != nil {
-		return nil, err
+		return false
 	}
 
-	m.Unlock()
-	<-nodeStopped
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// RestartNode stops running Status node (if any) and starts a new one with given config.
+// If config is nil, configuration of the running node is reused, and it fails if node
+// is not running. It fails if another restart is in progress.
+func (m *NodeManager) RestartNode(config *params.NodeConfig) (<-chan struct{}, error) {
 	m.Lock()
+	defer m.Unlock()
 
-	return m.startNode(&prevConfig)
+	if m.restarting {
+		return nil, ErrNodeRestarting
+	}
+
+	if config == nil {
+		if err := m.isNodeAvailable(); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.restartNode(config)
+}
+
+// restartNode stops running Status node (if any) and starts a new one with given config,
+// or with configuration of the running node, if config is nil.
+func (m *NodeManager) restartNode(config *params.NodeConfig) (<-chan struct{}, error) {
+	// don't stop the running node if the new one can't be started anyway
+	if config != nil {
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.isNodeAvailable(); err == nil {
+		<-m.nodeStarted
+
+		if config == nil {
+			prevConfig := *m.config
+			config = &prevConfig
+		}
+
+		// nobody else can start or stop a node while we drain RPC calls
+		// and wait for this one to stop
+		m.restarting = true
+
+		// new raw calls fail with rpc.ErrClientDraining, calls in flight
+		// are given a chance to complete; they may need the lock to do so
+		client := m.rpcClient
+		m.Unlock()
+		if client != nil {
+			if pending := client.Drain(restartDrainTimeout); pending > 0 {
+				m.log.Warn("Restarting node with RPC calls in flight", "pending", pending)
+			}
+		}
+		m.Lock()
+
+		nodeStopped, err := m.stopNode()
+		if err != nil {
+			m.restarting = false
+			return nil, err
+		}
+
+		m.Unlock()
+		<-nodeStopped
+		m.Lock()
+		m.restarting = false
+	}
+
+	return m.startNode(config)
 }
 
 // NodeConfig exposes reference to running node's configuration
@@ -383,7 +740,7 @@ func (m *NodeManager) LightEthereumService() (*les.LightEthereum, error) {
 
 	if m.lesService == nil {
 		if err := m.node.Service(&m.lesService); err != nil {
-			log.Warn("Cannot obtain LES service", "error", err)
+			m.log.Warn("Cannot obtain LES service", "error", err)
 			return nil, ErrInvalidLightEthereumService
 		}
 	}
@@ -408,7 +765,7 @@ func (m *NodeManager) WhisperService() (*whisper.Whisper, error) {
 
 	if m.whisperService == nil {
 		if err := m.node.Service(&m.whisperService); err != nil {
-			log.Warn("Cannot obtain whisper service", "error", err)
+			m.log.Warn("Cannot obtain whisper service", "error", err)
 			return nil, ErrInvalidWhisperService
 		}
 	}
@@ -420,6 +777,35 @@ func (m *NodeManager) WhisperService() (*whisper.Whisper, error) {
 	return m.whisperService, nil
 }
 
+// DeliveryNotification returns the notification dispatching delivery states
+// of whisper envelopes. It's the same instance for the manager's lifetime.
+func (m *NodeManager) DeliveryNotification() *delivery.DeliveryNotification {
+	return m.notifier
+}
+
+// whisperKeyStore resolves whisper keys with the whisper service of the running node.
+type whisperKeyStore struct {
+	m *NodeManager
+}
+
+func (s whisperKeyStore) GetSymKey(id string) ([]byte, error) {
+	w, err := s.m.WhisperService()
+	if err != nil {
+		return nil, err
+	}
+
+	return w.GetSymKey(id)
+}
+
+func (s whisperKeyStore) GetPrivateKey(id string) (*ecdsa.PrivateKey, error) {
+	w, err := s.m.WhisperService()
+	if err != nil {
+		return nil, err
+	}
+
+	return w.GetPrivateKey(id)
+}
+
 // AccountManager exposes reference to node's accounts manager
 func (m *NodeManager) AccountManager() (*accounts.Manager, error) {
 	m.RLock()
@@ -482,7 +868,12 @@ func (m *NodeManager) initLog(config *params.NodeConfig) {
 	log.SetLevel(config.LogLevel)
 
 	if config.LogFile != "" {
-		err := log.SetLogFile(config.LogFile)
+		setLogFile := log.SetLogFile
+		if config.LogToStderr {
+			setLogFile = log.SetLogFileAndStderr
+		}
+
+		err := setLogFile(config.LogFile)
 		if err != nil {
 			fmt.Println("Failed to open log file, using stdout")
 		}
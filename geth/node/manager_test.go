@@ -0,0 +1,151 @@
+package node
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/delivery"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveChainData(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "status-chaindata")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir) // nolint: errcheck
+
+	config := &params.NodeConfig{DataDir: dataDir, Name: "StatusIM"}
+	marker := filepath.Join(dataDir, "StatusIM", "lightchaindata", "marker")
+	key := filepath.Join(dataDir, "keystore", "key")
+	for _, path := range []string{marker, key} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, ioutil.WriteFile(path, []byte("test"), 0600))
+	}
+
+	require.NoError(t, removeChainData(config))
+	_, err = os.Stat(marker)
+	require.True(t, os.IsNotExist(err), "chain data should be removed")
+	_, err = os.Stat(key)
+	require.NoError(t, err, "keystore should survive")
+
+	// missing chain data is not an error
+	require.NoError(t, removeChainData(config))
+}
+
+func TestRemoveChainDataOutsideDataDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "status-chaindata")
+	require.NoError(t, err)
+	defer os.RemoveAll(root) // nolint: errcheck
+
+	outside := filepath.Join(root, "chaindata", "marker")
+	require.NoError(t, os.MkdirAll(filepath.Dir(outside), os.ModePerm))
+	require.NoError(t, ioutil.WriteFile(outside, []byte("test"), 0600))
+
+	config := &params.NodeConfig{DataDir: filepath.Join(root, "data"), Name: ".."}
+	err = removeChainData(config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), ErrOutsideDataDir.Error())
+
+	_, err = os.Stat(outside)
+	require.NoError(t, err, "nothing outside the data dir should be removed")
+}
+
+func TestRemoveChainDataKeepsKeyStore(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "status-chaindata")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir) // nolint: errcheck
+
+	keyStoreDir := filepath.Join(dataDir, "StatusIM", "chaindata", "keystore")
+	require.NoError(t, os.MkdirAll(keyStoreDir, os.ModePerm))
+	lightChainData := filepath.Join(dataDir, "StatusIM", "lightchaindata")
+	require.NoError(t, os.MkdirAll(lightChainData, os.ModePerm))
+
+	config := &params.NodeConfig{DataDir: dataDir, Name: "StatusIM", KeyStoreDir: keyStoreDir}
+	err = removeChainData(config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), ErrKeyStoreInChainData.Error())
+
+	_, err = os.Stat(keyStoreDir)
+	require.NoError(t, err, "keystore should survive")
+	_, err = os.Stat(lightChainData)
+	require.NoError(t, err, "nothing should be removed if any directory is refused")
+}
+
+func TestEnvelopeStatusSignal(t *testing.T) {
+	var received []string
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		received = append(received, jsonEvent)
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	env := sealEnvelope(t, &whisper.MessageParams{
+		TTL:      10,
+		KeySym:   []byte("0123456789abcdef0123456789abcdef"),
+		WorkTime: 1,
+		Payload:  []byte("hello"),
+	})
+
+	m := NewNodeManager()
+	m.DeliveryNotification().Send(env, delivery.StatusSent)
+
+	require.Len(t, received, 1)
+	require.JSONEq(t, fmt.Sprintf(`{"type": "envelope.status", "event": {"hash": "%s", "status": "sent"}}`, env.Hash().Hex()), received[0])
+}
+
+func TestEnvelopeStatusSignalWithoutEnvelope(t *testing.T) {
+	var received []string
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		received = append(received, jsonEvent)
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	require.NotPanics(t, func() {
+		sendEnvelopeStatusSignal(delivery.MessageDeliveryState{Status: delivery.StatusSent})
+	})
+	require.Empty(t, received)
+}
+
+func sealEnvelope(t *testing.T, params *whisper.MessageParams) *whisper.Envelope {
+	sent, err := whisper.NewSentMessage(params)
+	require.NoError(t, err)
+	env, err := sent.Wrap(params)
+	require.NoError(t, err)
+	return env
+}
+
+func TestDefaultDataDir(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "status-datadir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir) // nolint: errcheck
+
+	config, err := params.NewNodeConfig("", params.RopstenNetworkID, true)
+	require.NoError(t, err)
+	config.BootClusterConfig.Enabled = false
+	config.LightEthConfig.Enabled = false
+	config.WhisperConfig.Enabled = false
+	config.RPCEnabled = false
+
+	m := NewNodeManager(WithDefaultDataDir(dataDir))
+	started, err := m.StartNode(config)
+	require.NoError(t, err)
+	<-started
+	defer func() {
+		stopped, err := m.StopNode()
+		require.NoError(t, err)
+		<-stopped
+	}()
+
+	config, err = m.NodeConfig()
+	require.NoError(t, err)
+	require.Equal(t, dataDir, config.DataDir)
+	require.Equal(t, filepath.Join(dataDir, params.KeyStoreDir), config.KeyStoreDir)
+
+	node, err := m.Node()
+	require.NoError(t, err)
+	require.Equal(t, dataDir, node.DataDir())
+}
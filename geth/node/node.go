@@ -1,11 +1,11 @@
 package node
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 
@@ -27,6 +27,7 @@ import (
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
 	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
 )
 
 // node-related errors
@@ -37,6 +38,7 @@ var (
 	ErrNodeMakeFailure                   = errors.New("error creating p2p node")
 	ErrNodeRunFailure                    = errors.New("error running p2p node")
 	ErrNodeStartFailure                  = errors.New("error starting p2p node")
+	ErrUnsupportedInLightMode            = errors.New("unsupported in light mode")
 )
 
 // MakeNode create a geth node entity
@@ -54,6 +56,14 @@ func MakeNode(config *params.NodeConfig) (*node.Node, error) {
 	// configure required node (should you need to update node's config, e.g. add bootstrap nodes, see node.Config)
 	stackConfig := defaultEmbeddedNodeConfig(config)
 
+	if len(config.BootNodes) > 0 {
+		var err error
+		stackConfig.P2P.BootstrapNodes, stackConfig.P2P.BootstrapNodesV5, err = makeCustomBootstrapNodes(config.BootNodes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if len(config.NodeKeyFile) > 0 {
 		log.Info("Loading private key file", "file", config.NodeKeyFile)
 		pk, err := crypto.LoadECDSA(config.NodeKeyFile)
@@ -85,6 +95,33 @@ func MakeNode(config *params.NodeConfig) (*node.Node, error) {
 	return stack, nil
 }
 
+// lightModeUnsupportedMethods are methods a light client can't serve,
+// they fail with ErrUnsupportedInLightMode in light mode.
+var lightModeUnsupportedMethods = []string{
+	"eth_mining",
+	"eth_hashrate",
+	"eth_getWork",
+	"eth_submitWork",
+	"eth_submitHashrate",
+	"miner_start",
+	"miner_stop",
+	"miner_setEtherbase",
+	"miner_setExtra",
+	"miner_setGasPrice",
+}
+
+// registerLightModeHandlers makes methods unsupported in light mode fail
+// with ErrUnsupportedInLightMode, even if they are routed to the upstream.
+func registerLightModeHandlers(client *rpc.Client) {
+	unsupported := func(context.Context, ...interface{}) (interface{}, error) {
+		return nil, ErrUnsupportedInLightMode
+	}
+
+	for _, method := range lightModeUnsupportedMethods {
+		client.RegisterHandler(method, unsupported)
+	}
+}
+
 // defaultEmbeddedNodeConfig returns default stack configuration for mobile client node
 func defaultEmbeddedNodeConfig(config *params.NodeConfig) *node.Config {
 	nc := &node.Config{
@@ -96,7 +133,8 @@ func defaultEmbeddedNodeConfig(config *params.NodeConfig) *node.Config {
 		Version:           config.Version,
 		P2P: p2p.Config{
 			NoDiscovery:      true,
-			DiscoveryV5:      true,
+			DiscoveryV5:      !config.NoDiscovery && config.MaxPeers > 0,
+			NoDial:           config.MaxPeers == 0,
 			DiscoveryV5Addr:  ":0",
 			BootstrapNodes:   makeBootstrapNodes(),
 			BootstrapNodesV5: makeBootstrapNodesV5(),
@@ -106,11 +144,11 @@ func defaultEmbeddedNodeConfig(config *params.NodeConfig) *node.Config {
 			MaxPendingPeers:  config.MaxPendingPeers,
 		},
 		IPCPath:     makeIPCPath(config),
-		HTTPCors:    []string{"*"},
-		HTTPModules: strings.Split(config.APIModules, ","),
+		HTTPCors:    makeHTTPCors(config),
+		HTTPModules: makeHTTPModules(config),
 		WSHost:      makeWSHost(config),
 		WSPort:      config.WSPort,
-		WSOrigins:   []string{"*"},
+		WSOrigins:   makeWSOrigins(config),
 		WSModules:   strings.Split(config.APIModules, ","),
 	}
 
@@ -119,6 +157,10 @@ func defaultEmbeddedNodeConfig(config *params.NodeConfig) *node.Config {
 		nc.HTTPPort = config.HTTPPort
 	}
 
+	if config.LightMode && nc.P2P.MaxPeers > params.LightModeMaxPeers {
+		nc.P2P.MaxPeers = params.LightModeMaxPeers
+	}
+
 	return nc
 }
 
@@ -146,7 +188,7 @@ func updateCHT(eth *les.LightEthereum, config *params.NodeConfig) {
 
 // activateEthService configures and registers the eth.Ethereum service with a given node.
 func activateEthService(stack *node.Node, config *params.NodeConfig) error {
-	if !config.LightEthConfig.Enabled {
+	if !config.LightEthConfig.Enabled && !config.LightMode {
 		log.Info("LES protocol is disabled")
 		return nil
 	}
@@ -187,18 +229,25 @@ func activateShhService(stack *node.Node, config *params.NodeConfig) error {
 
 	serviceConstructor := func(*node.ServiceContext) (node.Service, error) {
 		whisperConfig := config.WhisperConfig
-		whisperService := whisper.New(nil)
-
-		// enable mail service
-		if whisperConfig.MailServerNode {
-			password, err := whisperConfig.ReadPasswordFile()
+		maxMessageSize := whisperConfig.MaxMessageSize
+		if maxMessageSize == 0 {
+			maxMessageSize = whisper.DefaultMaxMessageSize
+		}
+		whisperService := whisper.New(&whisper.Config{
+			MaxMessageSize:     maxMessageSize,
+			MinimumAcceptedPOW: whisperConfig.MinimumPoW,
+		})
+
+		// enable mail service, envelopes are archived and served to peers on request
+		if mailServerConfig := whisperConfig.MailServerConfig; mailServerConfig != nil && mailServerConfig.Enabled {
+			password, err := whisperConfig.MailServerPassword()
 			if err != nil {
 				return nil, err
 			}
 
 			var mailServer mailserver.WMailServer
 			whisperService.RegisterServer(&mailServer)
-			mailServer.Init(whisperService, whisperConfig.DataDir, string(password), whisperConfig.MinimumPoW)
+			mailServer.Init(whisperService, mailServerConfig.DataDir, password, whisperConfig.MinimumPoW)
 		}
 
 		// enable notification service
@@ -221,7 +270,34 @@ func makeIPCPath(config *params.NodeConfig) string {
 		return ""
 	}
 
-	return path.Join(config.DataDir, config.IPCFile)
+	return config.IPCPath()
+}
+
+// makeHTTPCors returns origins allowed to make cross-origin requests to HTTP-RPC Server
+func makeHTTPCors(config *params.NodeConfig) []string {
+	if len(config.HTTPCors) == 0 {
+		return []string{"*"}
+	}
+
+	return config.HTTPCors
+}
+
+// makeHTTPModules returns API modules exposed via HTTP-RPC Server
+func makeHTTPModules(config *params.NodeConfig) []string {
+	if len(config.HTTPModules) == 0 {
+		return strings.Split(config.APIModules, ",")
+	}
+
+	return config.HTTPModules
+}
+
+// makeWSOrigins returns origins WS-RPC Server accepts connections from
+func makeWSOrigins(config *params.NodeConfig) []string {
+	if len(config.WSOrigins) == 0 {
+		return []string{"*"}
+	}
+
+	return config.WSOrigins
 }
 
 // makeWSHost returns WS-RPC Server host, given enabled/disabled flag
@@ -247,6 +323,29 @@ func makeBootstrapNodes() []*discover.Node {
 	return bootstrapNodes
 }
 
+// makeCustomBootstrapNodes returns both discovery protocols bootstrap nodes for given enode URLs
+func makeCustomBootstrapNodes(enodes []string) ([]*discover.Node, []*discv5.Node, error) {
+	var (
+		bootstrapNodes   []*discover.Node
+		bootstrapNodesV5 []*discv5.Node
+	)
+	for _, enode := range enodes {
+		n, err := discover.ParseNode(enode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v %s: %v", params.ErrInvalidBootNode, enode, err)
+		}
+		nV5, err := discv5.ParseNode(enode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v %s: %v", params.ErrInvalidBootNode, enode, err)
+		}
+
+		bootstrapNodes = append(bootstrapNodes, n)
+		bootstrapNodesV5 = append(bootstrapNodesV5, nV5)
+	}
+
+	return bootstrapNodes, bootstrapNodesV5, nil
+}
+
 // makeBootstrapNodesV5 returns default (hence bootstrap) list of peers
 func makeBootstrapNodesV5() []*discv5.Node {
 	enodes := gethparams.DiscoveryV5Bootnodes
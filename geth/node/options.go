@@ -0,0 +1,44 @@
+package node
+
+import "github.com/status-im/status-go/geth/log"
+
+// Option configures a node manager, see NewNodeManager.
+type Option func(*NodeManager)
+
+// WithDefaultDataDir sets the data directory of started nodes whose
+// configs omit DataDir. Directories derived from it are updated as well.
+func WithDefaultDataDir(dir string) Option {
+	return func(m *NodeManager) {
+		m.defaultDataDir = dir
+	}
+}
+
+// WithDefaultKeyStoreDir sets the keystore directory of started nodes whose
+// configs omit KeyStoreDir, instead of the "keystore" subdirectory of DataDir.
+func WithDefaultKeyStoreDir(dir string) Option {
+	return func(m *NodeManager) {
+		m.defaultKeyStoreDir = dir
+	}
+}
+
+// WithLogger sets a logger of the manager's own messages. Any go-ethereum
+// logger can be used. By default messages are logged with the status-go logger.
+func WithLogger(logger Logger) Option {
+	return func(m *NodeManager) {
+		m.log = logger
+	}
+}
+
+// Logger logs messages of the node manager.
+type Logger interface {
+	Info(msg string, ctx ...interface{})
+	Warn(msg string, ctx ...interface{})
+	Error(msg string, ctx ...interface{})
+}
+
+// defaultLogger logs messages with the status-go logger.
+type defaultLogger struct{}
+
+func (defaultLogger) Info(msg string, ctx ...interface{})  { log.Info(msg, ctx...) }
+func (defaultLogger) Warn(msg string, ctx ...interface{})  { log.Warn(msg, ctx...) }
+func (defaultLogger) Error(msg string, ctx ...interface{}) { log.Error(msg, ctx...) }
@@ -0,0 +1,70 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// defaultReceiptPollInterval is used by WaitForReceipt if no poll interval is given.
+const defaultReceiptPollInterval = time.Second
+
+// WaitForReceipt polls eth_getTransactionReceipt every pollInterval until the
+// transaction is mined, and returns its receipt. It fails with the context's
+// error if the context is done first.
+//
+// While there is no receipt, the transaction is checked with eth_getTransactionByHash.
+// If it was pending before and is unknown now, ErrTransactionDropped is returned.
+// A transaction which has never been seen is awaited, as it may be propagating yet.
+func (m *NodeManager) WaitForReceipt(ctx context.Context, txHash gethcommon.Hash, pollInterval time.Duration) (*types.Receipt, error) {
+	if _, err := m.NodeConfig(); err != nil {
+		return nil, err
+	}
+
+	client := m.RPCClient()
+	if client == nil {
+		return nil, ErrRPCClient
+	}
+
+	return waitForReceipt(ctx, client, txHash, pollInterval)
+}
+
+// waitForReceipt polls for the receipt of a transaction with a given caller.
+func waitForReceipt(ctx context.Context, caller rpcCaller, txHash gethcommon.Hash, pollInterval time.Duration) (*types.Receipt, error) {
+	if pollInterval <= 0 {
+		pollInterval = defaultReceiptPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	seen := false
+	for {
+		var receipt *types.Receipt
+		if err := caller.CallContext(ctx, &receipt, "eth_getTransactionReceipt", txHash); err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			return receipt, nil
+		}
+
+		var tx json.RawMessage
+		if err := caller.CallContext(ctx, &tx, "eth_getTransactionByHash", txHash); err != nil {
+			return nil, err
+		}
+		known := len(tx) > 0 && string(tx) != "null"
+		if seen && !known {
+			return nil, ErrTransactionDropped
+		}
+		seen = seen || known
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// sequenceCaller responds to calls of each method with its JSON encoded results in order,
+// repeating the last one once they are exhausted.
+type sequenceCaller map[string][]string
+
+func (c sequenceCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	results, ok := c[method]
+	if !ok || len(results) == 0 {
+		return fmt.Errorf("unexpected method %s", method)
+	}
+	if len(results) > 1 {
+		c[method] = results[1:]
+	}
+	return json.Unmarshal([]byte(results[0]), result)
+}
+
+func testReceipt(t *testing.T, txHash gethcommon.Hash) string {
+	receipt := &types.Receipt{
+		CumulativeGasUsed: big.NewInt(21000),
+		GasUsed:           big.NewInt(21000),
+		Logs:              []*types.Log{},
+		TxHash:            txHash,
+	}
+	data, err := json.Marshal(receipt)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestWaitForReceipt(t *testing.T) {
+	txHash := gethcommon.HexToHash("0x01")
+	caller := sequenceCaller{
+		"eth_getTransactionReceipt": {`null`, `null`, testReceipt(t, txHash)},
+		"eth_getTransactionByHash":  {`{"hash":"0x01"}`},
+	}
+
+	receipt, err := waitForReceipt(context.Background(), caller, txHash, time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, txHash, receipt.TxHash)
+	require.Equal(t, big.NewInt(21000), receipt.GasUsed)
+}
+
+func TestWaitForReceiptDropped(t *testing.T) {
+	caller := sequenceCaller{
+		"eth_getTransactionReceipt": {`null`},
+		"eth_getTransactionByHash":  {`null`, `{"hash":"0x01"}`, `null`},
+	}
+
+	_, err := waitForReceipt(context.Background(), caller, gethcommon.HexToHash("0x01"), time.Millisecond)
+	require.Equal(t, ErrTransactionDropped, err)
+}
+
+func TestWaitForReceiptContextDone(t *testing.T) {
+	caller := sequenceCaller{
+		"eth_getTransactionReceipt": {`null`},
+		"eth_getTransactionByHash":  {`{"hash":"0x01"}`},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := waitForReceipt(ctx, caller, gethcommon.HexToHash("0x01"), 10*time.Millisecond)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,67 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/common"
+)
+
+// syncProgressTimeout limits the time of querying the sync progress.
+const syncProgressTimeout = 10 * time.Second
+
+// SyncProgress returns progress of the chain synchronisation, as reported by eth_syncing.
+// Once the node is synced, both block numbers are the number of the latest block.
+func (m *NodeManager) SyncProgress() (*common.SyncStatus, error) {
+	if _, err := m.NodeConfig(); err != nil {
+		return nil, err
+	}
+
+	client := m.RPCClient()
+	if client == nil {
+		return nil, ErrRPCClient
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncProgressTimeout)
+	defer cancel()
+
+	return syncProgress(ctx, client)
+}
+
+// syncProgress queries the sync progress with a given caller.
+func syncProgress(ctx context.Context, caller rpcCaller) (*common.SyncStatus, error) {
+	// eth_syncing returns either false or the progress object
+	var raw json.RawMessage
+	if err := caller.CallContext(ctx, &raw, "eth_syncing"); err != nil {
+		return nil, err
+	}
+
+	var syncing bool
+	if err := json.Unmarshal(raw, &syncing); err == nil && !syncing {
+		var head hexutil.Uint64
+		if err := caller.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+			return nil, err
+		}
+
+		return &common.SyncStatus{
+			CurrentBlock: uint64(head),
+			HighestBlock: uint64(head),
+			Synced:       true,
+		}, nil
+	}
+
+	var progress struct {
+		CurrentBlock hexutil.Uint64 `json:"currentBlock"`
+		HighestBlock hexutil.Uint64 `json:"highestBlock"`
+	}
+	if err := json.Unmarshal(raw, &progress); err != nil {
+		return nil, err
+	}
+
+	return &common.SyncStatus{
+		CurrentBlock: uint64(progress.CurrentBlock),
+		HighestBlock: uint64(progress.HighestBlock),
+	}, nil
+}
@@ -0,0 +1,40 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/status-im/status-go/geth/common"
+	"github.com/stretchr/testify/require"
+)
+
+// resultsCaller responds to calls with JSON encoded results mapped to methods.
+type resultsCaller map[string]string
+
+func (c resultsCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	data, ok := c[method]
+	if !ok {
+		return fmt.Errorf("unexpected method %s", method)
+	}
+	return json.Unmarshal([]byte(data), result)
+}
+
+func TestSyncProgress(t *testing.T) {
+	status, err := syncProgress(context.Background(), resultsCaller{
+		"eth_syncing": `{"startingBlock":"0x0","currentBlock":"0x64","highestBlock":"0xfa","pulledStates":"0x0","knownStates":"0x0"}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &common.SyncStatus{CurrentBlock: 100, HighestBlock: 250}, status)
+
+	status, err = syncProgress(context.Background(), resultsCaller{
+		"eth_syncing":     `false`,
+		"eth_blockNumber": `"0x1a4"`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &common.SyncStatus{CurrentBlock: 420, HighestBlock: 420, Synced: true}, status)
+
+	_, err = syncProgress(context.Background(), resultsCaller{})
+	require.Error(t, err)
+}
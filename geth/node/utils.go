@@ -6,6 +6,7 @@ import (
 	osSignal "os/signal"
 
 	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/delivery"
 	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/signal"
 )
@@ -27,6 +28,23 @@ func HaltOnPanic() {
 	}
 }
 
+// sendEnvelopeStatusSignal reports a delivery state of a whisper envelope to native app.
+// States without an envelope can't be matched by the app, so they are skipped.
+func sendEnvelopeStatusSignal(state delivery.MessageDeliveryState) {
+	if state.Envelope == nil {
+		log.Warn("Envelope status without an envelope is not signalled", "status", state.Status)
+		return
+	}
+
+	signal.Send(signal.Envelope{
+		Type: signal.EventEnvelopeStatus,
+		Event: signal.EnvelopeStatusEvent{
+			Hash:   state.Envelope.Hash().Hex(),
+			Status: state.Status.String(),
+		},
+	})
+}
+
 // HaltOnInterruptSignal stops node and panics if you press Ctrl-C enough times
 func HaltOnInterruptSignal(nodeManager *NodeManager) {
 	sigc := make(chan os.Signal, 1)
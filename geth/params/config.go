@@ -9,10 +9,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/static"
 )
@@ -39,8 +42,38 @@ var (
 	ErrEmptyIdentityFile          = errors.New("identity file cannot be empty")
 	ErrEmptyAuthorizationKeyFile  = errors.New("authorization key file cannot be empty")
 	ErrAuthorizationKeyFileNotSet = errors.New("authorization key file is not set")
+	ErrMissingUpstreamURL         = errors.New("upstream enabled but URL is empty")
+	ErrMissingGenesis             = errors.New("missing genesis for a private network")
+	ErrInvalidBootNode            = errors.New("invalid boot node")
+	ErrInvalidGasPriceRange       = errors.New("gas price oracle MinPrice is greater than MaxPrice")
+	ErrMissingMailServerPassword  = errors.New("mail server enabled but neither password nor password file is set")
+	ErrIPCPathTooLong             = errors.New("IPC path exceeds the platform socket path length limit")
+	ErrWSPortCollision            = errors.New("WebSocket RPC port collides with HTTP RPC port")
 )
 
+// MissingSubConfigError is returned by NodeConfig.Validate if a required
+// sub-configuration (e.g. WhisperConfig) is nil.
+type MissingSubConfigError struct {
+	Name string
+}
+
+func (e MissingSubConfigError) Error() string {
+	return fmt.Sprintf("missing required sub-configuration: %s", e.Name)
+}
+
+// MailServerConfig holds configuration of the whisper mail server,
+// which archives envelopes and serves them to peers on request
+type MailServerConfig struct {
+	// Enabled flag specifies whether the node runs a mail server
+	Enabled bool
+
+	// DataDir is the file system folder envelopes are archived in
+	DataDir string
+
+	// Password the key of requests is derived from, WhisperConfig.PasswordFile is read if it's empty
+	Password string `json:",omitempty"`
+}
+
 // LightEthConfig holds LES-related configuration
 // Status nodes are always lightweight clients (due to mobile platform constraints)
 type LightEthConfig struct {
@@ -104,7 +137,8 @@ type WhisperConfig struct {
 	// ForwarderNode is mode when node only forwards messages, neither sends nor decrypts messages
 	ForwarderNode bool
 
-	// MailServerNode is mode when node is capable of delivering expired messages on demand
+	// MailServerNode is mode when node is capable of delivering expired messages on demand,
+	// it enables the mail server configured by MailServerConfig
 	MailServerNode bool
 
 	// NotificationServerNode is mode when node is capable of sending Push (and probably other kinds) Notifications
@@ -116,14 +150,21 @@ type WhisperConfig struct {
 	// Port Whisper node's listening port
 	Port int
 
-	// MinimumPoW minimum PoW for Whisper messages
+	// MinimumPoW minimum PoW for Whisper messages, both received and posted
 	MinimumPoW float64
 
+	// MaxMessageSize maximum size of accepted Whisper messages, in bytes,
+	// limited by the protocol to 10 MB
+	MaxMessageSize uint32 `validate:"lte=10485760"`
+
 	// TTL time to live for messages, in seconds
 	TTL int
 
 	// FirebaseConfig extra configuration for Firebase Cloud Messaging
 	FirebaseConfig *FirebaseConfig `json:"FirebaseConfig,"`
+
+	// MailServerConfig extra configuration for the mail server
+	MailServerConfig *MailServerConfig `json:"MailServerConfig,"`
 }
 
 // ReadPasswordFile reads and returns content of the password file
@@ -145,6 +186,21 @@ func (c *WhisperConfig) ReadPasswordFile() ([]byte, error) {
 	return password, nil
 }
 
+// MailServerPassword returns the mail server password,
+// read from the password file unless it's set explicitly
+func (c *WhisperConfig) MailServerPassword() (string, error) {
+	if c.MailServerConfig != nil && c.MailServerConfig.Password != "" {
+		return c.MailServerConfig.Password, nil
+	}
+
+	password, err := c.ReadPasswordFile()
+	if err != nil {
+		return "", err
+	}
+
+	return string(password), nil
+}
+
 // ReadIdentityFile reads and loads identity private key
 func (c *WhisperConfig) ReadIdentityFile() (*ecdsa.PrivateKey, error) {
 	if len(c.IdentityFile) == 0 {
@@ -213,8 +269,105 @@ type UpstreamRPCConfig struct {
 	Enabled bool
 
 	// URL sets the rpc upstream host address for communication with
-	// a non-local infura endpoint.
+	// a non-local infura endpoint. Both HTTP (http://, https://) and
+	// WebSocket (ws://, wss://) endpoints are supported.
+	// References to secrets like ${INFURA_TOKEN} are resolved, see SetSecretResolver.
 	URL string
+
+	// FallbackURLs are tried in order if the upstream at URL is unavailable.
+	// Secret references are resolved the same way as in URL.
+	FallbackURLs []string `json:",omitempty"`
+
+	// Headers are HTTP headers sent with every upstream request (e.g. API keys).
+	// Secret references like ${INFURA_TOKEN} in values are resolved, see SetSecretResolver.
+	Headers map[string]string `json:",omitempty"`
+
+	// MaxIdleConns limits the number of idle (keep-alive) upstream connections.
+	// Zero means the default of net/http is used.
+	MaxIdleConns int `json:",omitempty"`
+
+	// MaxIdleConnsPerHost limits the number of idle (keep-alive) connections per upstream host.
+	// Zero means the default of net/http is used.
+	MaxIdleConnsPerHost int `json:",omitempty"`
+
+	// IdleConnTimeout is a time an idle upstream connection is kept open for, in seconds.
+	// Zero means the default of net/http is used.
+	IdleConnTimeout int `json:",omitempty"`
+
+	// RequestTimeout limits the time of a single request to an HTTP upstream, in seconds,
+	// including reading the response. Zero means 30 seconds.
+	RequestTimeout int `json:",omitempty" validate:"gte=0"`
+
+	// RetryCount is a number of times upstream calls of read-only methods are retried,
+	// if they fail due to a transient error, e.g. a reset connection or 5xx status.
+	// Zero disables retries.
+	RetryCount int `json:",omitempty" validate:"gte=0"`
+
+	// RetryBackoff is a time to wait before the first retry, in milliseconds.
+	// It is doubled after every retry. Zero means 100 milliseconds.
+	RetryBackoff int `json:",omitempty" validate:"gte=0"`
+
+	// RouteLocal lists prefixes of methods (e.g. "shh_" or "eth_sign") served
+	// by the local node, even if they are routed to the upstream by default.
+	RouteLocal []string `json:",omitempty"`
+
+	// RouteUpstream lists prefixes of methods routed to the upstream.
+	// RouteLocal rules take precedence.
+	RouteUpstream []string `json:",omitempty"`
+
+	// BlockedMethods lists prefixes of methods (e.g. "personal_" or "eth_accounts")
+	// never routed to the upstream. Dapps calling them are rejected with a JSON-RPC
+	// error, even if they are in NodeConfig.AllowedRPCMethods.
+	BlockedMethods []string `json:",omitempty"`
+
+	// RateLimits limits upstream calls of a method to the given number of calls per second.
+	RateLimits map[string]int `json:",omitempty"`
+
+	// DefaultRateLimit limits upstream calls of every method without its own limit
+	// to the given number of calls per second. Zero means no limit.
+	DefaultRateLimit int `json:",omitempty"`
+
+	// FailOnRateLimit makes calls exceeding their rate limit fail with a JSON-RPC
+	// error, instead of waiting until the limit allows them.
+	FailOnRateLimit bool `json:",omitempty"`
+
+	// BreakerThreshold is a number of consecutive upstream calls failed due to
+	// the upstream being unavailable, after which upstream calls fail immediately
+	// for BreakerCooldown. Zero disables the circuit breaker.
+	BreakerThreshold int `json:",omitempty"`
+
+	// BreakerCooldown is a time upstream calls are suspended for by the circuit breaker,
+	// in seconds. A single trial call is let through afterwards. Zero means 30 seconds.
+	BreakerCooldown int `json:",omitempty"`
+
+	// CacheTTLs sets how long results of upstream calls of a method are cached for,
+	// in seconds. Zero disables caching of the method. net_version and web3_clientVersion
+	// are cached for 5 minutes by default. Methods changing the state are never cached.
+	CacheTTLs map[string]int `json:",omitempty"`
+}
+
+//=====================================================================================
+
+// GasPriceOracleConfig stores configuration of the gas price oracle, which suggests
+// gas prices of transactions sent without one based on recent blocks.
+type GasPriceOracleConfig struct {
+	// Enabled flag specifies whether feature is enabled. If disabled,
+	// the gas price suggested by the network (eth_gasPrice) is used.
+	Enabled bool
+
+	// Blocks is a number of recent blocks the lowest gas prices are sampled from.
+	// Zero means DefaultGasPriceOracleBlocks.
+	Blocks int `json:",omitempty" validate:"gte=0"`
+
+	// Percentile of the sampled gas prices to suggest, from 1 to 100.
+	// Zero means DefaultGasPriceOraclePercentile.
+	Percentile int `json:",omitempty" validate:"gte=0,lte=100"`
+
+	// MinPrice is the lowest gas price suggested, in wei. Zero means no limit.
+	MinPrice uint64 `json:",omitempty"`
+
+	// MaxPrice is the highest gas price suggested, in wei. Zero means no limit.
+	MaxPrice uint64 `json:",omitempty"`
 }
 
 //=====================================================================================
@@ -234,6 +387,8 @@ type NodeConfig struct {
 
 	// KeyStoreDir is the file system folder that contains private keys.
 	// If KeyStoreDir is empty, the default location is the "keystore" subdirectory of DataDir.
+	// It may be outside of DataDir, e.g. in a protected location. Removing chain data
+	// never touches it.
 	KeyStoreDir string
 
 	// PrivateKeyFile is a filename with node ID (private key)
@@ -250,6 +405,10 @@ type NodeConfig struct {
 	// APIModules is a comma-separated list of API modules exposed via *any* (HTTP/WS/IPC) RPC interface.
 	APIModules string
 
+	// AllowedRPCMethods restricts RPC calls made by dapps (via CallRPC) to the given methods.
+	// Empty list allows all methods. It's applied every time the node is (re)started.
+	AllowedRPCMethods []string `json:",omitempty"`
+
 	// HTTPHost is the host interface on which to start the HTTP RPC server.
 	// Pass empty string if no HTTP RPC interface needs to be started.
 	HTTPHost string
@@ -260,16 +419,31 @@ type NodeConfig struct {
 	// HTTPPort is the TCP port number on which to start the Geth's HTTP RPC server.
 	HTTPPort int
 
+	// HTTPCors is a list of origins browsers are allowed to make cross-origin requests
+	// to the HTTP RPC server from, e.g. "http://localhost:3000". If empty, cross-origin
+	// requests from any origin are allowed.
+	HTTPCors []string `json:",omitempty"`
+
+	// HTTPModules is a list of API modules exposed via the HTTP RPC server.
+	// If empty, all modules listed in APIModules are exposed.
+	HTTPModules []string `json:",omitempty"`
+
 	// WSHost is a host interface for the WebSocket RPC server
 	WSHost string
 
 	// WSPort is the TCP port number on which to start the Geth's WebSocket RPC server.
+	// It must differ from HTTPPort if both servers are enabled.
 	WSPort int
 
+	// WSOrigins is a list of origins WebSocket connections are accepted from,
+	// e.g. "http://localhost:3000". If empty, connections from any origin are accepted.
+	WSOrigins []string `json:",omitempty"`
+
 	// WSEnabled specifies whether WS-RPC Server is enabled or not
 	WSEnabled bool
 
-	// IPCFile is filename of exposed IPC RPC Server
+	// IPCFile is a path of the IPC socket of exposed IPC RPC Server, see IPCPath.
+	// Relative paths are relative to DataDir. If empty, params.IPCFile is used.
 	IPCFile string
 
 	// IPCEnabled specifies whether IPC-RPC Server is enabled or not
@@ -280,22 +454,53 @@ type NodeConfig struct {
 	TLSEnabled bool
 
 	// MaxPeers is the maximum number of (global) peers that can be connected.
-	// Set to zero, if only static or trusted peers are allowed to connect.
+	// Zero means no peers are connected at all, static and boot cluster ones included.
 	MaxPeers int
 
+	// NoDiscovery disables peer discovery, so that only static and boot cluster
+	// peers are connected.
+	NoDiscovery bool `json:",omitempty"`
+
 	// MaxPendingPeers is the maximum number of peers that can be pending in the
 	// handshake phase, counted separately for inbound and outbound connections.
 	MaxPendingPeers int
 
+	// BootNodes is a list of enode URLs of discovery bootstrap nodes.
+	// If set, it replaces the default bootstrap nodes.
+	BootNodes []string `json:",omitempty"`
+
 	// LogFile is filename where exposed logs get written to
 	LogFile string
 
-	// LogLevel defines minimum log level. Valid names are "ERROR", "WARNING", "INFO", "DEBUG", and "TRACE".
-	LogLevel string `validate:"eq=ERROR|eq=WARNING|eq=INFO|eq=DEBUG|eq=TRACE"`
+	// LogLevel defines minimum log level. Valid names are "ERROR", "WARN" (or "WARNING"),
+	// "INFO", "DEBUG", and "TRACE", in either case.
+	LogLevel string `validate:"loglevel"`
 
-	// LogToStderr defines whether logged info should also be output to os.Stderr
+	// LogToStderr defines whether logged info should also be output to os.Stderr,
+	// when LogFile is set
 	LogToStderr bool
 
+	// GasPriceMultiplier is applied to a gas price suggested by the network when
+	// a transaction is sent without one, e.g. 1.2 to pay 20% more for faster inclusion.
+	// Zero means the suggested gas price is used as is.
+	GasPriceMultiplier float64 `json:",omitempty" validate:"gte=0"`
+
+	// GasPriceOracle configures how gas prices of transactions sent without one are suggested.
+	GasPriceOracle GasPriceOracleConfig `json:",omitempty"`
+
+	// TxCompletionTimeout is how many seconds a queued transaction waits to be completed
+	// or discarded before it's discarded with a timeout error, 300 if zero.
+	TxCompletionTimeout uint `json:",omitempty"`
+
+	// TxQueueCapacity is how many transactions can wait for completion at once,
+	// 35 if zero. Transactions sent while the queue is full fail immediately.
+	TxQueueCapacity int `json:",omitempty" validate:"gte=0"`
+
+	// LightMode runs the node as a read-only light client: LES is enabled even if
+	// LightEthConfig is disabled, at most LightModeMaxPeers peers are connected,
+	// and mining methods fail with an "unsupported in light mode" error.
+	LightMode bool `json:",omitempty"`
+
 	// UpstreamConfig extra config for providing upstream infura server.
 	UpstreamConfig UpstreamRPCConfig `json:"UpstreamConfig"`
 
@@ -341,13 +546,15 @@ func NewNodeConfig(dataDir string, networkID uint64, devMode bool) (*NodeConfig,
 			DatabaseCache: DatabaseCache,
 		},
 		WhisperConfig: &WhisperConfig{
-			Enabled:    true,
-			Port:       WhisperPort,
-			MinimumPoW: WhisperMinimumPoW,
-			TTL:        WhisperTTL,
+			Enabled:        true,
+			Port:           WhisperPort,
+			MinimumPoW:     WhisperMinimumPoW,
+			TTL:            WhisperTTL,
+			MaxMessageSize: WhisperMaxMessageSize,
 			FirebaseConfig: &FirebaseConfig{
 				NotificationTriggerURL: FirebaseNotificationTriggerURL,
 			},
+			MailServerConfig: &MailServerConfig{},
 		},
 		SwarmConfig: &SwarmConfig{},
 	}
@@ -362,11 +569,38 @@ func NewNodeConfig(dataDir string, networkID uint64, devMode bool) (*NodeConfig,
 
 // LoadNodeConfig parses incoming JSON and returned it as Config
 func LoadNodeConfig(configJSON string) (*NodeConfig, error) {
-	nodeConfig, err := loadNodeConfig(configJSON)
+	nodeConfig, err := loadNodeConfig(configJSON, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := nodeConfig.Validate(); err != nil {
+		return nil, err
+	}
+
+	return nodeConfig, nil
+}
+
+// NewNodeConfigFromJSON creates node configuration from JSON string, as passed
+// by mobile bindings. Only fields given in JSON override defaults of the network
+// given by NetworkID, and the result is validated.
+func NewNodeConfigFromJSON(configJSON string) (*NodeConfig, error) {
+	return LoadNodeConfig(configJSON)
+}
+
+// LoadNodeConfigFromFile reads JSON config from the given file. Omitted fields
+// are set to their defaults, and unknown fields are reported as an error.
+func LoadNodeConfigFromFile(configFilePath string) (*NodeConfig, error) {
+	data, err := ioutil.ReadFile(configFilePath)
 	if err != nil {
 		return nil, err
 	}
 
+	nodeConfig, err := loadNodeConfig(string(data), true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", configFilePath, err)
+	}
+
 	if err := nodeConfig.Validate(); err != nil {
 		return nil, err
 	}
@@ -374,16 +608,22 @@ func LoadNodeConfig(configJSON string) (*NodeConfig, error) {
 	return nodeConfig, nil
 }
 
-func loadNodeConfig(configJSON string) (*NodeConfig, error) {
+// loadNodeConfig overrides default configuration with JSON input.
+// If strict is set, unknown fields in JSON input result in error.
+func loadNodeConfig(configJSON string, strict bool) (*NodeConfig, error) {
 	nodeConfig, err := NewNodeConfig("", 0, true)
 	if err != nil {
 		return nil, err
 	}
 
-	decoder := json.NewDecoder(strings.NewReader(configJSON))
+	if strict {
+		if err := checkUnknownFields([]byte(configJSON), reflect.TypeOf(nodeConfig)); err != nil {
+			return nil, err
+		}
+	}
 
 	// override default configuration with values by JSON input
-	if err := decoder.Decode(&nodeConfig); err != nil {
+	if err := json.Unmarshal([]byte(configJSON), &nodeConfig); err != nil {
 		return nil, err
 	}
 
@@ -395,6 +635,67 @@ func loadNodeConfig(configJSON string) (*NodeConfig, error) {
 	return nodeConfig, nil
 }
 
+// checkUnknownFields returns an error if JSON object has keys not matching
+// any field of the given struct type, including nested structs.
+func checkUnknownFields(data []byte, typ reflect.Type) error {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil || object == nil {
+		return nil // not an object, reported when decoding
+	}
+
+	for key, value := range object {
+		field, ok := jsonField(typ, key)
+		if !ok {
+			return fmt.Errorf("json: unknown field %q", key)
+		}
+
+		if err := checkUnknownFields(value, field.Type); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jsonField returns a field of the struct type, that JSON key is decoded into.
+// Like encoding/json, it prefers an exact match, but accepts a case-insensitive one.
+func jsonField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	var (
+		match reflect.StructField
+		found bool
+	)
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+
+		name := field.Name
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+
+		if name == key {
+			return field, true
+		}
+		if !found && strings.EqualFold(name, key) {
+			match, found = field, true
+		}
+	}
+
+	return match, found
+}
+
 // Validate checks if NodeConfig fields have valid values.
 //
 // It returns nil if there are no errors, otherwise one or more errors
@@ -410,13 +711,59 @@ func loadNodeConfig(configJSON string) (*NodeConfig, error) {
 //
 //   Key: 'TestStruct.TestField' Error:Field validation for 'TestField' failed on the 'required' tag
 //
+// Invariants spanning multiple fields, that the node can't be started without,
+// are reported with descriptive errors, e.g. ErrMissingUpstreamURL.
 func (c *NodeConfig) Validate() error {
+	subConfigs := []struct {
+		name    string
+		missing bool
+	}{
+		{"BootClusterConfig", c.BootClusterConfig == nil},
+		{"LightEthConfig", c.LightEthConfig == nil},
+		{"WhisperConfig", c.WhisperConfig == nil},
+		{"SwarmConfig", c.SwarmConfig == nil},
+	}
+	for _, sc := range subConfigs {
+		if sc.missing {
+			return MissingSubConfigError{Name: sc.name}
+		}
+	}
+
 	validate := NewValidator()
 
 	if err := validate.Struct(c); err != nil {
 		return err
 	}
 
+	if c.UpstreamConfig.Enabled && c.UpstreamConfig.URL == "" {
+		return ErrMissingUpstreamURL
+	}
+
+	if oracle := c.GasPriceOracle; oracle.MaxPrice > 0 && oracle.MinPrice > oracle.MaxPrice {
+		return ErrInvalidGasPriceRange
+	}
+
+	if c.WSEnabled && c.RPCEnabled && c.WSPort == c.HTTPPort {
+		return ErrWSPortCollision
+	}
+
+	if c.IPCEnabled {
+		if limit := maxIPCPathLength(); limit > 0 && len(c.IPCPath()) > limit {
+			return fmt.Errorf("%v: %s", ErrIPCPathTooLong, c.IPCPath())
+		}
+	}
+
+	for _, enode := range c.BootNodes {
+		if _, err := discover.ParseNode(enode); err != nil {
+			return fmt.Errorf("%v %s: %v", ErrInvalidBootNode, enode, err)
+		}
+	}
+
+	// genesis of public networks is known, private ones have to provide it
+	if (c.LightEthConfig.Enabled || c.LightMode) && !c.UpstreamConfig.Enabled && c.LightEthConfig.Genesis == "" {
+		return ErrMissingGenesis
+	}
+
 	if c.BootClusterConfig.Enabled {
 		if err := validate.Struct(c.BootClusterConfig); err != nil {
 			return err
@@ -433,6 +780,11 @@ func (c *NodeConfig) Validate() error {
 		if err := validate.Struct(c.WhisperConfig); err != nil {
 			return err
 		}
+
+		mailServer := c.WhisperConfig.MailServerConfig
+		if mailServer != nil && mailServer.Enabled && mailServer.Password == "" && c.WhisperConfig.PasswordFile == "" {
+			return ErrMissingMailServerPassword
+		}
 	}
 
 	if c.SwarmConfig.Enabled {
@@ -444,18 +796,51 @@ func (c *NodeConfig) Validate() error {
 	return nil
 }
 
-// Save dumps configuration to the disk
+// IPCPath returns a path of the IPC socket, IPCFile resolved relatively to DataDir.
+func (c *NodeConfig) IPCPath() string {
+	ipcFile := c.IPCFile
+	if ipcFile == "" {
+		ipcFile = IPCFile
+	}
+
+	if filepath.IsAbs(ipcFile) {
+		return ipcFile
+	}
+
+	return filepath.Join(c.DataDir, ipcFile)
+}
+
+// maxIPCPathLength returns the longest IPC socket path supported by the platform,
+// zero if there is no limit. The path of a unix socket is limited by the size of
+// sockaddr_un.sun_path, which includes the terminating NUL byte.
+func maxIPCPathLength() int {
+	switch runtime.GOOS {
+	case "windows": // named pipes
+		return 0
+	case "linux", "android":
+		return 107
+	default: // darwin and BSDs
+		return 103
+	}
+}
+
+// Save dumps configuration to the disk, as config.json in DataDir
 func (c *NodeConfig) Save() error {
+	return c.SaveToFile(filepath.Join(c.DataDir, "config.json"))
+}
+
+// SaveToFile dumps configuration to the given file as JSON,
+// which can be loaded back with LoadNodeConfigFromFile
+func (c *NodeConfig) SaveToFile(configFilePath string) error {
 	data, err := json.MarshalIndent(c, "", "    ")
 	if err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(c.DataDir, os.ModePerm); err != nil {
+	if err := os.MkdirAll(filepath.Dir(configFilePath), os.ModePerm); err != nil {
 		return err
 	}
 
-	configFilePath := filepath.Join(c.DataDir, "config.json")
 	if err := ioutil.WriteFile(configFilePath, data, os.ModePerm); err != nil {
 		return err
 	}
@@ -479,6 +864,8 @@ func (c *NodeConfig) updateConfig() error {
 		return err
 	}
 
+	c.updateMailServerConfig()
+
 	if err := c.updateRelativeDirsConfig(); err != nil {
 		return err
 	}
@@ -584,6 +971,34 @@ func (c *NodeConfig) updateBootClusterConfig() error {
 	return nil
 }
 
+// updateMailServerConfig enables the mail server if the node is configured as one.
+func (c *NodeConfig) updateMailServerConfig() {
+	if c.WhisperConfig.MailServerConfig == nil {
+		c.WhisperConfig.MailServerConfig = &MailServerConfig{}
+	}
+	if c.WhisperConfig.MailServerNode {
+		c.WhisperConfig.MailServerConfig.Enabled = true
+	}
+}
+
+// SetDefaultDirs sets DataDir and KeyStoreDir to given directories if they are
+// empty, and updates directories derived from them. Empty defaults are ignored.
+func (c *NodeConfig) SetDefaultDirs(dataDir, keyStoreDir string) error {
+	if len(c.DataDir) == 0 {
+		c.DataDir = dataDir
+	}
+	if len(c.KeyStoreDir) == 0 {
+		c.KeyStoreDir = keyStoreDir
+	}
+
+	// missing sub configs are reported by Validate
+	if c.WhisperConfig == nil || c.WhisperConfig.MailServerConfig == nil {
+		return nil
+	}
+
+	return c.updateRelativeDirsConfig()
+}
+
 // updateRelativeDirsConfig updates directories that should be wrt to DataDir
 func (c *NodeConfig) updateRelativeDirsConfig() error {
 	makeSubDirPath := func(baseDir, subDir string) string {
@@ -601,6 +1016,10 @@ func (c *NodeConfig) updateRelativeDirsConfig() error {
 		c.WhisperConfig.DataDir = makeSubDirPath(c.DataDir, WhisperDataDir)
 	}
 
+	if len(c.WhisperConfig.MailServerConfig.DataDir) == 0 {
+		c.WhisperConfig.MailServerConfig.DataDir = c.WhisperConfig.DataDir
+	}
+
 	return nil
 }
 
@@ -207,13 +207,27 @@ var loadConfigTestCases = []struct {
 			"Name": "TestStatusNode",
 			"WSPort": 8546,
 			"IPCEnabled": true,
-			"WSEnabled": false
+			"WSEnabled": false,
+			"LightEthConfig": {
+				"Enabled": true,
+				"Genesis": "{\"config\": {\"chainId\": 311}}"
+			}
 		}`,
 		func(t *testing.T, dataDir string, nodeConfig *params.NodeConfig, err error) {
 			require.NoError(t, err)
 			require.EqualValues(t, 311, nodeConfig.NetworkID)
 		},
 	},
+	{
+		`test loading Privatenet config without genesis`,
+		`{
+			"NetworkId": 311,
+			"DataDir": "$TMPDIR"
+		}`,
+		func(t *testing.T, dataDir string, nodeConfig *params.NodeConfig, err error) {
+			require.Equal(t, params.ErrMissingGenesis, err)
+		},
+	},
 	{
 		`default boot cluster (Ropsten Dev)`,
 		`{
@@ -257,6 +271,9 @@ var loadConfigTestCases = []struct {
 			"DataDir": "$TMPDIR",
 			"BootClusterConfig": {
 				"Enabled": false
+			},
+			"LightEthConfig": {
+				"Genesis": "{\"config\": {\"chainId\": 311}}"
 			}
 		}`,
 		func(t *testing.T, dataDir string, nodeConfig *params.NodeConfig, err error) {
@@ -382,7 +399,7 @@ var loadConfigTestCases = []struct {
 	{
 		`default DevMode (true)`,
 		`{
-			"NetworkId": 311,
+			"NetworkId": 3,
 			"DataDir": "$TMPDIR"
 		}`,
 		func(t *testing.T, dataDir string, nodeConfig *params.NodeConfig, err error) {
@@ -459,6 +476,69 @@ func TestConfigWriteRead(t *testing.T) {
 	configReadWrite(params.MainNetworkID, "testdata/config.mainnet.json")
 }
 
+func TestNewNodeConfigFromJSON(t *testing.T) {
+	nodeConfig, err := params.NewNodeConfigFromJSON(`{"NetworkID":4,"DataDir":"/tmp/x"}`)
+	require.NoError(t, err)
+
+	require.EqualValues(t, params.RinkebyNetworkID, nodeConfig.NetworkID)
+	require.Equal(t, "/tmp/x", nodeConfig.DataDir)
+	require.Equal(t, "/tmp/x/keystore", nodeConfig.KeyStoreDir)
+	require.Equal(t, params.WSHost, nodeConfig.WSHost)
+	require.Equal(t, params.WSPort, nodeConfig.WSPort)
+	require.Equal(t, params.IPCFile, nodeConfig.IPCFile)
+	require.Equal(t, params.HTTPHost, nodeConfig.HTTPHost)
+	require.Equal(t, params.HTTPPort, nodeConfig.HTTPPort)
+	require.Equal(t, params.UpstreamRinkebyEthereumNetworkURL, nodeConfig.UpstreamConfig.URL)
+
+	_, err = params.NewNodeConfigFromJSON(`{"NetworkID":4}`)
+	require.Error(t, err, "DataDir is required")
+}
+
+func TestLoadNodeConfigFromFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "geth-config-tests")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+
+	nodeConfig, err := params.NewNodeConfig(tmpDir, params.RopstenNetworkID, true)
+	require.NoError(t, err)
+	nodeConfig.MaxPeers = 3
+
+	configFile := filepath.Join(tmpDir, "configs", "node.json")
+	require.NoError(t, nodeConfig.SaveToFile(configFile))
+
+	loadedConfig, err := params.LoadNodeConfigFromFile(configFile)
+	require.NoError(t, err)
+	require.Equal(t, nodeConfig, loadedConfig)
+
+	// omitted fields are set to defaults
+	partialFile := filepath.Join(tmpDir, "partial.json")
+	require.NoError(t, ioutil.WriteFile(partialFile, []byte(`{"NetworkId": 3, "DataDir": "`+tmpDir+`"}`), os.ModePerm))
+
+	defaultConfig, err := params.NewNodeConfig(tmpDir, params.RopstenNetworkID, true)
+	require.NoError(t, err)
+	loadedConfig, err = params.LoadNodeConfigFromFile(partialFile)
+	require.NoError(t, err)
+	require.Equal(t, defaultConfig, loadedConfig)
+
+	// unknown fields are not ignored
+	unknownFile := filepath.Join(tmpDir, "unknown.json")
+	require.NoError(t, ioutil.WriteFile(unknownFile, []byte(`{"NetworkId": 3, "DataDir": "/tmp", "Foo": 1}`), os.ModePerm))
+
+	_, err = params.LoadNodeConfigFromFile(unknownFile)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unknown field "Foo"`)
+
+	unknownFile = filepath.Join(tmpDir, "unknown_nested.json")
+	require.NoError(t, ioutil.WriteFile(unknownFile, []byte(`{"NetworkId": 3, "DataDir": "/tmp", "WhisperConfig": {"Enabled": true, "Foo": 1}}`), os.ModePerm))
+
+	_, err = params.LoadNodeConfigFromFile(unknownFile)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unknown field "Foo"`)
+
+	_, err = params.LoadNodeConfigFromFile(filepath.Join(tmpDir, "missing.json"))
+	require.Error(t, err)
+}
+
 // TestNodeConfigValidate checks validation of individual fields.
 func TestNodeConfigValidate(t *testing.T) {
 	testCases := []struct {
@@ -497,6 +577,20 @@ func TestNodeConfigValidate(t *testing.T) {
 				"DataDir":   "required",
 			},
 		},
+		{
+			Name: "Validate upstream URL is set for a private network",
+			Config: `{
+				"NetworkId": 777,
+				"DataDir": "/some/dir",
+				"UpstreamConfig": {
+					"Enabled": true,
+					"URL": ""
+				}
+			}`,
+			Error:       params.ErrMissingUpstreamURL.Error(),
+			FieldErrors: nil,
+		},
+
 		{
 			Name: "Validate Name does not contain slash",
 			Config: `{
@@ -509,6 +603,43 @@ func TestNodeConfigValidate(t *testing.T) {
 				"Name": "excludes",
 			},
 		},
+		{
+			Name: "Validate Whisper max message size",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"WhisperConfig": {
+					"Enabled": true,
+					"MaxMessageSize": 20971520
+				}
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"MaxMessageSize": "lte",
+			},
+		},
+		{
+			Name: "Validate log level in lower case",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"LogLevel": "warn"
+			}`,
+			Error:       "",
+			FieldErrors: nil,
+		},
+		{
+			Name: "Validate invalid log level",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"LogLevel": "verbose"
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"LogLevel": "loglevel",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -530,3 +661,236 @@ func TestNodeConfigValidate(t *testing.T) {
 		}
 	}
 }
+
+// TestNodeConfigValidateInvariants checks that configs the node can't be
+// started with are reported with descriptive errors.
+func TestNodeConfigValidateInvariants(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Update func(*params.NodeConfig)
+		Error  string
+	}{
+		{
+			Name:   "Valid config",
+			Update: func(*params.NodeConfig) {},
+			Error:  "",
+		},
+		{
+			Name:   "Missing NetworkID",
+			Update: func(c *params.NodeConfig) { c.NetworkID = 0 },
+			Error:  "Key: 'NodeConfig.NetworkID' Error:Field validation for 'NetworkID' failed on the 'required' tag",
+		},
+		{
+			Name:   "Missing DataDir",
+			Update: func(c *params.NodeConfig) { c.DataDir = "" },
+			Error:  "Key: 'NodeConfig.DataDir' Error:Field validation for 'DataDir' failed on the 'required' tag",
+		},
+		{
+			Name: "Upstream enabled with empty URL",
+			Update: func(c *params.NodeConfig) {
+				c.UpstreamConfig.Enabled = true
+				c.UpstreamConfig.URL = ""
+			},
+			Error: params.ErrMissingUpstreamURL.Error(),
+		},
+		{
+			Name:   "Mail server without password",
+			Update: func(c *params.NodeConfig) { c.WhisperConfig.MailServerConfig.Enabled = true },
+			Error:  params.ErrMissingMailServerPassword.Error(),
+		},
+		{
+			Name: "Mail server with password file",
+			Update: func(c *params.NodeConfig) {
+				c.WhisperConfig.MailServerConfig.Enabled = true
+				c.WhisperConfig.PasswordFile = "/tmp/password"
+			},
+		},
+		{
+			Name: "Private network without genesis",
+			Update: func(c *params.NodeConfig) {
+				c.NetworkID = 1337
+				c.LightEthConfig.Genesis = ""
+			},
+			Error: params.ErrMissingGenesis.Error(),
+		},
+		{
+			Name: "Private network without genesis in light mode",
+			Update: func(c *params.NodeConfig) {
+				c.NetworkID = 1337
+				c.LightEthConfig.Enabled = false
+				c.LightEthConfig.Genesis = ""
+				c.LightMode = true
+			},
+			Error: params.ErrMissingGenesis.Error(),
+		},
+		{
+			Name: "Private network without genesis using upstream",
+			Update: func(c *params.NodeConfig) {
+				c.NetworkID = 1337
+				c.LightEthConfig.Genesis = ""
+				c.UpstreamConfig.Enabled = true
+			},
+			Error: "",
+		},
+		{
+			Name: "Private network with genesis",
+			Update: func(c *params.NodeConfig) {
+				c.NetworkID = 1337
+				c.LightEthConfig.Genesis = `{"config": {"chainId": 1337}}`
+			},
+			Error: "",
+		},
+		{
+			Name: "Valid boot nodes",
+			Update: func(c *params.NodeConfig) {
+				c.BootNodes = []string{"enode://7ab298cedc4185a894d21d8a4615262ec6bdce66c9b6783878258e0d5b31013d30c9038932432f70e5b2b6a5cd323bf820554fcb22fbc7b45367889522e9c449@51.15.63.93:30303"}
+			},
+			Error: "",
+		},
+		{
+			Name: "Invalid boot node",
+			Update: func(c *params.NodeConfig) {
+				c.BootNodes = []string{
+					"enode://7ab298cedc4185a894d21d8a4615262ec6bdce66c9b6783878258e0d5b31013d30c9038932432f70e5b2b6a5cd323bf820554fcb22fbc7b45367889522e9c449@51.15.63.93:30303",
+					"enode://foobar@41.41.41.41:30300",
+				}
+			},
+			Error: "invalid boot node enode://foobar@41.41.41.41:30300: invalid node ID (encoding/hex: invalid byte: U+006F 'o')",
+		},
+		{
+			Name: "Upstream disabled with empty URL",
+			Update: func(c *params.NodeConfig) {
+				c.UpstreamConfig.Enabled = false
+				c.UpstreamConfig.URL = ""
+			},
+			Error: "",
+		},
+		{
+			Name:   "Negative GasPriceMultiplier",
+			Update: func(c *params.NodeConfig) { c.GasPriceMultiplier = -1 },
+			Error:  "Key: 'NodeConfig.GasPriceMultiplier' Error:Field validation for 'GasPriceMultiplier' failed on the 'gte' tag",
+		},
+		{
+			Name: "Gas price oracle with MinPrice greater than MaxPrice",
+			Update: func(c *params.NodeConfig) {
+				c.GasPriceOracle.MinPrice = 2
+				c.GasPriceOracle.MaxPrice = 1
+			},
+			Error: params.ErrInvalidGasPriceRange.Error(),
+		},
+		{
+			Name:   "Gas price oracle percentile out of range",
+			Update: func(c *params.NodeConfig) { c.GasPriceOracle.Percentile = 101 },
+			Error:  "Key: 'NodeConfig.GasPriceOracle.Percentile' Error:Field validation for 'Percentile' failed on the 'lte' tag",
+		},
+		{
+			Name: "WS port colliding with HTTP port",
+			Update: func(c *params.NodeConfig) {
+				c.RPCEnabled = true
+				c.WSEnabled = true
+				c.WSPort = c.HTTPPort
+			},
+			Error: params.ErrWSPortCollision.Error(),
+		},
+		{
+			Name: "WS port colliding with disabled HTTP server",
+			Update: func(c *params.NodeConfig) {
+				c.WSEnabled = true
+				c.WSPort = c.HTTPPort
+			},
+			Error: "",
+		},
+		{
+			Name: "Overlong IPC path",
+			Update: func(c *params.NodeConfig) {
+				c.IPCEnabled = true
+				c.IPCFile = "/" + strings.Repeat("a", 200)
+			},
+			Error: params.ErrIPCPathTooLong.Error() + ": /" + strings.Repeat("a", 200),
+		},
+		{
+			Name:   "Overlong IPC path with IPC disabled",
+			Update: func(c *params.NodeConfig) { c.IPCFile = "/" + strings.Repeat("a", 200) },
+			Error:  "",
+		},
+		{
+			Name:   "Missing BootClusterConfig",
+			Update: func(c *params.NodeConfig) { c.BootClusterConfig = nil },
+			Error:  "missing required sub-configuration: BootClusterConfig",
+		},
+		{
+			Name:   "Missing LightEthConfig",
+			Update: func(c *params.NodeConfig) { c.LightEthConfig = nil },
+			Error:  "missing required sub-configuration: LightEthConfig",
+		},
+		{
+			Name:   "Missing WhisperConfig",
+			Update: func(c *params.NodeConfig) { c.WhisperConfig = nil },
+			Error:  "missing required sub-configuration: WhisperConfig",
+		},
+		{
+			Name:   "Missing SwarmConfig",
+			Update: func(c *params.NodeConfig) { c.SwarmConfig = nil },
+			Error:  "missing required sub-configuration: SwarmConfig",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			config, err := params.NewNodeConfig("/tmp/data", params.RopstenNetworkID, true)
+			require.NoError(t, err)
+
+			tc.Update(config)
+
+			err = config.Validate()
+			if tc.Error == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.Error)
+		})
+	}
+}
+
+func TestLoadNodeConfigMailServer(t *testing.T) {
+	nodeConfig, err := params.LoadNodeConfig(`{
+		"NetworkId": 3,
+		"DataDir": "/tmp/data",
+		"WhisperConfig": {
+			"Enabled": true,
+			"MailServerNode": true,
+			"PasswordFile": "/tmp/password"
+		}
+	}`)
+	require.NoError(t, err)
+	require.True(t, nodeConfig.WhisperConfig.MailServerConfig.Enabled)
+	require.Equal(t, "/tmp/data/wnode", nodeConfig.WhisperConfig.MailServerConfig.DataDir)
+
+	nodeConfig, err = params.LoadNodeConfig(`{
+		"NetworkId": 3,
+		"DataDir": "/tmp/data",
+		"WhisperConfig": {
+			"Enabled": true,
+			"MailServerConfig": {
+				"Enabled": true,
+				"DataDir": "/tmp/mail",
+				"Password": "secret"
+			}
+		}
+	}`)
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/mail", nodeConfig.WhisperConfig.MailServerConfig.DataDir)
+	password, err := nodeConfig.WhisperConfig.MailServerPassword()
+	require.NoError(t, err)
+	require.Equal(t, "secret", password)
+}
+
+func TestNodeConfigValidateMissingSubConfig(t *testing.T) {
+	config, err := params.NewNodeConfig("/tmp/data", params.RopstenNetworkID, true)
+	require.NoError(t, err)
+
+	config.WhisperConfig = nil
+
+	err = config.Validate()
+	require.Equal(t, params.MissingSubConfigError{Name: "WhisperConfig"}, err)
+}
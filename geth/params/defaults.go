@@ -76,6 +76,9 @@ const (
 	// WhisperTTL is time to live for messages, in seconds
 	WhisperTTL = 120
 
+	// WhisperMaxMessageSize is the maximum size of accepted Whisper messages, in bytes
+	WhisperMaxMessageSize = 1024 * 1024
+
 	// FirebaseNotificationTriggerURL is URL where FCM notification requests are sent to
 	FirebaseNotificationTriggerURL = "https://fcm.googleapis.com/fcm/send"
 
@@ -91,6 +94,15 @@ const (
 	// allow us avoid syncing node.
 	UpstreamRinkebyEthereumNetworkURL = "https://rinkeby.infura.io/nKmXgiFgc2KqtoQ8BCGJ"
 
+	// DefaultGasPriceOracleBlocks is a number of recent blocks gas prices are sampled from
+	DefaultGasPriceOracleBlocks = 20
+
+	// DefaultGasPriceOraclePercentile is a percentile of sampled gas prices suggested
+	DefaultGasPriceOraclePercentile = 60
+
+	// LightModeMaxPeers is the maximum number of peers connected in light mode
+	LightModeMaxPeers = 10
+
 	// MainNetworkID is id of the main network
 	MainNetworkID = 1
 
@@ -0,0 +1,80 @@
+package params
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// SecretResolver returns the value of a secret (e.g. an API key kept in the
+// platform keychain) referenced by ref.
+type SecretResolver func(ref string) (string, error)
+
+var (
+	secretResolverMx sync.RWMutex // guards secretResolver
+	secretResolver   SecretResolver
+)
+
+// SetSecretResolver sets the resolver of references like ${INFURA_TOKEN} in upstream
+// URLs and headers, so that secrets don't have to be stored in the config. References
+// are resolved when the upstream is connected to, i.e. every time the node starts.
+// A nil resolver, the default, resolves references to environment variables.
+func SetSecretResolver(resolver SecretResolver) {
+	secretResolverMx.Lock()
+	defer secretResolverMx.Unlock()
+
+	secretResolver = resolver
+}
+
+// ResolveSecrets replaces references like ${INFURA_TOKEN} in s with values
+// returned by the secret resolver, or with environment variables if none is set.
+func ResolveSecrets(s string) (string, error) {
+	secretResolverMx.RLock()
+	resolver := secretResolver
+	secretResolverMx.RUnlock()
+
+	if resolver == nil {
+		return os.ExpandEnv(s), nil
+	}
+
+	var resolveErr error
+	resolved := os.Expand(s, func(ref string) string {
+		value, err := resolver(ref)
+		if err != nil && resolveErr == nil {
+			resolveErr = fmt.Errorf("failed to resolve secret %s: %v", ref, err)
+		}
+		return value
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+
+	return resolved, nil
+}
+
+// Resolved returns a copy of the config with secret references in URLs
+// and header values resolved by ResolveSecrets.
+func (c UpstreamRPCConfig) Resolved() (UpstreamRPCConfig, error) {
+	var err error
+	if c.URL, err = ResolveSecrets(c.URL); err != nil {
+		return c, err
+	}
+
+	fallbackURLs := make([]string, len(c.FallbackURLs))
+	for i, url := range c.FallbackURLs {
+		if fallbackURLs[i], err = ResolveSecrets(url); err != nil {
+			return c, err
+		}
+	}
+	c.FallbackURLs = fallbackURLs
+
+	headers := make(map[string]string, len(c.Headers))
+	for name, value := range c.Headers {
+		if headers[name], err = ResolveSecrets(value); err != nil {
+			return c, err
+		}
+	}
+	c.Headers = headers
+
+	return c, nil
+}
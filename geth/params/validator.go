@@ -1,10 +1,32 @@
 package params
 
 import (
+	"strings"
+
 	"gopkg.in/go-playground/validator.v9"
 )
 
+// logLevels are valid values of NodeConfig.LogLevel, in upper case.
+var logLevels = map[string]bool{
+	"ERROR":   true,
+	"WARN":    true,
+	"WARNING": true,
+	"INFO":    true,
+	"DEBUG":   true,
+	"TRACE":   true,
+}
+
 // NewValidator returns a new validator.Validate.
+// Besides the built-in tags, it supports "loglevel" tag, which accepts
+// log level names in either case.
 func NewValidator() *validator.Validate {
-	return validator.New()
+	validate := validator.New()
+	validate.RegisterValidation("loglevel", isLogLevel) // nolint: errcheck
+
+	return validate
+}
+
+// isLogLevel checks if a field is a valid log level name.
+func isLogLevel(fl validator.FieldLevel) bool {
+	return logLevels[strings.ToUpper(fl.Field().String())]
 }
@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultBreakerCooldown is used if the circuit breaker is enabled without a cooldown.
+const defaultBreakerCooldown = 30 * time.Second
+
+// circuitOpenError is returned for upstream calls rejected by the open circuit breaker.
+// It implements gethrpc.Error, so it is reported as a JSON-RPC error.
+type circuitOpenError struct{}
+
+func (e *circuitOpenError) ErrorCode() int { return errCallbackCode }
+
+func (e *circuitOpenError) Error() string {
+	return "upstream is unavailable, calls are suspended"
+}
+
+// circuitBreaker rejects calls once threshold consecutive calls failed (the circuit
+// is open). After cooldown a single trial call is let through: if it succeeds,
+// the circuit is closed, otherwise it stays open for another cooldown.
+// A zero threshold disables the breaker.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mx        sync.Mutex // mx guards failures, openUntil and probing
+	failures  int        // number of consecutive failed calls
+	openUntil time.Time  // calls are rejected until then, once threshold is reached
+	probing   bool       // set while a trial call is in flight
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow returns an error if a call must be rejected. Otherwise, the caller
+// must report the result of the call with done.
+func (b *circuitBreaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+
+	if b.probing || time.Now().Before(b.openUntil) {
+		return &circuitOpenError{}
+	}
+
+	b.probing = true
+	return nil
+}
+
+// done records whether an allowed call failed.
+func (b *circuitBreaker) done(failed bool) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// abandon releases an allowed call without recording its result.
+func (b *circuitBreaker) abandon() {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	b.probing = false
+}
@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamCircuitBreaker(t *testing.T) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("test", TestService{}))
+
+	var (
+		failing int32 = 1
+		calls   int32
+	)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer upstream.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{
+		URL:              upstream.URL,
+		BreakerThreshold: 2,
+	})
+	require.NoError(t, err)
+	pool.breaker.cooldown = 100 * time.Millisecond
+
+	ctx := context.Background()
+	var version string
+
+	// the circuit opens after 2 failures
+	for i := 0; i < 2; i++ {
+		err = pool.CallContext(ctx, &version, "test_version")
+		require.Error(t, err)
+		require.NotEqual(t, &circuitOpenError{}, err)
+	}
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+
+	err = pool.CallContext(ctx, &version, "test_version")
+	require.Equal(t, &circuitOpenError{}, err)
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls), "upstream should not be called while the circuit is open")
+
+	// a failed trial keeps the circuit open
+	time.Sleep(150 * time.Millisecond)
+	err = pool.CallContext(ctx, &version, "test_version")
+	require.NotEqual(t, &circuitOpenError{}, err)
+	require.EqualValues(t, 3, atomic.LoadInt32(&calls))
+	require.Equal(t, &circuitOpenError{}, pool.CallContext(ctx, &version, "test_version"))
+
+	// a successful trial closes the circuit
+	atomic.StoreInt32(&failing, 0)
+	time.Sleep(150 * time.Millisecond)
+	require.NoError(t, pool.CallContext(ctx, &version, "test_version"))
+	require.Equal(t, "1.0", version)
+	require.NoError(t, pool.CallContext(ctx, &version, "test_version"))
+	require.EqualValues(t, 5, atomic.LoadInt32(&calls))
+}
+
+func TestUpstreamCircuitBreakerDisabled(t *testing.T) {
+	var calls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{URL: upstream.URL})
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		err = pool.CallContext(context.Background(), nil, "test_version")
+		require.Error(t, err)
+		require.NotEqual(t, &circuitOpenError{}, err)
+	}
+	require.EqualValues(t, 5, atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,127 @@
+package rpc
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// defaultCacheTTLs are cache TTLs of methods whose results don't change during a session.
+var defaultCacheTTLs = map[string]time.Duration{
+	"net_version":        5 * time.Minute,
+	"web3_clientVersion": 5 * time.Minute,
+}
+
+// uncacheableMethods change the state or depend on the caller, so their
+// responses are never cached, even if a TTL is set for them.
+var uncacheableMethods = map[string]bool{
+	"eth_sendRawTransaction":          true,
+	"eth_sendTransaction":             true,
+	"eth_sign":                        true,
+	"eth_signTransaction":             true,
+	"eth_submitWork":                  true,
+	"eth_submitHashrate":              true,
+	"eth_newFilter":                   true,
+	"eth_newBlockFilter":              true,
+	"eth_newPendingTransactionFilter": true,
+	"eth_uninstallFilter":             true,
+	"eth_getFilterChanges":            true,
+	"shh_post":                        true,
+}
+
+type cacheEntry struct {
+	method  string
+	result  json.RawMessage
+	expires time.Time
+}
+
+// responseCache keeps results of upstream calls of methods with a TTL set,
+// keyed by method and params. Zero value caches nothing and is ready to use.
+type responseCache struct {
+	mx      sync.Mutex // mx guards all fields
+	ttls    map[string]time.Duration
+	entries map[string]cacheEntry
+}
+
+// setTTL sets how long results of the method are cached for. Zero or less
+// disables caching of the method. Uncacheable methods are ignored.
+func (c *responseCache) setTTL(method string, ttl time.Duration) {
+	if uncacheableMethods[method] {
+		return
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.ttls == nil {
+		c.ttls = make(map[string]time.Duration)
+	}
+
+	if ttl > 0 {
+		c.ttls[method] = ttl
+	} else {
+		delete(c.ttls, method)
+	}
+
+	for key, entry := range c.entries {
+		if entry.method == method {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// ttl returns the cache TTL of the method, zero if it is not cached.
+func (c *responseCache) ttl(method string) time.Duration {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return c.ttls[method]
+}
+
+// get returns a cached result of a call with given key, if it has not expired.
+func (c *responseCache) get(key string) (json.RawMessage, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return entry.result, true
+}
+
+// put caches the result of a call of the method with given key for ttl.
+func (c *responseCache) put(method, key string, result json.RawMessage, ttl time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]cacheEntry)
+	}
+
+	c.entries[key] = cacheEntry{
+		method:  method,
+		result:  result,
+		expires: time.Now().Add(ttl),
+	}
+}
+
+// cacheKey returns the cache key of a call, or false if params can't be encoded.
+func cacheKey(method string, args []interface{}) (string, bool) {
+	if args == nil {
+		args = []interface{}{} // the same key for no params as for empty ones
+	}
+
+	params, err := json.Marshal(args)
+	if err != nil {
+		return "", false
+	}
+
+	return method + " " + string(params), true
+}
@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+// EthService is an RPC service used by tests, it has to be exported.
+type EthService struct{}
+
+func (EthService) SendRawTransaction(tx string) string { return tx }
+
+func TestCacheUpstreamResponses(t *testing.T) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("net", NetService{}))
+	require.NoError(t, server.RegisterName("eth", EthService{}))
+
+	var calls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		server.ServeHTTP(w, r)
+	}))
+	defer upstream.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{URL: upstream.URL})
+	require.NoError(t, err)
+
+	c := &Client{
+		upstreamEnabled: true,
+		upstream:        pool,
+		router:          newRouter(true),
+		handlers:        make(map[string]Handler),
+	}
+	for method, ttl := range defaultCacheTTLs {
+		c.SetCacheTTL(method, ttl)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp := c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+		require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"3"}`, resp)
+	}
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls), "cached result should be used")
+
+	var version string
+	require.NoError(t, c.Call(&version, "net_version"))
+	require.Equal(t, "3", version)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	// mutating methods are never cached
+	c.SetCacheTTL("eth_sendRawTransaction", time.Minute)
+	for i := 0; i < 2; i++ {
+		resp := c.CallRaw(`{"jsonrpc":"2.0","method":"eth_sendRawTransaction","params":["0x01"],"id":1}`)
+		require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x01"}`, resp)
+	}
+	require.EqualValues(t, 3, atomic.LoadInt32(&calls))
+
+	// disabling caching of the method drops its cached results
+	c.SetCacheTTL("net_version", 0)
+	require.NoError(t, c.Call(&version, "net_version"))
+	require.EqualValues(t, 4, atomic.LoadInt32(&calls))
+}
+
+func TestCacheExpiration(t *testing.T) {
+	var cache responseCache
+	cache.setTTL("net_version", 10*time.Millisecond)
+
+	cache.put("net_version", "net_version []", []byte(`"3"`), cache.ttl("net_version"))
+	result, ok := cache.get("net_version []")
+	require.True(t, ok)
+	require.Equal(t, `"3"`, string(result))
+
+	time.Sleep(20 * time.Millisecond)
+	_, ok = cache.get("net_version []")
+	require.False(t, ok, "expired result should not be returned")
+}
@@ -3,6 +3,9 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 
 	gethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/status-im/status-go/geth/log"
@@ -11,8 +14,30 @@ import (
 const (
 	jsonrpcVersion        = "2.0"
 	errInvalidMessageCode = -32700 // from go-ethereum/rpc/errors.go
+	errInvalidRequestCode = -32600 // from go-ethereum/rpc/errors.go
+	errCallbackCode       = -32000 // from go-ethereum/rpc/errors.go
 )
 
+var errBatchExpected = errors.New("batch request must be a JSON array")
+
+// errors of requests which are valid JSON, but not valid JSON-RPC 2.0 requests
+var (
+	errNotAnObject    error = invalidRequestError("request must be a JSON object")
+	errEmptyBatch     error = invalidRequestError("batch request must not be empty")
+	errInvalidVersion error = invalidRequestError(`jsonrpc must be "2.0"`)
+	errMissingMethod  error = invalidRequestError("method is required")
+	errInvalidID      error = invalidRequestError("id must be a string, a number or null")
+	errInvalidParams  error = invalidRequestError("params must be an array")
+)
+
+// invalidRequestError is returned for requests which are not valid JSON-RPC 2.0 requests.
+// It implements gethrpc.Error, so it is reported as a JSON-RPC error.
+type invalidRequestError string
+
+func (e invalidRequestError) ErrorCode() int { return errInvalidRequestCode }
+
+func (e invalidRequestError) Error() string { return string(e) }
+
 // for JSON-RPC responses obtained via CallRaw(), we have no way
 // to know ID field from actual response. web3.js (primary and
 // only user of CallRaw()) will validate response by checking
@@ -21,11 +46,96 @@ const (
 // thus, we will use zero ID as a workaround of this limitation
 var defaultMsgID = json.RawMessage(`0`)
 
+// nullMsgID is the id of error responses to requests whose id can't be
+// determined, e.g. because they can't be parsed, as required by the spec.
+var nullMsgID = json.RawMessage(`null`)
+
 // CallRaw performs a JSON-RPC call with already crafted JSON-RPC body. It
 // returns string in JSON format with response (successul or error).
+//
+// The id of the response is the id of the request, verbatim. Notifications
+// (requests without an id) are executed, but their response is empty.
+// Responses are marshaled by the client, whether served locally or by the
+// upstream, so they are formatted the same way, without a trailing newline.
 func (c *Client) CallRaw(body string) string {
 	ctx := context.Background()
-	return c.callRawContext(ctx, json.RawMessage(body))
+	resp, _ := c.CallRawContext(ctx, body) // background context is never done
+	return resp
+}
+
+// CallRawContext performs a JSON-RPC call with already crafted JSON-RPC body and
+// given context. It returns string in JSON format with response (successul or error).
+//
+// JSON-RPC errors returned by the server or a local handler are only reported
+// in the response. Malformed requests and other failures (e.g. upstream being
+// unreachable) are reported in the response and returned as an error. If the
+// context is done before the call has returned, the context's error is returned
+// with an empty response.
+func (c *Client) CallRawContext(ctx context.Context, body string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if err := c.startCall(); err != nil {
+		return newErrorResponse(errCallbackCode, err, defaultMsgID), err
+	}
+	defer c.finishCall()
+
+	resp, err := c.callRawContext(ctx, json.RawMessage(body), false)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", ctxErr
+	}
+
+	return resp, err
+}
+
+// CallMap performs a JSON-RPC call with already crafted JSON-RPC body, like CallRaw,
+// and returns the response parsed into a map, so that its fields (e.g. "result",
+// "error" or "id") can be checked separately. JSON-RPC errors are only reported
+// in the map. An error is returned if the response is not a JSON object, e.g. for
+// a batch or a notification.
+func (c *Client) CallMap(body string) (map[string]interface{}, error) {
+	var resp map[string]interface{}
+	if err := json.Unmarshal([]byte(c.CallRaw(body)), &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// CallBatch performs a batch of JSON-RPC calls given as a JSON array of requests.
+// Each request is routed separately, and responses are returned as a JSON array,
+// in the same order as requests. Notifications have no responses, so the
+// response is empty if the batch consists of notifications only.
+func (c *Client) CallBatch(body string) string {
+	msgs := json.RawMessage(body)
+	if !isBatch(msgs) {
+		return newErrorResponse(errInvalidRequestCode, errBatchExpected, defaultMsgID)
+	}
+
+	if err := c.startCall(); err != nil {
+		return newErrorResponse(errCallbackCode, err, defaultMsgID)
+	}
+	defer c.finishCall()
+
+	resp, _ := c.callBatchMethods(context.Background(), msgs, false) // error is reported in the response
+	return resp
+}
+
+// CallRawLocal performs a JSON-RPC call (or a batch of calls) with already crafted
+// JSON-RPC body, like CallRaw, but always serves it by the local node, even if upstream
+// is enabled. Routing rules, rate limits and the allowed methods list are not applied,
+// so it must only be used by trusted callers. Locally registered handlers are called
+// the same way as by CallRaw.
+func (c *Client) CallRawLocal(body string) string {
+	if err := c.startCall(); err != nil {
+		return newErrorResponse(errCallbackCode, err, defaultMsgID)
+	}
+	defer c.finishCall()
+
+	resp, _ := c.callRawContext(context.Background(), json.RawMessage(body), true) // error is reported in the response
+	return resp
 }
 
 // jsonrpcMessage represents JSON-RPC request, notification, successful response or
@@ -46,8 +156,23 @@ type jsonError struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// Error implements error.
+func (err *jsonError) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("json-rpc error %d", err.Code)
+	}
+	return err.Message
+}
+
+// ErrorCode implements gethrpc.Error.
+func (err *jsonError) ErrorCode() int {
+	return err.Code
+}
+
 // callRawContext performs a JSON-RPC call with already crafted JSON-RPC body and
-// given context. It returns string in JSON format with response (successul or error).
+// given context. It returns string in JSON format with response (successul or error)
+// and an error if the request could not be parsed or sent. If local is set, the call
+// is served by the local node bypassing the router.
 //
 // TODO(divan): this function exists for compatibility and uses default
 // go-ethereum's RPC client under the hood. It adds some unnecessary overhead
@@ -56,89 +181,180 @@ type jsonError struct {
 // This is waste of CPU and memory and should be avoided if possible,
 // either by changing exported API (provide only Call, not CallRaw) or
 // refactoring go-ethereum's client to allow using raw JSON directly.
-func (c *Client) callRawContext(ctx context.Context, body json.RawMessage) string {
+func (c *Client) callRawContext(ctx context.Context, body json.RawMessage, local bool) (string, error) {
 	if isBatch(body) {
-		return c.callBatchMethods(ctx, body)
+		return c.callBatchMethods(ctx, body, local)
 	}
 
-	return c.callSingleMethod(ctx, body)
+	return c.callSingleMethod(ctx, body, local)
 }
 
 // callBatchMethods handles batched JSON-RPC requests, calling each of
 // individual requests one by one and constructing proper batched response.
+// Only failures of the batch itself are returned as an error, errors of
+// individual requests are reported in their responses.
 //
 // See http://www.jsonrpc.org/specification#batch for details.
 //
 // We can't use gethtrpc.BatchCall here, because each call should go through
 // our routing logic and router to corresponding destination.
-func (c *Client) callBatchMethods(ctx context.Context, msgs json.RawMessage) string {
+func (c *Client) callBatchMethods(ctx context.Context, msgs json.RawMessage, local bool) (string, error) {
 	var requests []json.RawMessage
 
 	err := json.Unmarshal(msgs, &requests)
 	if err != nil {
-		return newErrorResponse(errInvalidMessageCode, err, defaultMsgID)
+		return newErrorResponse(errInvalidMessageCode, err, nullMsgID), err
+	}
+	if len(requests) == 0 {
+		return newErrorResponse(errInvalidRequestCode, errEmptyBatch, nullMsgID), errEmptyBatch
 	}
 
 	// run all methods sequentially, this seems to be main
 	// objective to use batched requests.
 	// See: https://github.com/ethereum/wiki/wiki/JavaScript-API#batch-requests
-	responses := make([]json.RawMessage, len(requests))
+	responses := make([]json.RawMessage, 0, len(requests))
 	for i := range requests {
-		resp := c.callSingleMethod(ctx, requests[i])
-		responses[i] = json.RawMessage(resp)
+		resp, _ := c.callSingleMethod(ctx, requests[i], local)
+		if resp == "" { // notification
+			continue
+		}
+		responses = append(responses, json.RawMessage(resp))
+	}
+
+	if len(responses) == 0 {
+		return "", nil
 	}
 
 	data, err := json.Marshal(responses)
 	if err != nil {
 		log.Error("Failed to marshal batch responses:", err)
-		return newErrorResponse(errInvalidMessageCode, err, defaultMsgID)
+		return newErrorResponse(errInvalidMessageCode, err, defaultMsgID), err
 	}
 
-	return string(data)
+	return string(data), nil
 }
 
 // callSingleMethod executes single JSON-RPC message and constructs proper response.
-func (c *Client) callSingleMethod(ctx context.Context, msg json.RawMessage) string {
+// JSON-RPC errors are only reported in the response, other errors are returned as well.
+// The response to a notification is empty.
+func (c *Client) callSingleMethod(ctx context.Context, msg json.RawMessage, local bool) (string, error) {
 	// unmarshal JSON body into json-rpc request
 	method, params, id, err := methodAndParamsFromBody(msg)
 	if err != nil {
-		return newErrorResponse(errInvalidMessageCode, err, id)
+		code := errInvalidMessageCode
+		if er, ok := err.(gethrpc.Error); ok {
+			code = er.ErrorCode()
+		}
+		if id == nil {
+			id = nullMsgID
+		}
+		return newErrorResponse(code, err, id), err
+	}
+
+	started := time.Now()
+	target := c.target(method, local)
+	resp, err := c.execSingleMethod(ctx, method, params, id, local)
+	c.logCall(method, id, msg, target, started, resp)
+
+	if id == nil {
+		return "", err
+	}
+
+	return resp, err
+}
+
+// execSingleMethod executes a JSON-RPC call of the method and constructs proper response.
+func (c *Client) execSingleMethod(ctx context.Context, method string, params []interface{}, id json.RawMessage, local bool) (string, error) {
+	// only raw calls come from dapps, so that only they are restricted
+	if !local && !c.router.isAllowed(method) {
+		err := &methodNotAllowedError{method}
+		c.router.stats.addRejected()
+		c.observeCall(method, time.Now(), err)
+		return newErrorResponse(errMethodNotFoundCode, err, id), nil
 	}
 
 	// route and execute
-	var result json.RawMessage
-	err = c.CallContext(ctx, &result, method, params...)
+	var (
+		result json.RawMessage
+		err    error
+	)
+	handler, isLocalHandler := c.handler(method)
+	switch {
+	case !local:
+		err = c.CallContext(ctx, &result, method, params...)
+	case isLocalHandler:
+		c.router.stats.addLocal()
+		started := time.Now()
+		err = c.callMethod(ctx, &result, handler, params...)
+		c.observeCall(method, started, err)
+	case c.local == nil:
+		err = ErrNoLocalNode
+		c.observeCall(method, time.Now(), err)
+	default:
+		c.router.stats.addLocal()
+		started := time.Now()
+		err = c.local.CallContext(ctx, &result, method, params...)
+		c.observeCall(method, started, err)
+	}
 
 	// as we have to return original JSON, we have to
 	// analyze returned error and reconstruct original
 	// JSON error response.
 	if err != nil && err != gethrpc.ErrNoResult {
 		if er, ok := err.(gethrpc.Error); ok {
-			return newErrorResponse(er.ErrorCode(), err, id)
+			return newErrorResponse(er.ErrorCode(), err, id), nil
 		}
 
-		return newErrorResponse(errInvalidMessageCode, err, id)
+		// errors of local handlers are application errors,
+		// the same way as errors of methods served by the node
+		if isLocalHandler {
+			return newErrorResponse(errCallbackCode, err, id), nil
+		}
+
+		// so is a missing node, which callers may race to start
+		if err == ErrNoLocalNode {
+			return newErrorResponse(errCallbackCode, err, id), err
+		}
+
+		return newErrorResponse(errInvalidMessageCode, err, id), err
 	}
 
 	// finally, marshal answer
-	return newSuccessResponse(result, id)
+	return newSuccessResponse(result, id), nil
 }
 
 // methodAndParamsFromBody extracts Method and Params of
 // JSON-RPC body into values ready to use with ethereum-go's
 // RPC client Call() function. A lot of empty interface usage is
 // due to the underlying code design :/
+//
+// Bodies which are not valid JSON-RPC 2.0 requests are rejected with
+// an invalidRequestError. The id is returned if it is valid, so that
+// the error can be reported with it.
 func methodAndParamsFromBody(body json.RawMessage) (string, []interface{}, json.RawMessage, error) {
 	msg, err := unmarshalMessage(body)
 	if err != nil {
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return "", nil, nil, errNotAnObject
+		}
 		return "", nil, nil, err
 	}
 
+	if !isValidID(msg.ID) {
+		return "", nil, nil, errInvalidID
+	}
+	if msg.Version != jsonrpcVersion {
+		return "", nil, msg.ID, errInvalidVersion
+	}
+	if msg.Method == "" {
+		return "", nil, msg.ID, errMissingMethod
+	}
+
 	params := []interface{}{}
 	if msg.Params != nil {
 		err = json.Unmarshal(msg.Params, &params)
 		if err != nil {
-			return "", nil, nil, err
+			return "", nil, msg.ID, errInvalidParams
 		}
 	}
 
@@ -191,6 +407,26 @@ func newErrorResponse(code int, err error, id json.RawMessage) string {
 	return string(data)
 }
 
+// isValidID returns true if a request id is missing (notification), null,
+// a string or a number, as required by the JSON-RPC 2.0 spec.
+func isValidID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(id, &v); err != nil {
+		return false
+	}
+
+	switch v.(type) {
+	case nil, string, float64:
+		return true
+	default:
+		return false
+	}
+}
+
 // isBatch returns true when the first non-whitespace characters is '['
 // code from go-ethereum's rpc client (rpc/client.go)
 func isBatch(msg json.RawMessage) bool {
@@ -1,10 +1,13 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/stretchr/testify/require"
 )
 
@@ -146,3 +149,325 @@ func TestIsBatch(t *testing.T) {
 		})
 	}
 }
+
+// newLocalTestClient returns Client without local or upstream node,
+// which serves only locally registered handlers.
+func newLocalTestClient() *Client {
+	return &Client{
+		handlers: make(map[string]Handler),
+		router:   newRouter(false),
+	}
+}
+
+func TestCallRawContextCancelled(t *testing.T) {
+	client := newLocalTestClient()
+
+	called := false
+	client.RegisterHandler("eth_sendTransaction", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		called = true
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	type result struct {
+		resp string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := client.CallRawContext(ctx, `{"jsonrpc":"2.0","id":1,"method":"eth_sendTransaction","params":[]}`)
+		done <- result{resp, err}
+	}()
+
+	select {
+	case r := <-done:
+		require.Equal(t, context.Canceled, r.err)
+		require.Empty(t, r.resp)
+	case <-time.After(time.Second):
+		t.Fatal("CallRawContext did not return on cancelled context")
+	}
+	require.False(t, called, "handler should not be called with cancelled context")
+}
+
+func TestCallBatch(t *testing.T) {
+	client := newLocalTestClient()
+
+	for method, result := range map[string]string{
+		"web3_sha3":   "0x47173285a8d7341e5e972fc677286384f802f8ef42a5ec5f03bbfa254cb01fad",
+		"net_version": "4",
+		"shh_version": "5.0",
+	} {
+		result := result
+		client.RegisterHandler(method, func(context.Context, ...interface{}) (interface{}, error) {
+			return result, nil
+		})
+	}
+
+	got := client.CallBatch(`[
+		{"jsonrpc":"2.0","id":3,"method":"web3_sha3","params":["0x68656c6c6f20776f726c64"]},
+		{"jsonrpc":"2.0","id":1,"method":"net_version","params":[]},
+		{"jsonrpc":"2.0","id":2,"method":"shh_version","params":[]}
+	]`)
+
+	expected := `[` +
+		`{"jsonrpc":"2.0","id":3,"result":"0x47173285a8d7341e5e972fc677286384f802f8ef42a5ec5f03bbfa254cb01fad"},` +
+		`{"jsonrpc":"2.0","id":1,"result":"4"},` +
+		`{"jsonrpc":"2.0","id":2,"result":"5.0"}` +
+		`]`
+	require.Equal(t, expected, got)
+
+	got = client.CallBatch(`{"jsonrpc":"2.0","id":1,"method":"net_version","params":[]}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":0,"error":{"code":-32600,"message":"batch request must be a JSON array"}}`, got)
+}
+
+func TestCallRawContextErrors(t *testing.T) {
+	client := newLocalTestClient()
+	client.RegisterHandler("net_version", func(context.Context, ...interface{}) (interface{}, error) {
+		return "4", nil
+	})
+	client.RegisterHandler("eth_sendTransaction", func(context.Context, ...interface{}) (interface{}, error) {
+		return nil, errors.New("transaction has been discarded")
+	})
+
+	ctx := context.Background()
+
+	resp, err := client.CallRawContext(ctx, `{"jsonrpc":"2.0","id":1,"method":"net_version","params":[]}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"4"}`, resp)
+
+	// errors of local handlers are JSON-RPC errors
+	resp, err = client.CallRawContext(ctx, `{"jsonrpc":"2.0","id":1,"method":"eth_sendTransaction","params":[]}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"transaction has been discarded"}}`, resp)
+
+	resp, err = client.CallRawContext(ctx, `{"jsonrpc":"2.0","id":1,"method":`)
+	require.Error(t, err)
+	require.Contains(t, resp, `"code":-32700`)
+
+	resp, err = client.CallRawContext(ctx, `[{"jsonrpc":"2.0","id":1,"method":`)
+	require.Error(t, err)
+	require.Contains(t, resp, `"code":-32700`)
+}
+
+func TestCallRawIDs(t *testing.T) {
+	client := newLocalTestClient()
+	var calls int
+	client.RegisterHandler("net_version", func(context.Context, ...interface{}) (interface{}, error) {
+		calls++
+		return "4", nil
+	})
+
+	// ids are echoed with their type and value
+	for _, id := range []string{`1`, `1.5`, `"1"`, `"abc"`, `null`} {
+		resp := client.CallRaw(`{"jsonrpc":"2.0","id":` + id + `,"method":"net_version","params":[]}`)
+		require.Equal(t, `{"jsonrpc":"2.0","id":`+id+`,"result":"4"}`, resp)
+	}
+	require.Equal(t, 5, calls)
+
+	// notifications are called, but not responded to
+	resp := client.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[]}`)
+	require.Equal(t, "", resp)
+	require.Equal(t, 6, calls)
+
+	resp = client.CallBatch(`[
+		{"jsonrpc":"2.0","method":"net_version","params":[]},
+		{"jsonrpc":"2.0","id":"a","method":"net_version","params":[]}
+	]`)
+	require.Equal(t, `[{"jsonrpc":"2.0","id":"a","result":"4"}]`, resp)
+	require.Equal(t, 8, calls)
+
+	resp = client.CallBatch(`[{"jsonrpc":"2.0","method":"net_version","params":[]}]`)
+	require.Equal(t, "", resp)
+	require.Equal(t, 9, calls)
+
+	// ids of other types are invalid
+	resp, err := client.CallRawContext(context.Background(), `{"jsonrpc":"2.0","id":{},"method":"net_version","params":[]}`)
+	require.Equal(t, errInvalidID, err)
+	require.Equal(t, `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"id must be a string, a number or null"}}`, resp)
+	require.Equal(t, 9, calls)
+}
+
+func TestCallRawMalformedRequests(t *testing.T) {
+	client := newLocalTestClient()
+	client.local = gethrpc.DialInProc(gethrpc.NewServer())
+
+	cases := []struct {
+		name string
+		body string
+		code int
+		id   string
+	}{
+		{"invalid_json", `{"jsonrpc":"2.0","id":1,"method":`, errInvalidMessageCode, `null`},
+		{"invalid_batch_json", `[{"jsonrpc":"2.0","id":1,"method":`, errInvalidMessageCode, `null`},
+		{"not_an_object", `42`, errInvalidRequestCode, `null`},
+		{"wrong_field_type", `{"jsonrpc":"2.0","id":1,"method":42}`, errInvalidRequestCode, `null`},
+		{"missing_version", `{"id":1,"method":"net_version","params":[]}`, errInvalidRequestCode, `1`},
+		{"wrong_version", `{"jsonrpc":"1.0","id":"a","method":"net_version","params":[]}`, errInvalidRequestCode, `"a"`},
+		{"missing_method", `{"jsonrpc":"2.0","id":2,"params":[]}`, errInvalidRequestCode, `2`},
+		{"missing_method_notification", `{"jsonrpc":"2.0","params":[]}`, errInvalidRequestCode, `null`},
+		{"invalid_id", `{"jsonrpc":"2.0","id":[1],"method":"net_version"}`, errInvalidRequestCode, `null`},
+		{"params_not_array", `{"jsonrpc":"2.0","id":3,"method":"net_version","params":{"a":1}}`, errInvalidRequestCode, `3`},
+		{"empty_batch", `[]`, errInvalidRequestCode, `null`},
+		{"unknown_method", `{"jsonrpc":"2.0","id":4,"method":"foo_bar","params":[]}`, errMethodNotFoundCode, `4`},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			resp := client.CallRaw(test.body)
+
+			var msg jsonrpcMessage
+			require.NoError(t, json.Unmarshal([]byte(resp), &msg))
+			require.NotNil(t, msg.Error)
+			require.Equal(t, test.code, msg.Error.Code)
+			require.Equal(t, test.id, string(msg.ID))
+		})
+	}
+
+	// each malformed request of a batch is reported separately
+	resp := client.CallBatch(`[42,{"jsonrpc":"2.0","id":5}]`)
+	require.Equal(t, `[`+
+		`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"request must be a JSON object"}},`+
+		`{"jsonrpc":"2.0","id":5,"error":{"code":-32600,"message":"method is required"}}`+
+		`]`, resp)
+}
+
+func TestCallRawAllowedMethods(t *testing.T) {
+	c := newLocalTestClient()
+	c.RegisterHandler("eth_accounts", func(context.Context, ...interface{}) (interface{}, error) {
+		return []string{}, nil
+	})
+	var signCalls int
+	c.RegisterHandler("personal_sign", func(context.Context, ...interface{}) (interface{}, error) {
+		signCalls++
+		return "0x01", nil
+	})
+
+	c.SetAllowedMethods([]string{"eth_accounts"})
+
+	resp, err := c.CallRawContext(context.Background(), `{"jsonrpc":"2.0","method":"eth_accounts","params":[],"id":1}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":[]}`, resp)
+
+	resp, err = c.CallRawContext(context.Background(), `{"jsonrpc":"2.0","method":"personal_sign","params":[],"id":2}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"the method personal_sign does not exist/is not available"}}`, resp)
+	require.Equal(t, 0, signCalls, "not allowed method should not be called")
+
+	// internal calls are not restricted
+	var result string
+	require.NoError(t, c.CallContext(context.Background(), &result, "personal_sign"))
+	require.Equal(t, "0x01", result)
+	require.Equal(t, 1, signCalls)
+}
+
+func TestCallRawFormatting(t *testing.T) {
+	c, stop := newUpstreamTestClient(t)
+	defer stop()
+
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("shh", TestService{}))
+	c.local = gethrpc.DialInProc(server)
+
+	local := c.CallRaw(`{"jsonrpc":"2.0","method":"shh_version","params":[],"id":67}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":67,"result":"1.0"}`, local)
+
+	upstream := c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":67}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":67,"result":"3"}`, upstream)
+
+	batch := c.CallRaw(`[{"jsonrpc":"2.0","method":"shh_version","params":[],"id":1},{"jsonrpc":"2.0","method":"net_version","params":[],"id":2}]`)
+	require.Equal(t, `[{"jsonrpc":"2.0","id":1,"result":"1.0"},{"jsonrpc":"2.0","id":2,"result":"3"}]`, batch)
+}
+
+func TestCallMap(t *testing.T) {
+	c, stop := newUpstreamTestClient(t)
+	defer stop()
+
+	resp, err := c.CallMap(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":67}`)
+	require.NoError(t, err)
+	require.Equal(t, "3", resp["result"])
+	require.Equal(t, float64(67), resp["id"])
+
+	resp, err = c.CallMap(`{"jsonrpc":"2.0","method":"net_unknown","params":[],"id":"a"}`)
+	require.NoError(t, err)
+	require.Nil(t, resp["result"])
+	require.Contains(t, resp, "error")
+	require.Equal(t, "a", resp["id"])
+
+	_, err = c.CallMap(`[{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}]`)
+	require.Error(t, err)
+}
+
+func TestCallRawLocal(t *testing.T) {
+	c, stop := newUpstreamTestClient(t)
+	defer stop()
+
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("net", TestService{}))
+	c.local = gethrpc.DialInProc(server)
+
+	c.RouteUpstream("net_")
+	c.SetAllowedMethods([]string{"eth_accounts"})
+
+	// local node is called regardless of routing rules and allowed methods
+	resp := c.CallRawLocal(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"1.0"}`, resp)
+
+	resp = c.CallRawLocal(`[{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}]`)
+	require.Equal(t, `[{"jsonrpc":"2.0","id":1,"result":"1.0"}]`, resp)
+
+	c.SetAllowedMethods(nil)
+	resp = c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"3"}`, resp)
+}
+
+func TestOfflineClient(t *testing.T) {
+	client := NewOfflineClient()
+	client.RegisterHandler("status_ping", func(context.Context, ...interface{}) (interface{}, error) {
+		return true, nil
+	})
+
+	resp := client.CallRaw(`{"jsonrpc":"2.0","method":"status_ping","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":true}`, resp)
+
+	resp, err := client.CallRawContext(context.Background(), `{"jsonrpc":"2.0","method":"net_version","params":[],"id":2}`)
+	require.Equal(t, ErrNoLocalNode, err)
+	require.Equal(t, `{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"node not started"}}`, resp)
+
+	require.Equal(t, ErrNoLocalNode, client.Call(nil, "net_version"))
+}
+
+func TestRegisterRawHandler(t *testing.T) {
+	c, stop := newUpstreamTestClient(t)
+	defer stop()
+
+	c.RouteUpstream("status_", "net_")
+	c.RegisterRawHandler("status_echo", func(params json.RawMessage) (interface{}, error) {
+		return params, nil
+	})
+	c.RegisterRawHandler("status_fail", func(json.RawMessage) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+
+	resp := c.CallRaw(`{"jsonrpc":"2.0","method":"status_echo","params":["hello",{"a":1}],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":["hello",{"a":1}]}`, resp)
+
+	resp = c.CallRaw(`{"jsonrpc":"2.0","method":"status_echo","id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":[]}`, resp)
+
+	resp = c.CallRaw(`{"jsonrpc":"2.0","method":"status_fail","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"failed"}}`, resp)
+
+	var result json.RawMessage
+	require.NoError(t, c.Call(&result, "status_echo", 42))
+	require.Equal(t, `[42]`, string(result))
+
+	// registered handlers take precedence over upstream routing
+	c.RegisterRawHandler("net_version", func(json.RawMessage) (interface{}, error) {
+		return "local", nil
+	})
+	resp = c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"local"}`, resp)
+}
@@ -5,18 +5,43 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/status-im/status-go/geth/params"
 
+	gethlog "github.com/ethereum/go-ethereum/log"
 	gethrpc "github.com/ethereum/go-ethereum/rpc"
 )
 
+const errMethodNotFoundCode = -32601 // from go-ethereum/rpc/errors.go
+
+// ErrNoLocalNode is returned for calls which can't be served by an offline client,
+// e.g. before the node is started. Raw calls report it with -32000 error code.
+var ErrNoLocalNode = errors.New("node not started")
+
+// methodNotAllowedError is returned for methods not in the allowed methods list.
+// It implements gethrpc.Error, so it is reported as a JSON-RPC error.
+type methodNotAllowedError struct {
+	method string
+}
+
+func (e *methodNotAllowedError) ErrorCode() int { return errMethodNotFoundCode }
+
+func (e *methodNotAllowedError) Error() string {
+	return fmt.Sprintf("the method %s does not exist/is not available", e.method)
+}
+
 // Handler defines handler for RPC methods.
 type Handler func(context.Context, ...interface{}) (interface{}, error)
 
+// RawHandler defines handler for RPC methods, which gets params
+// of the call as a JSON array.
+type RawHandler func(params json.RawMessage) (interface{}, error)
+
 // Client represents RPC client with custom routing
 // scheme. It automatically decides where RPC call
 // goes - Upstream or Local node.
@@ -25,12 +50,31 @@ type Client struct {
 	upstreamURL     string
 
 	local    *gethrpc.Client
-	upstream *gethrpc.Client
+	upstream *upstreamPool
 
-	router *router
+	router   *router
+	limiter  rateLimiter   // limits calls routed to the upstream
+	retries  retryPolicy   // retries upstream calls failed due to transient errors
+	cache    responseCache // caches results of upstream calls
+	inflight callGroup     // coalesces identical upstream calls in flight
 
 	handlersMx sync.RWMutex       // mx guards handlers
 	handlers   map[string]Handler // locally registered handlers
+
+	subs subscriptions // created with eth_subscribe
+
+	middlewares middlewares // installed with Use
+
+	callsMx  sync.Mutex    // mx guards calls, draining and drained
+	calls    int           // number of raw calls in flight
+	draining bool          // set by Drain, new raw calls are rejected
+	drained  chan struct{} // closed once draining and no calls are in flight
+
+	loggerMx sync.RWMutex   // mx guards logger
+	logger   gethlog.Logger // logs raw calls, if set
+
+	metricsMx sync.RWMutex // mx guards metrics
+	metrics   Metrics      // observes all calls, if set
 }
 
 // NewClient initializes Client and tries to connect to both,
@@ -53,17 +97,46 @@ func NewClient(node *node.Node, upstream params.UpstreamRPCConfig) (*Client, err
 		c.upstreamEnabled = upstream.Enabled
 		c.upstreamURL = upstream.URL
 
-		c.upstream, err = gethrpc.Dial(c.upstreamURL)
+		c.upstream, err = newUpstreamPool(upstream)
 		if err != nil {
 			return nil, fmt.Errorf("dial upstream server: %s", err)
 		}
 	}
 
 	c.router = newRouter(c.upstreamEnabled)
+	c.router.routeLocal(upstream.RouteLocal...)
+	c.router.routeUpstream(upstream.RouteUpstream...)
+	c.router.block(upstream.BlockedMethods...)
+
+	for method, perSecond := range upstream.RateLimits {
+		c.limiter.setRate(method, perSecond)
+	}
+	c.limiter.setDefaultRate(upstream.DefaultRateLimit)
+	if upstream.FailOnRateLimit {
+		c.limiter.setMode(RateLimitFail)
+	}
+	c.retries.set(upstream.RetryCount, time.Duration(upstream.RetryBackoff)*time.Millisecond)
+
+	for method, ttl := range defaultCacheTTLs {
+		c.cache.setTTL(method, ttl)
+	}
+	for method, ttl := range upstream.CacheTTLs {
+		c.cache.setTTL(method, time.Duration(ttl)*time.Second)
+	}
 
 	return c, nil
 }
 
+// NewOfflineClient returns Client without local node and upstream, which serves
+// only locally registered handlers, e.g. while the node is not running.
+// Calls of any other method fail with ErrNoLocalNode.
+func NewOfflineClient() *Client {
+	return &Client{
+		handlers: make(map[string]Handler),
+		router:   newRouter(false),
+	}
+}
+
 // Call performs a JSON-RPC call with the given arguments and unmarshals into
 // result if no error occurred.
 //
@@ -84,17 +157,184 @@ func (c *Client) Call(result interface{}, method string, args ...interface{}) er
 //
 // It uses custom routing scheme for calls.
 func (c *Client) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	started := time.Now()
+	err := c.callChain(ctx, result, method, args...)
+	c.observeCall(method, started, err)
+
+	return err
+}
+
+// callContext performs a JSON-RPC call routed by the custom routing scheme.
+func (c *Client) callContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
 	// check locally registered handlers first
 	if handler, ok := c.handler(method); ok {
+		c.router.stats.addLocal()
 		return c.callMethod(ctx, result, handler, args...)
 	}
 
+	if ok, err := c.callSubscriptionMethod(ctx, result, method, args...); ok {
+		return err
+	}
+
 	if c.router.routeRemote(method) {
-		return c.upstream.CallContext(ctx, result, method, args...)
+		return c.callUpstream(ctx, result, method, args...)
+	}
+	if c.local == nil {
+		return ErrNoLocalNode
 	}
+	c.router.stats.addLocal()
 	return c.local.CallContext(ctx, result, method, args...)
 }
 
+// callUpstream performs a rate limited call to the upstream. Results of methods
+// with a cache TTL are cached, and served from the cache until they expire.
+//
+// Identical concurrent calls of cached or read-only methods share a single
+// upstream call, and all of them receive its response.
+func (c *Client) callUpstream(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	cached, err := c.callUpstreamCached(ctx, result, method, args...)
+	switch {
+	case cached:
+		c.router.stats.addCached()
+	case isRateLimited(err):
+		c.router.stats.addRejected()
+	default:
+		c.router.stats.addUpstream()
+	}
+
+	return err
+}
+
+// callUpstreamCached performs a call to the upstream, see callUpstream.
+// It returns true if the result was served from the cache.
+func (c *Client) callUpstreamCached(ctx context.Context, result interface{}, method string, args ...interface{}) (bool, error) {
+	ttl := c.cache.ttl(method)
+	key, ok := cacheKey(method, args)
+	if !ok || (ttl <= 0 && !coalescable(method)) {
+		if err := c.limiter.wait(ctx, method); err != nil {
+			return false, err
+		}
+
+		return false, c.callUpstreamRetrying(ctx, result, method, args...)
+	}
+
+	if ttl > 0 {
+		if cached, ok := c.cache.get(key); ok {
+			return true, unmarshalResult(cached, result)
+		}
+	}
+
+	raw, err := c.inflight.do(ctx, key, func(ctx context.Context) (json.RawMessage, error) {
+		if err := c.limiter.wait(ctx, method); err != nil {
+			return nil, err
+		}
+
+		var raw json.RawMessage
+		if err := c.callUpstreamRetrying(ctx, &raw, method, args...); err != nil {
+			return nil, err
+		}
+		if ttl > 0 {
+			c.cache.put(method, key, raw, ttl)
+		}
+
+		return raw, nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return false, unmarshalResult(raw, result)
+}
+
+// SetCacheTTL makes results of the method routed to the upstream cached for ttl.
+// Zero or less disables caching of the method. Methods changing the state, like
+// eth_sendRawTransaction, are never cached. By default, only net_version and
+// web3_clientVersion are cached.
+//
+// Like rate limits, TTLs are kept by the client only. Use UpstreamRPCConfig.CacheTTLs
+// to apply them to every client.
+func (c *Client) SetCacheTTL(method string, ttl time.Duration) {
+	c.cache.setTTL(method, ttl)
+}
+
+// RouteLocal makes methods starting with any of given prefixes (e.g. "shh_"
+// or "eth_sign") to be served by the local node, even if upstream is enabled.
+// Local rules take precedence over upstream rules.
+//
+// Rules are kept by the client only, and the client is recreated every time
+// the node starts. Use UpstreamRPCConfig.RouteLocal to apply them to every client.
+func (c *Client) RouteLocal(prefixes ...string) {
+	c.router.routeLocal(prefixes...)
+}
+
+// RouteUpstream makes methods starting with any of given prefixes to be
+// routed to the upstream node, if upstream is enabled.
+// Methods matching no rule are routed by the default methods list.
+// See RouteLocal on how to keep rules across node restarts.
+func (c *Client) RouteUpstream(prefixes ...string) {
+	c.router.routeUpstream(prefixes...)
+}
+
+// BlockMethods makes methods starting with any of given prefixes (e.g. "personal_"
+// or "eth_accounts") to be never routed to the upstream, regardless of routing rules.
+// Calls of them made with CallRaw, CallRawContext and CallBatch are rejected with
+// "method not found" JSON-RPC error, even if they are in the allowed methods list.
+// Call and CallContext, used internally, are served locally.
+//
+// See RouteLocal on how to keep rules across node restarts.
+func (c *Client) BlockMethods(prefixes ...string) {
+	c.router.block(prefixes...)
+}
+
+// SetAllowedMethods restricts calls made with CallRaw, CallRawContext and CallBatch
+// to the given methods. Calls of any other method are rejected with "method not found"
+// JSON-RPC error before reaching local handlers, the local node or the upstream.
+// An empty list allows all methods. Call and CallContext, used internally, are not affected.
+//
+// Like routing rules and rate limits, the list is kept by the client only, and the client
+// is recreated every time the node starts. Use NodeConfig.AllowedRPCMethods to apply it
+// to every client.
+func (c *Client) SetAllowedMethods(methods []string) {
+	c.router.setAllowedMethods(methods)
+}
+
+// SetRateLimit limits calls of the method routed to the upstream to perSecond
+// calls per second. Zero or less removes the limit of the method.
+//
+// Limits are kept by the client only, and the client is recreated every time
+// the node starts. Use UpstreamRPCConfig.RateLimits to apply them to every client.
+func (c *Client) SetRateLimit(method string, perSecond int) {
+	c.limiter.setRate(method, perSecond)
+}
+
+// SetDefaultRateLimit limits calls routed to the upstream of every method
+// without its own limit to perSecond calls per second. Zero or less means no limit.
+func (c *Client) SetDefaultRateLimit(perSecond int) {
+	c.limiter.setDefaultRate(perSecond)
+}
+
+// SetRateLimitMode sets whether calls exceeding their rate limit wait
+// (RateLimitBlock, the default) or fail with a JSON-RPC error (RateLimitFail).
+func (c *Client) SetRateLimitMode(mode RateLimitMode) {
+	c.limiter.setMode(mode)
+}
+
+// SetHTTPClient makes all calls to HTTP upstream endpoints to be sent with
+// a given HTTP client, e.g. to use a custom transport, TLS settings or a proxy.
+// The client replaces the one built from UpstreamRPCConfig, so configured
+// headers and the request timeout are not applied anymore. WebSocket
+// endpoints are not affected. It has no effect if upstream is disabled.
+//
+// Like routing rules, the HTTP client is kept by the client only, and the
+// client is recreated every time the node starts.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	if c.upstream == nil {
+		return
+	}
+
+	c.upstream.setHTTPClient(httpClient)
+}
+
 // RegisterHandler registers local handler for specific RPC method.
 //
 // If method is registered, it will be executed with given handler and
@@ -106,11 +346,35 @@ func (c *Client) RegisterHandler(method string, handler Handler) {
 	c.handlers[method] = handler
 }
 
+// RegisterRawHandler registers local handler for specific RPC method, like
+// RegisterHandler, e.g. to expose app-specific methods to dapps. The handler
+// gets params of the call as a JSON array, so that it can unmarshal them into
+// concrete types.
+//
+// Like routing rules, handlers are kept by the client only, and the client is
+// recreated every time the node starts, so they should be registered on start.
+func (c *Client) RegisterRawHandler(method string, handler RawHandler) {
+	c.RegisterHandler(method, func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		if args == nil {
+			args = []interface{}{}
+		}
+
+		params, err := json.Marshal(args)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(params)
+	})
+}
+
 // callMethod calls registered RPC handler with given args and pointer to result.
 // It handles proper params and result converting
-//
-// TODO(divan): use cancellation via context here?
 func (c *Client) callMethod(ctx context.Context, result interface{}, handler Handler, args ...interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	response, err := handler(ctx, args...)
 	if err != nil {
 		return err
@@ -137,6 +401,15 @@ func (c *Client) handler(method string) (Handler, bool) {
 	return handler, ok
 }
 
+// unmarshalResult unmarshals a raw JSON-RPC result into result, unless it is nil.
+func unmarshalResult(raw json.RawMessage, result interface{}) error {
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(raw, result)
+}
+
 // setResultFromRPCResponse tries to set result value from response using reflection
 // as concrete types are unknown.
 func setResultFromRPCResponse(result, response interface{}) (err error) {
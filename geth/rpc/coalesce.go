@@ -0,0 +1,103 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// readOnlyPrefixes are prefixes of methods which only read the state. Identical
+// calls of them made concurrently are coalesced, unless they are uncacheable.
+var readOnlyPrefixes = []string{
+	"web3_",
+	"net_",
+	"eth_get",
+	"eth_call",
+	"eth_estimateGas",
+	"eth_blockNumber",
+	"eth_gasPrice",
+	"eth_protocolVersion",
+	"eth_syncing",
+}
+
+// coalescable checks if concurrent identical calls of the method may share a single call.
+func coalescable(method string) bool {
+	return !uncacheableMethods[method] && hasPrefix(method, readOnlyPrefixes)
+}
+
+// inflightCall is a call shared by identical calls made while it is in flight.
+type inflightCall struct {
+	done    chan struct{}      // closed once result and err are set
+	cancel  context.CancelFunc // cancels the call once nobody waits for it
+	waiters int                // guarded by callGroup.mx
+	result  json.RawMessage
+	err     error
+}
+
+// callGroup coalesces identical calls in flight, keyed by method and params,
+// so that only the first of them is performed. Zero value is ready to use.
+type callGroup struct {
+	mx    sync.Mutex // mx guards calls
+	calls map[string]*inflightCall
+}
+
+// do performs call, unless a call with the same key is in flight already,
+// in which case its result is awaited and returned instead.
+//
+// The call is performed with its own context, so that it isn't affected by
+// the context of the caller who started it. It's cancelled only when contexts
+// of all callers waiting for it are done.
+func (g *callGroup) do(ctx context.Context, key string, call func(context.Context) (json.RawMessage, error)) (json.RawMessage, error) {
+	g.mx.Lock()
+	c, ok := g.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.Background())
+		c = &inflightCall{done: make(chan struct{}), cancel: cancel}
+		if g.calls == nil {
+			g.calls = make(map[string]*inflightCall)
+		}
+		g.calls[key] = c
+
+		go g.perform(callCtx, key, c, call)
+	}
+	c.waiters++
+	g.mx.Unlock()
+
+	select {
+	case <-c.done:
+		return c.result, c.err
+	case <-ctx.Done():
+		g.leave(key, c)
+		return nil, ctx.Err()
+	}
+}
+
+// perform performs the shared call and wakes up callers waiting for it.
+func (g *callGroup) perform(ctx context.Context, key string, c *inflightCall, call func(context.Context) (json.RawMessage, error)) {
+	c.result, c.err = call(ctx)
+	c.cancel()
+
+	g.mx.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mx.Unlock()
+	close(c.done)
+}
+
+// leave stops waiting for the call. The last caller leaving cancels it,
+// and identical calls made afterwards don't share it anymore.
+func (g *callGroup) leave(key string, c *inflightCall) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	c.cancel()
+}
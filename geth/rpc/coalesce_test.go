@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func newCoalesceTestClient(t *testing.T, calls *int32) (*Client, func()) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("net", NetService{}))
+	require.NoError(t, server.RegisterName("eth", EthService{}))
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		time.Sleep(200 * time.Millisecond) // let concurrent calls pile up
+		server.ServeHTTP(w, r)
+	}))
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{URL: upstream.URL})
+	require.NoError(t, err)
+
+	return &Client{
+		upstreamEnabled: true,
+		upstream:        pool,
+		router:          newRouter(true),
+		handlers:        make(map[string]Handler),
+	}, upstream.Close
+}
+
+func TestCoalesceIdenticalCalls(t *testing.T) {
+	var calls int32
+	c, cleanup := newCoalesceTestClient(t, &calls)
+	defer cleanup()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var version string
+			require.NoError(t, c.Call(&version, "net_version"))
+			require.Equal(t, "3", version)
+		}()
+	}
+	wg.Wait()
+
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls), "identical calls should share one upstream call")
+
+	// once the call is done, the next one goes to the upstream again
+	var version string
+	require.NoError(t, c.Call(&version, "net_version"))
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
+
+func TestCoalesceLeaderCancelled(t *testing.T) {
+	var calls int32
+	c, cleanup := newCoalesceTestClient(t, &calls)
+	defer cleanup()
+
+	// the leader gives up while the shared call is in flight
+	leaderCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	leaderErr := make(chan error, 1)
+	go func() {
+		var version string
+		leaderErr <- c.CallContext(leaderCtx, &version, "net_version")
+	}()
+
+	// wait for the leader to start the shared call
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var version string
+	require.NoError(t, c.Call(&version, "net_version"))
+	require.Equal(t, "3", version)
+
+	require.Equal(t, context.DeadlineExceeded, <-leaderErr)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls), "follower should share the call of the cancelled leader")
+}
+
+func TestCoalesceAllCallersCancelled(t *testing.T) {
+	var calls int32
+	c, cleanup := newCoalesceTestClient(t, &calls)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var version string
+	require.Equal(t, context.DeadlineExceeded, c.CallContext(ctx, &version, "net_version"))
+
+	// the abandoned call is not shared anymore
+	require.NoError(t, c.Call(&version, "net_version"))
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
+
+func TestCoalesceStateChangingCalls(t *testing.T) {
+	var calls int32
+	c, cleanup := newCoalesceTestClient(t, &calls)
+	defer cleanup()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var tx string
+			require.NoError(t, c.Call(&tx, "eth_sendRawTransaction", "0x01"))
+			require.Equal(t, "0x01", tx)
+		}()
+	}
+	wg.Wait()
+
+	require.EqualValues(t, 3, atomic.LoadInt32(&calls), "state changing calls should not be coalesced")
+}
+
+func TestCoalescable(t *testing.T) {
+	require.True(t, coalescable("net_version"))
+	require.True(t, coalescable("eth_getBalance"))
+	require.True(t, coalescable("eth_call"))
+	require.False(t, coalescable("eth_getFilterChanges"))
+	require.False(t, coalescable("eth_sendRawTransaction"))
+	require.False(t, coalescable("personal_unlockAccount"))
+}
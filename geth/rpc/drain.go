@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrClientDraining is returned for calls made after Drain was called.
+var ErrClientDraining = errors.New("RPC client does not accept new calls")
+
+// Drain stops accepting new calls made with CallRaw, CallRawContext and
+// CallBatch, and waits up to timeout for calls in flight to complete.
+// It returns the number of calls still pending when the timeout elapsed.
+//
+// Calls made with Call and CallContext are not affected, so that in-flight
+// calls can complete their internal requests.
+func (c *Client) Drain(timeout time.Duration) int {
+	c.callsMx.Lock()
+	if !c.draining {
+		c.draining = true
+		c.drained = make(chan struct{})
+		if c.calls == 0 {
+			close(c.drained)
+		}
+	}
+	drained := c.drained
+	c.callsMx.Unlock()
+
+	select {
+	case <-drained:
+		return 0
+	case <-time.After(timeout):
+	}
+
+	c.callsMx.Lock()
+	defer c.callsMx.Unlock()
+
+	return c.calls
+}
+
+// startCall registers a call in flight, fails if client is draining.
+func (c *Client) startCall() error {
+	c.callsMx.Lock()
+	defer c.callsMx.Unlock()
+
+	if c.draining {
+		return ErrClientDraining
+	}
+	c.calls++
+
+	return nil
+}
+
+// finishCall unregisters a call in flight.
+func (c *Client) finishCall() {
+	c.callsMx.Lock()
+	defer c.callsMx.Unlock()
+
+	c.calls--
+	if c.draining && c.calls == 0 {
+		close(c.drained)
+	}
+}
@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrain(t *testing.T) {
+	c := newLocalTestClient()
+
+	started := make(chan struct{})
+	c.RegisterHandler("slow_method", func(context.Context, ...interface{}) (interface{}, error) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		return "done", nil
+	})
+
+	finished := make(chan string)
+	go func() {
+		finished <- c.CallRaw(`{"jsonrpc":"2.0","method":"slow_method","params":[],"id":1}`)
+	}()
+	<-started
+
+	require.Zero(t, c.Drain(time.Second))
+
+	select {
+	case resp := <-finished:
+		require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"done"}`, resp)
+	case <-time.After(time.Second):
+		t.Fatal("in-flight call should be finished once drained")
+	}
+
+	resp, err := c.CallRawContext(context.Background(), `{"jsonrpc":"2.0","method":"slow_method","params":[],"id":2}`)
+	require.Equal(t, ErrClientDraining, err)
+	require.Contains(t, resp, `"code":-32000`)
+
+	resp = c.CallBatch(`[{"jsonrpc":"2.0","method":"slow_method","params":[],"id":3}]`)
+	require.Contains(t, resp, `"code":-32000`)
+}
+
+func TestDrainTimeout(t *testing.T) {
+	c := newLocalTestClient()
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	defer close(unblock)
+	c.RegisterHandler("slow_method", func(context.Context, ...interface{}) (interface{}, error) {
+		close(started)
+		<-unblock
+		return nil, nil
+	})
+
+	go c.CallRaw(`{"jsonrpc":"2.0","method":"slow_method","params":[],"id":1}`)
+	<-started
+
+	require.Equal(t, 1, c.Drain(10*time.Millisecond))
+}
@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"encoding/json"
+	"time"
+
+	gethlog "github.com/ethereum/go-ethereum/log"
+)
+
+// call targets reported in logs
+const (
+	targetHandler  = "handler"
+	targetLocal    = "local"
+	targetUpstream = "upstream"
+)
+
+// redactedParams replaces params of sensitive methods in logs.
+const redactedParams = "[redacted]"
+
+// sensitiveMethods are methods whose params carry keys, passwords
+// or data to be signed, and are never logged.
+var sensitiveMethods = map[string]bool{
+	"eth_sign":                 true,
+	"eth_signTransaction":      true,
+	"eth_sendTransaction":      true,
+	"personal_sign":            true,
+	"personal_ecRecover":       true,
+	"personal_importRawKey":    true,
+	"personal_newAccount":      true,
+	"personal_unlockAccount":   true,
+	"personal_sendTransaction": true,
+}
+
+// SetLogger enables logging of raw calls (made with CallRaw, CallRawContext,
+// CallBatch and CallRawLocal) to the given logger. Every call is logged with its
+// method, id, params, latency and whether it was served by a local handler, the
+// local node or the upstream. Params of methods dealing with keys, passwords or
+// signing are redacted. A nil logger disables logging, which is the default.
+func (c *Client) SetLogger(logger gethlog.Logger) {
+	c.loggerMx.Lock()
+	defer c.loggerMx.Unlock()
+
+	c.logger = logger
+}
+
+// target returns where a call of the method is served.
+func (c *Client) target(method string, local bool) string {
+	if _, ok := c.handler(method); ok {
+		return targetHandler
+	}
+	if !local && c.router.routeRemote(method) {
+		return targetUpstream
+	}
+
+	return targetLocal
+}
+
+// logCall logs a raw call of the JSON-RPC message served by target, if logging is enabled.
+func (c *Client) logCall(method string, id, msg json.RawMessage, target string, started time.Time, resp string) {
+	c.loggerMx.RLock()
+	logger := c.logger
+	c.loggerMx.RUnlock()
+
+	if logger == nil {
+		return
+	}
+
+	logParams := redactedParams
+	if !sensitiveMethods[method] {
+		logParams = string(rawParams(msg))
+	}
+
+	logger.Info("RPC call",
+		"method", method,
+		"id", string(id),
+		"target", target,
+		"latency", time.Since(started),
+		"params", logParams,
+		"response", resp)
+}
+
+// rawParams returns params of a JSON-RPC message as they were sent.
+func rawParams(msg json.RawMessage) json.RawMessage {
+	var req struct {
+		Params json.RawMessage `json:"params"`
+	}
+	json.Unmarshal(msg, &req) // nolint: errcheck, msg is known to be valid
+
+	return req.Params
+}
@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gethlog "github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+)
+
+// recordLogger returns a logger collecting records and the collected records.
+func recordLogger() (gethlog.Logger, *[]*gethlog.Record) {
+	var records []*gethlog.Record
+	logger := gethlog.New()
+	logger.SetHandler(gethlog.FuncHandler(func(r *gethlog.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+
+	return logger, &records
+}
+
+// recordCtx returns the record's context as a map.
+func recordCtx(r *gethlog.Record) map[string]interface{} {
+	ctx := make(map[string]interface{})
+	for i := 0; i+1 < len(r.Ctx); i += 2 {
+		ctx[r.Ctx[i].(string)] = r.Ctx[i+1]
+	}
+
+	return ctx
+}
+
+func TestLogRoutedCall(t *testing.T) {
+	c, stop := newUpstreamTestClient(t)
+	defer stop()
+
+	logger, records := recordLogger()
+	c.SetLogger(logger)
+
+	resp := c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"3"}`, resp)
+
+	require.Len(t, *records, 1)
+	ctx := recordCtx((*records)[0])
+	require.Equal(t, "net_version", ctx["method"])
+	require.Equal(t, "1", ctx["id"])
+	require.Equal(t, targetUpstream, ctx["target"])
+	require.Equal(t, "[]", ctx["params"])
+	require.Equal(t, resp, ctx["response"])
+	require.IsType(t, time.Duration(0), ctx["latency"])
+
+	// logging is disabled with nil logger
+	c.SetLogger(nil)
+	c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	require.Len(t, *records, 1)
+}
+
+func TestLogRedactsSensitiveParams(t *testing.T) {
+	c := newLocalTestClient()
+	c.RegisterHandler("eth_sign", func(context.Context, ...interface{}) (interface{}, error) {
+		return "0x01", nil
+	})
+
+	logger, records := recordLogger()
+	c.SetLogger(logger)
+
+	c.CallRaw(`{"jsonrpc":"2.0","method":"eth_sign","params":["0xb60e8dd61c5d32be8058bb8eb970870f07233155","0xdeadbeef"],"id":1}`)
+
+	require.Len(t, *records, 1)
+	ctx := recordCtx((*records)[0])
+	require.Equal(t, "eth_sign", ctx["method"])
+	require.Equal(t, targetHandler, ctx["target"])
+	require.Equal(t, redactedParams, ctx["params"])
+}
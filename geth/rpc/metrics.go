@@ -0,0 +1,34 @@
+package rpc
+
+import (
+	"time"
+)
+
+// Metrics collects statistics of RPC calls, e.g. to back them with expvar or Prometheus.
+type Metrics interface {
+	// ObserveCall is called once a call of the method is finished, with its duration
+	// and the error it failed with, if any. Rejected calls are observed as well.
+	ObserveCall(method string, duration time.Duration, err error)
+}
+
+// SetMetrics makes every call made with the client, including rejected ones, to be
+// observed by m. A nil m disables observing, which is the default.
+func (c *Client) SetMetrics(m Metrics) {
+	c.metricsMx.Lock()
+	defer c.metricsMx.Unlock()
+
+	c.metrics = m
+}
+
+// observeCall passes a finished call to metrics, if set.
+func (c *Client) observeCall(method string, started time.Time, err error) {
+	c.metricsMx.RLock()
+	metrics := c.metrics
+	c.metricsMx.RUnlock()
+
+	if metrics == nil {
+		return
+	}
+
+	metrics.ObserveCall(method, time.Since(started), err)
+}
@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type observedCall struct {
+	method string
+	err    error
+}
+
+// fakeMetrics records observed calls.
+type fakeMetrics struct {
+	mu    sync.Mutex
+	calls []observedCall
+}
+
+func (m *fakeMetrics) ObserveCall(method string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, observedCall{method, err})
+}
+
+func TestMetricsObserveCall(t *testing.T) {
+	c := newLocalTestClient()
+	c.RegisterHandler("net_version", func(context.Context, ...interface{}) (interface{}, error) {
+		return "4", nil
+	})
+	errDiscarded := errors.New("transaction has been discarded")
+	c.RegisterHandler("eth_sendTransaction", func(context.Context, ...interface{}) (interface{}, error) {
+		return nil, errDiscarded
+	})
+
+	metrics := &fakeMetrics{}
+	c.SetMetrics(metrics)
+
+	var result string
+	require.NoError(t, c.Call(&result, "net_version"))
+	require.Error(t, c.Call(nil, "eth_sendTransaction"))
+	c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	c.CallRawLocal(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+
+	// rejected calls are observed as well
+	c.SetAllowedMethods([]string{"net_version"})
+	c.CallRaw(`{"jsonrpc":"2.0","method":"eth_sendTransaction","params":[],"id":1}`)
+
+	require.Len(t, metrics.calls, 5)
+	require.Equal(t, observedCall{"net_version", nil}, metrics.calls[0])
+	require.Equal(t, observedCall{"eth_sendTransaction", errDiscarded}, metrics.calls[1])
+	require.Equal(t, observedCall{"net_version", nil}, metrics.calls[2])
+	require.Equal(t, observedCall{"net_version", nil}, metrics.calls[3])
+	require.Equal(t, "eth_sendTransaction", metrics.calls[4].method)
+	require.IsType(t, &methodNotAllowedError{}, metrics.calls[4].err)
+
+	// observing is disabled with nil metrics
+	c.SetMetrics(nil)
+	require.NoError(t, c.Call(&result, "net_version"))
+	require.Len(t, metrics.calls, 5)
+}
@@ -0,0 +1,54 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+)
+
+// CallHandler performs a JSON-RPC call of the method with given params,
+// setting result, the same way as Client.CallContext does.
+type CallHandler func(ctx context.Context, result interface{}, method string, args ...interface{}) error
+
+// Middleware wraps a CallHandler, e.g. to observe, modify or reject calls.
+// It may call next to pass the call on, or return without calling it to
+// short-circuit the call. Errors implementing gethrpc.Error are reported
+// to raw calls as JSON-RPC errors with their code.
+type Middleware func(next CallHandler) CallHandler
+
+// middlewares keeps middlewares installed with Use.
+type middlewares struct {
+	mx    sync.RWMutex // mx guards list and chain
+	list  []Middleware
+	chain CallHandler // the client's call handler wrapped by all of list, nil if empty
+}
+
+// Use installs a middleware wrapping every call made with Call, CallContext, CallRaw,
+// CallRawContext and CallBatch, before it reaches local handlers, the local node
+// or the upstream. Middlewares are applied in the order they are installed, so
+// the first one sees calls first. Calls rejected by SetAllowedMethods and calls
+// made with CallRawLocal don't pass through middlewares.
+func (c *Client) Use(m Middleware) {
+	c.middlewares.mx.Lock()
+	defer c.middlewares.mx.Unlock()
+
+	c.middlewares.list = append(c.middlewares.list, m)
+
+	chain := CallHandler(c.callContext)
+	for i := len(c.middlewares.list) - 1; i >= 0; i-- {
+		chain = c.middlewares.list[i](chain)
+	}
+	c.middlewares.chain = chain
+}
+
+// callChain performs a call with installed middlewares, if any.
+func (c *Client) callChain(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	c.middlewares.mx.RLock()
+	chain := c.middlewares.chain
+	c.middlewares.mx.RUnlock()
+
+	if chain == nil {
+		return c.callContext(ctx, result, method, args...)
+	}
+
+	return chain(ctx, result, method, args...)
+}
@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// CountingService is an RPC service used by tests, it has to be exported.
+type CountingService struct {
+	calls *int32
+}
+
+func (s CountingService) Version() string {
+	atomic.AddInt32(s.calls, 1)
+	return "1.0"
+}
+
+func (s CountingService) Secret() string {
+	atomic.AddInt32(s.calls, 1)
+	return "secret"
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	var calls int32
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("test", CountingService{&calls}))
+
+	c := newLocalTestClient()
+	c.local = gethrpc.DialInProc(server)
+
+	c.Use(func(next CallHandler) CallHandler {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			if method == "test_secret" {
+				return &methodNotAllowedError{method}
+			}
+			return next(ctx, result, method, args...)
+		}
+	})
+
+	resp := c.CallRaw(`{"jsonrpc":"2.0","method":"test_secret","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the method test_secret does not exist/is not available"}}`, resp)
+
+	var secret string
+	require.Error(t, c.Call(&secret, "test_secret"))
+	require.EqualValues(t, 0, atomic.LoadInt32(&calls), "rejected calls should not reach the node")
+
+	resp = c.CallRaw(`{"jsonrpc":"2.0","method":"test_version","params":[],"id":2}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":2,"result":"1.0"}`, resp)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	c := newLocalTestClient()
+	c.RegisterHandler("test_version", func(context.Context, ...interface{}) (interface{}, error) {
+		return "1.0", nil
+	})
+
+	var order []string
+	record := func(name string) Middleware {
+		return func(next CallHandler) CallHandler {
+			return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				order = append(order, name+" "+method)
+				return next(ctx, result, method, args...)
+			}
+		}
+	}
+	c.Use(record("first"))
+	c.Use(record("second"))
+
+	var version string
+	require.NoError(t, c.Call(&version, "test_version"))
+	require.Equal(t, "1.0", version)
+	require.Equal(t, []string{"first test_version", "second test_version"}, order)
+}
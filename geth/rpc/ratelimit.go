@@ -0,0 +1,198 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const errLimitExceededCode = -32005 // from EIP-1474
+
+// RateLimitMode defines what happens to a call exceeding its rate limit.
+type RateLimitMode int
+
+// rate limit modes
+const (
+	// RateLimitBlock makes the call wait until the limit allows it.
+	RateLimitBlock RateLimitMode = iota
+	// RateLimitFail makes the call fail immediately with a JSON-RPC error.
+	RateLimitFail
+)
+
+// rateLimitedError is returned for calls rejected due to exceeded rate limit.
+// It implements gethrpc.Error, so it is reported as a JSON-RPC error.
+type rateLimitedError struct {
+	method string
+}
+
+func (e *rateLimitedError) ErrorCode() int { return errLimitExceededCode }
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit of method %s exceeded", e.method)
+}
+
+// tokenBucket is a token bucket refilled with rate tokens per second,
+// holding up to rate tokens.
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate int) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// refill adds tokens accumulated since the last refill.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+}
+
+// take takes a token and returns how long to wait until it is available.
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.refill(now)
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// tryTake takes a token only if it is available right now.
+func (b *tokenBucket) tryTake(now time.Time) bool {
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// rateLimiter limits the number of calls per second of every method.
+// Zero value has no limits and is ready to use.
+type rateLimiter struct {
+	mx          sync.Mutex // mx guards all fields
+	mode        RateLimitMode
+	defaultRate int                     // limit of methods not in rates, zero means no limit
+	rates       map[string]int          // per-method limits
+	buckets     map[string]*tokenBucket // lazily created buckets of limited methods
+}
+
+// setRate sets the limit of method calls per second. Zero or less removes
+// the limit, so that the default one is used.
+func (l *rateLimiter) setRate(method string, perSecond int) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if l.rates == nil {
+		l.rates = make(map[string]int)
+	}
+
+	if perSecond > 0 {
+		l.rates[method] = perSecond
+	} else {
+		delete(l.rates, method)
+	}
+	delete(l.buckets, method)
+}
+
+// setDefaultRate sets the limit of calls per second of methods without
+// their own limit. Zero or less means no limit.
+func (l *rateLimiter) setDefaultRate(perSecond int) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.defaultRate = perSecond
+	for method := range l.buckets {
+		if _, ok := l.rates[method]; !ok {
+			delete(l.buckets, method)
+		}
+	}
+}
+
+func (l *rateLimiter) setMode(mode RateLimitMode) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.mode = mode
+}
+
+// wait returns when the call of given method is allowed by its limit.
+// In RateLimitFail mode it returns rateLimitedError instead of waiting.
+func (l *rateLimiter) wait(ctx context.Context, method string) error {
+	l.mx.Lock()
+	bucket := l.bucket(method)
+	if bucket == nil {
+		l.mx.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	if l.mode == RateLimitFail {
+		ok := bucket.tryTake(now)
+		l.mx.Unlock()
+
+		if !ok {
+			return &rateLimitedError{method}
+		}
+		return nil
+	}
+
+	delay := bucket.take(now)
+	l.mx.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isRateLimited returns true if err is returned for a call exceeding its rate limit.
+func isRateLimited(err error) bool {
+	_, ok := err.(*rateLimitedError)
+	return ok
+}
+
+// bucket returns the bucket of given method or nil if method is not limited.
+// It must be called with mx locked.
+func (l *rateLimiter) bucket(method string) *tokenBucket {
+	if bucket, ok := l.buckets[method]; ok {
+		return bucket
+	}
+
+	rate, ok := l.rates[method]
+	if !ok {
+		rate = l.defaultRate
+	}
+	if rate <= 0 {
+		return nil
+	}
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*tokenBucket)
+	}
+	l.buckets[method] = newTokenBucket(rate)
+
+	return l.buckets[method]
+}
@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+// NetService is an RPC service used by tests, it has to be exported.
+type NetService struct{}
+
+func (NetService) Version() string { return "3" }
+
+func newUpstreamTestClient(t *testing.T) (*Client, func()) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("net", NetService{}))
+	upstream := httptest.NewServer(server)
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{URL: upstream.URL})
+	require.NoError(t, err)
+
+	c := &Client{
+		upstreamEnabled: true,
+		upstream:        pool,
+		router:          newRouter(true),
+		handlers:        make(map[string]Handler),
+	}
+
+	return c, upstream.Close
+}
+
+func TestRateLimitBlock(t *testing.T) {
+	c, stop := newUpstreamTestClient(t)
+	defer stop()
+
+	c.SetRateLimit("net_version", 5)
+
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		var version string
+		require.NoError(t, c.Call(&version, "net_version"))
+		require.Equal(t, "3", version)
+	}
+
+	// 5 calls are allowed right away, the others are spaced by 200ms
+	require.True(t, time.Since(start) >= 2900*time.Millisecond, "calls should be spaced out, took %s", time.Since(start))
+}
+
+func TestRateLimitFail(t *testing.T) {
+	c, stop := newUpstreamTestClient(t)
+	defer stop()
+
+	c.SetRateLimitMode(RateLimitFail)
+	c.SetDefaultRateLimit(5)
+
+	var failed int
+	for i := 0; i < 20; i++ {
+		err := c.Call(nil, "net_version")
+		if err != nil {
+			_, ok := err.(gethrpc.Error)
+			require.True(t, ok, "JSON-RPC error expected")
+			failed++
+		}
+	}
+	require.True(t, failed >= 14, "calls exceeding the limit should fail, %d failed", failed)
+
+	resp := c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"rate limit of method net_version exceeded"}}`, resp)
+}
+
+func TestRateLimitContext(t *testing.T) {
+	var l rateLimiter
+	l.setRate("net_version", 1)
+	require.NoError(t, l.wait(context.Background(), "net_version"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, l.wait(ctx, "net_version"))
+
+	require.NoError(t, l.wait(context.Background(), "eth_syncing"), "method without a limit")
+}
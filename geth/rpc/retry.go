@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// defaultRetryBackoff is used if retries are enabled without a backoff.
+const defaultRetryBackoff = 100 * time.Millisecond
+
+// retryPolicy sets how many times upstream calls failed due to a transient error
+// are retried. Zero value disables retries and is ready to use.
+type retryPolicy struct {
+	mx      sync.RWMutex // mx guards all fields
+	count   int
+	backoff time.Duration // doubled after every retry
+}
+
+// set sets the number of retries and the backoff before the first one.
+func (r *retryPolicy) set(count int, backoff time.Duration) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	r.count = count
+	r.backoff = backoff
+}
+
+func (r *retryPolicy) get() (int, time.Duration) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	return r.count, r.backoff
+}
+
+// retryable checks if a failed call of the method may be safely repeated.
+// Only calls of read-only methods are, the same ones which are coalesced.
+func retryable(method string) bool {
+	return coalescable(method)
+}
+
+// SetRetries makes calls of read-only methods (e.g. net_version, but not
+// eth_sendRawTransaction) routed to the upstream to be retried up to count
+// times if the upstream is unavailable, e.g. the connection is reset or it
+// responds with 5xx status. The backoff before the first retry is doubled
+// after every retry. Zero count disables retries, which is the default.
+//
+// Like rate limits, retries are set for the client only. Use UpstreamRPCConfig.RetryCount
+// and UpstreamRPCConfig.RetryBackoff to apply them to every client.
+func (c *Client) SetRetries(count int, backoff time.Duration) {
+	c.retries.set(count, backoff)
+}
+
+// callUpstreamRetrying performs a call to the upstream, retrying it with
+// an exponential backoff if it's retryable and failed due to a transient error.
+func (c *Client) callUpstreamRetrying(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	count, backoff := c.retries.get()
+	if count <= 0 || !retryable(method) {
+		return c.upstream.CallContext(ctx, result, method, args...)
+	}
+
+	// a failed call might have partially unmarshaled its result
+	var raw json.RawMessage
+	for i := 0; ; i++ {
+		err := c.upstream.CallContext(ctx, &raw, method, args...)
+		if i == count || !isUnavailable(ctx, err) {
+			if err != nil {
+				return err
+			}
+			return unmarshalResult(raw, result)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryTransientErrors(t *testing.T) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("net", NetService{}))
+	require.NoError(t, server.RegisterName("eth", EthService{}))
+
+	var calls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// every third call succeeds
+		if atomic.AddInt32(&calls, 1)%3 != 0 {
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer upstream.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{URL: upstream.URL})
+	require.NoError(t, err)
+
+	c := &Client{
+		upstreamEnabled: true,
+		upstream:        pool,
+		router:          newRouter(true),
+		handlers:        make(map[string]Handler),
+	}
+	c.SetRetries(3, 10*time.Millisecond)
+
+	resp := c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"3"}`, resp)
+	require.EqualValues(t, 3, atomic.LoadInt32(&calls))
+
+	// sending transactions is not idempotent, so it's never retried
+	resp = c.CallRaw(`{"jsonrpc":"2.0","method":"eth_sendRawTransaction","params":["0x01"],"id":1}`)
+	require.Contains(t, resp, `"error"`)
+	require.EqualValues(t, 4, atomic.LoadInt32(&calls))
+
+	// retries are given up after count
+	c.SetRetries(1, 10*time.Millisecond)
+	atomic.StoreInt32(&calls, 0)
+	resp = c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	require.Contains(t, resp, `"error"`)
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
@@ -1,11 +1,24 @@
 package rpc
 
+import (
+	"strings"
+	"sync"
+)
+
 // router implements logic for routing
 // JSON-RPC requests either to Upstream or
 // Local node.
 type router struct {
+	stats routeStats // first, so that its counters are 64-bit aligned for atomic access
+
 	methods         map[string]bool
 	upstreamEnabled bool
+
+	rulesMx         sync.RWMutex    // guards localPrefixes, remotePrefixes, blockedPrefixes and allowed
+	localPrefixes   []string        // methods forced to the local node
+	remotePrefixes  []string        // methods forced to the upstream node
+	blockedPrefixes []string        // methods never routed to the upstream nor called by dapps
+	allowed         map[string]bool // permitted methods, empty means all
 }
 
 // newRouter inits new router.
@@ -22,16 +35,87 @@ func newRouter(upstreamEnabled bool) *router {
 	return r
 }
 
+// routeLocal adds rules routing methods with given prefixes to the local node.
+func (r *router) routeLocal(prefixes ...string) {
+	r.rulesMx.Lock()
+	defer r.rulesMx.Unlock()
+
+	r.localPrefixes = append(r.localPrefixes, prefixes...)
+}
+
+// routeUpstream adds rules routing methods with given prefixes to the upstream node.
+func (r *router) routeUpstream(prefixes ...string) {
+	r.rulesMx.Lock()
+	defer r.rulesMx.Unlock()
+
+	r.remotePrefixes = append(r.remotePrefixes, prefixes...)
+}
+
+// block adds rules blocking methods with given prefixes.
+func (r *router) block(prefixes ...string) {
+	r.rulesMx.Lock()
+	defer r.rulesMx.Unlock()
+
+	r.blockedPrefixes = append(r.blockedPrefixes, prefixes...)
+}
+
+// setAllowedMethods replaces the list of permitted methods.
+// An empty list permits all methods.
+func (r *router) setAllowedMethods(methods []string) {
+	r.rulesMx.Lock()
+	defer r.rulesMx.Unlock()
+
+	r.allowed = make(map[string]bool, len(methods))
+	for _, m := range methods {
+		r.allowed[m] = true
+	}
+}
+
+// isAllowed returns true if given method is permitted to be called.
+// Blocked methods are never permitted, even if they are allowed.
+func (r *router) isAllowed(method string) bool {
+	r.rulesMx.RLock()
+	defer r.rulesMx.RUnlock()
+
+	if hasPrefix(method, r.blockedPrefixes) {
+		return false
+	}
+
+	return len(r.allowed) == 0 || r.allowed[method]
+}
+
 // routeRemote returns true if given method should be routed to the remote node
 func (r *router) routeRemote(method string) bool {
 	if !r.upstreamEnabled {
 		return false
 	}
 
+	r.rulesMx.RLock()
+	defer r.rulesMx.RUnlock()
+
+	// registered rules take precedence, blocking and local ones first
+	if hasPrefix(method, r.blockedPrefixes) || hasPrefix(method, r.localPrefixes) {
+		return false
+	}
+	if hasPrefix(method, r.remotePrefixes) {
+		return true
+	}
+
 	// else check route using the methods list
 	return r.methods[method]
 }
 
+// hasPrefix returns true if method starts with any of given prefixes.
+func hasPrefix(method string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // remoteMethods contains methods that should be routed to
 // the upstream node; the rest is considered to be routed to
 // the local node.
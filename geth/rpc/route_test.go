@@ -34,3 +34,59 @@ func TestRouteWithoutUpstream(t *testing.T) {
 		require.False(t, router.routeRemote(method), "method "+method+" should routed to local")
 	}
 }
+
+func TestRouteRules(t *testing.T) {
+	router := newRouter(true)
+	router.routeLocal("shh_", "net_version")
+	router.routeUpstream("eth_", "some_")
+
+	require.False(t, router.routeRemote("shh_version"))
+	require.False(t, router.routeRemote("net_version"))
+	require.True(t, router.routeRemote("net_peerCount"), "default route should be used")
+	require.True(t, router.routeRemote("eth_accounts"))
+	require.True(t, router.routeRemote("some_weirdo_method"))
+	require.False(t, router.routeRemote("other_method"), "default route should be used")
+
+	router.routeLocal("eth_sign")
+	require.False(t, router.routeRemote("eth_sign"), "local rules should take precedence")
+
+	router = newRouter(false)
+	router.routeUpstream("eth_")
+	require.False(t, router.routeRemote("eth_accounts"), "upstream is disabled")
+}
+
+func TestBlockedMethods(t *testing.T) {
+	router := newRouter(true)
+	router.routeUpstream("eth_", "personal_")
+	router.setAllowedMethods([]string{"eth_accounts", "net_version"})
+	router.block("eth_accounts", "personal_")
+
+	require.False(t, router.routeRemote("eth_accounts"), "blocking rules should take precedence")
+	require.False(t, router.routeRemote("personal_sign"))
+	require.True(t, router.routeRemote("eth_getBalance"))
+	require.False(t, router.isAllowed("eth_accounts"), "blocking rules should take precedence")
+	require.True(t, router.isAllowed("net_version"))
+
+	c, stop := newUpstreamTestClient(t)
+	defer stop()
+	c.BlockMethods("eth_accounts")
+
+	resp := c.CallRaw(`{"jsonrpc":"2.0","method":"eth_accounts","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the method eth_accounts does not exist/is not available"}}`, resp)
+
+	resp = c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"3"}`, resp)
+}
+
+func TestAllowedMethods(t *testing.T) {
+	router := newRouter(false)
+	require.True(t, router.isAllowed("eth_accounts"), "all methods should be allowed by default")
+
+	router.setAllowedMethods([]string{"eth_accounts", "shh_version"})
+	require.True(t, router.isAllowed("eth_accounts"))
+	require.True(t, router.isAllowed("shh_version"))
+	require.False(t, router.isAllowed("personal_sign"))
+
+	router.setAllowedMethods(nil)
+	require.True(t, router.isAllowed("personal_sign"))
+}
@@ -0,0 +1,49 @@
+package rpc
+
+import (
+	"sync/atomic"
+)
+
+// RouteStats are numbers of calls made with the client by where they were served,
+// counted since the client was created or its stats were reset.
+type RouteStats struct {
+	Local    uint64 // served by locally registered handlers or the local node
+	Upstream uint64 // served by the upstream
+	Cached   uint64 // served from cached results of upstream calls
+	Rejected uint64 // not allowed or exceeding their rate limit
+}
+
+// routeStats counts routed calls, it is updated atomically.
+type routeStats struct {
+	local    uint64
+	upstream uint64
+	cached   uint64
+	rejected uint64
+}
+
+func (s *routeStats) addLocal()    { atomic.AddUint64(&s.local, 1) }
+func (s *routeStats) addUpstream() { atomic.AddUint64(&s.upstream, 1) }
+func (s *routeStats) addCached()   { atomic.AddUint64(&s.cached, 1) }
+func (s *routeStats) addRejected() { atomic.AddUint64(&s.rejected, 1) }
+
+// RouteStats returns numbers of calls served locally, by the upstream and
+// from the cache, and of rejected calls, e.g. to see where the traffic goes.
+// Each counter is read atomically, but not all of them at once.
+func (c *Client) RouteStats() RouteStats {
+	s := &c.router.stats
+	return RouteStats{
+		Local:    atomic.LoadUint64(&s.local),
+		Upstream: atomic.LoadUint64(&s.upstream),
+		Cached:   atomic.LoadUint64(&s.cached),
+		Rejected: atomic.LoadUint64(&s.rejected),
+	}
+}
+
+// ResetRouteStats sets all the numbers returned by RouteStats to zero.
+func (c *Client) ResetRouteStats() {
+	s := &c.router.stats
+	atomic.StoreUint64(&s.local, 0)
+	atomic.StoreUint64(&s.upstream, 0)
+	atomic.StoreUint64(&s.cached, 0)
+	atomic.StoreUint64(&s.rejected, 0)
+}
@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteStats(t *testing.T) {
+	c, stop := newUpstreamTestClient(t)
+	defer stop()
+
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("shh", TestService{}))
+	c.local = gethrpc.DialInProc(server)
+
+	c.RegisterHandler("eth_accounts", func(context.Context, ...interface{}) (interface{}, error) {
+		return []string{}, nil
+	})
+	c.SetCacheTTL("net_version", time.Minute)
+	c.SetRateLimit("net_listening", 1)
+	c.SetRateLimitMode(RateLimitFail)
+
+	for i := 0; i < 2; i++ {
+		resp := c.CallRaw(`{"jsonrpc":"2.0","method":"shh_version","params":[],"id":1}`)
+		require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"1.0"}`, resp)
+	}
+	require.NoError(t, c.Call(nil, "eth_accounts"))
+
+	// the second call is served from the cache
+	for i := 0; i < 2; i++ {
+		resp := c.CallRaw(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`)
+		require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"3"}`, resp)
+	}
+
+	// the first call is let through and fails upstream, the second exceeds the limit
+	for i := 0; i < 2; i++ {
+		c.CallRaw(`{"jsonrpc":"2.0","method":"net_listening","params":[],"id":1}`)
+	}
+
+	c.SetAllowedMethods([]string{"shh_version"})
+	c.CallRaw(`{"jsonrpc":"2.0","method":"eth_accounts","params":[],"id":1}`)
+
+	require.Equal(t, RouteStats{Local: 3, Upstream: 2, Cached: 1, Rejected: 2}, c.RouteStats())
+
+	c.ResetRouteStats()
+	require.Equal(t, RouteStats{}, c.RouteStats())
+}
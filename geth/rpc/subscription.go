@@ -0,0 +1,314 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// resubscribeTimeout limits a single attempt to reestablish a dropped subscription.
+const resubscribeTimeout = 10 * time.Second
+
+// errors
+var (
+	ErrSubscriptionNotFound     error = subscriptionError("subscription not found")
+	ErrNotificationsUnsupported error = subscriptionError("notifications not supported, no notification handler is set")
+	ErrUpstreamNoSubscriptions  error = subscriptionError("upstream does not support subscriptions, a WebSocket upstream URL is required")
+)
+
+// subscriptionError is returned for failed subscription calls.
+// It implements gethrpc.Error, so it is reported as a JSON-RPC error.
+type subscriptionError string
+
+func (e subscriptionError) ErrorCode() int { return errCallbackCode }
+
+func (e subscriptionError) Error() string { return string(e) }
+
+// NotificationHandler is called with every notification of a subscription
+// created with a raw eth_subscribe call.
+type NotificationHandler func(subscriptionID string, result json.RawMessage)
+
+// subscriber is a client supporting eth_subscribe subscriptions.
+type subscriber interface {
+	EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (*gethrpc.ClientSubscription, error)
+}
+
+// ConnectionState describes the connection subscriptions are served over.
+type ConnectionState int
+
+// connection states
+const (
+	ConnectionConnected    ConnectionState = iota
+	ConnectionReconnecting                 // some subscriptions are dropped and being reestablished
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case ConnectionConnected:
+		return "connected"
+	case ConnectionReconnecting:
+		return "reconnecting"
+	}
+
+	return "unknown"
+}
+
+// subscriptions keeps subscriptions created by the client.
+type subscriptions struct {
+	mx      sync.Mutex // mx guards subs, handler and sub of every subscription
+	subs    map[string]*subscription
+	handler NotificationHandler
+	lastID  uint64 // accessed atomically
+
+	reconnecting int32 // number of subscriptions being reestablished, accessed atomically
+
+	// backoff of attempts to reestablish a dropped subscription,
+	// upstreamMinBackoff and upstreamMaxBackoff if zero
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// subscription is a subscription created by the client. If its connection drops,
+// it is created again with the same args and keeps its id.
+type subscription struct {
+	args []interface{}
+	sub  *gethrpc.ClientSubscription
+}
+
+// nextBackoff doubles a backoff between attempts to reestablish a subscription.
+func (s *subscriptions) nextBackoff(backoff time.Duration) time.Duration {
+	min, max := s.minBackoff, s.maxBackoff
+	if min == 0 {
+		min = upstreamMinBackoff
+	}
+	if max == 0 {
+		max = upstreamMaxBackoff
+	}
+
+	backoff *= 2
+	if backoff < min {
+		backoff = min
+	}
+	if backoff > max {
+		backoff = max
+	}
+
+	return backoff
+}
+
+// SetNotificationHandler sets the handler receiving notifications of subscriptions
+// created with raw eth_subscribe calls (e.g. coming from dapps). Without a handler,
+// such calls fail.
+func (c *Client) SetNotificationHandler(handler NotificationHandler) {
+	c.subs.mx.Lock()
+	defer c.subs.mx.Unlock()
+
+	c.subs.handler = handler
+}
+
+// Subscribe creates a subscription with eth_subscribe with given args (e.g. "newHeads")
+// and calls handler with result of every notification until it is unsubscribed.
+// The subscription is created on the local node or on the upstream, the same way
+// as eth_subscribe calls are routed. Only WebSocket upstreams support subscriptions.
+//
+// It returns the subscription id, to be passed to Unsubscribe.
+func (c *Client) Subscribe(ctx context.Context, handler func(result json.RawMessage), args ...interface{}) (string, error) {
+	return c.subscribe(ctx, func(_ string, result json.RawMessage) {
+		handler(result)
+	}, args...)
+}
+
+// ConnectionState returns the state of the connection subscriptions are served over.
+// It is ConnectionReconnecting while any dropped subscription is being reestablished.
+func (c *Client) ConnectionState() ConnectionState {
+	if atomic.LoadInt32(&c.subs.reconnecting) > 0 {
+		return ConnectionReconnecting
+	}
+
+	return ConnectionConnected
+}
+
+// subscribe creates a subscription and passes its notifications to handler.
+func (c *Client) subscribe(ctx context.Context, handler NotificationHandler, args ...interface{}) (string, error) {
+	ch := make(chan json.RawMessage)
+	sub, err := c.ethSubscribe(ctx, ch, args...)
+	if err != nil {
+		return "", err
+	}
+
+	id := hexutil.EncodeUint64(atomic.AddUint64(&c.subs.lastID, 1))
+	s := &subscription{args: args, sub: sub}
+
+	c.subs.mx.Lock()
+	if c.subs.subs == nil {
+		c.subs.subs = make(map[string]*subscription)
+	}
+	c.subs.subs[id] = s
+	c.subs.mx.Unlock()
+
+	go c.relay(id, s, sub, ch, handler)
+
+	return id, nil
+}
+
+// ethSubscribe creates a subscription with eth_subscribe, sending its notifications to ch.
+func (c *Client) ethSubscribe(ctx context.Context, ch chan<- json.RawMessage, args ...interface{}) (*gethrpc.ClientSubscription, error) {
+	s, err := c.subscriber()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.EthSubscribe(ctx, ch, args...)
+}
+
+// Unsubscribe tears down the subscription with given id.
+func (c *Client) Unsubscribe(id string) error {
+	c.subs.mx.Lock()
+	s, ok := c.subs.subs[id]
+	delete(c.subs.subs, id)
+	c.subs.mx.Unlock()
+
+	if !ok {
+		return ErrSubscriptionNotFound
+	}
+
+	s.sub.Unsubscribe()
+	return nil
+}
+
+// subscriber returns the client eth_subscribe calls are routed to.
+func (c *Client) subscriber() (subscriber, error) {
+	if !c.router.routeRemote("eth_subscribe") {
+		if c.local == nil {
+			return nil, ErrNoLocalNode
+		}
+		return c.local, nil
+	}
+
+	if s, ok := c.upstream.subscriber(); ok {
+		return s, nil
+	}
+
+	return nil, ErrUpstreamNoSubscriptions
+}
+
+// relay passes notifications of the subscription to the handler until it is
+// unsubscribed. If the connection of the subscription drops, it is reestablished.
+func (c *Client) relay(id string, s *subscription, sub *gethrpc.ClientSubscription, ch chan json.RawMessage, handler NotificationHandler) {
+	for {
+		select {
+		case result := <-ch:
+			handler(id, result)
+		case err := <-sub.Err():
+			// the error is nil if the subscription is unsubscribed or the client is closed
+			if err == nil {
+				c.subs.mx.Lock()
+				if c.subs.subs[id] == s {
+					delete(c.subs.subs, id)
+				}
+				c.subs.mx.Unlock()
+				return
+			}
+
+			log.Warn("Subscription dropped, resubscribing", "id", id, "error", err)
+
+			var ok bool
+			if sub, ok = c.resubscribe(id, s, ch); !ok {
+				return
+			}
+		}
+	}
+}
+
+// resubscribe reestablishes a dropped subscription, retrying with an exponentially
+// growing backoff until it succeeds. It returns false if the subscription is
+// unsubscribed in the meantime.
+func (c *Client) resubscribe(id string, s *subscription, ch chan json.RawMessage) (*gethrpc.ClientSubscription, bool) {
+	atomic.AddInt32(&c.subs.reconnecting, 1)
+	defer atomic.AddInt32(&c.subs.reconnecting, -1)
+
+	var backoff time.Duration
+	for c.isSubscribed(id, s) {
+		ctx, cancel := context.WithTimeout(context.Background(), resubscribeTimeout)
+		sub, err := c.ethSubscribe(ctx, ch, s.args...)
+		cancel()
+
+		if err == nil {
+			c.subs.mx.Lock()
+			defer c.subs.mx.Unlock()
+
+			if c.subs.subs[id] != s {
+				sub.Unsubscribe()
+				return nil, false
+			}
+			s.sub = sub
+
+			log.Info("Subscription reestablished", "id", id)
+			return sub, true
+		}
+
+		backoff = c.subs.nextBackoff(backoff)
+		log.Warn("Failed to resubscribe", "id", id, "error", err, "retry", backoff)
+		time.Sleep(backoff)
+	}
+
+	return nil, false
+}
+
+// isSubscribed checks if a given subscription is still registered under its id.
+func (c *Client) isSubscribed(id string, s *subscription) bool {
+	c.subs.mx.Lock()
+	defer c.subs.mx.Unlock()
+
+	return c.subs.subs[id] == s
+}
+
+// callSubscriptionMethod serves raw eth_subscribe and eth_unsubscribe calls.
+// It returns false if the method is neither of them.
+func (c *Client) callSubscriptionMethod(ctx context.Context, result interface{}, method string, args ...interface{}) (bool, error) {
+	switch method {
+	case "eth_subscribe":
+		c.subs.mx.Lock()
+		handler := c.subs.handler
+		c.subs.mx.Unlock()
+
+		if handler == nil {
+			return true, ErrNotificationsUnsupported
+		}
+
+		id, err := c.subscribe(ctx, handler, args...)
+		if err != nil {
+			return true, err
+		}
+
+		return true, setResult(result, id)
+	case "eth_unsubscribe":
+		if len(args) != 1 {
+			return true, ErrSubscriptionNotFound
+		}
+
+		id, _ := args[0].(string)
+		if err := c.Unsubscribe(id); err != nil {
+			return true, err
+		}
+
+		return true, setResult(result, true)
+	}
+
+	return false, nil
+}
+
+// setResult sets result of a call served by the client itself, unless result is nil.
+func setResult(result, response interface{}) error {
+	if result == nil {
+		return nil
+	}
+
+	return setResultFromRPCResponse(result, response)
+}
@@ -0,0 +1,191 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+// HeadsService is an RPC service used by tests, it has to be exported.
+type HeadsService struct{}
+
+// NewHeads emits two heads notifications.
+func (HeadsService) NewHeads(ctx context.Context) (*gethrpc.Subscription, error) {
+	notifier, ok := gethrpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, gethrpc.ErrNotificationsUnsupported
+	}
+
+	sub := notifier.CreateSubscription()
+	go func() {
+		time.Sleep(10 * time.Millisecond) // let the subscription be activated
+		for i := 1; i <= 2; i++ {
+			notifier.Notify(sub.ID, map[string]int{"number": i}) // nolint: errcheck
+		}
+	}()
+
+	return sub, nil
+}
+
+func newSubscriptionTestClient(t *testing.T) *Client {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", HeadsService{}))
+
+	c := newLocalTestClient()
+	c.local = gethrpc.DialInProc(server)
+
+	return c
+}
+
+func receiveNotification(t *testing.T, received <-chan string) string {
+	select {
+	case result := <-received:
+		return result
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a notification")
+	}
+	return ""
+}
+
+func TestSubscribe(t *testing.T) {
+	c := newSubscriptionTestClient(t)
+
+	received := make(chan string, 2)
+	id, err := c.Subscribe(context.Background(), func(result json.RawMessage) {
+		received <- string(result)
+	}, "newHeads")
+	require.NoError(t, err)
+
+	require.Equal(t, `{"number":1}`, receiveNotification(t, received))
+	require.Equal(t, `{"number":2}`, receiveNotification(t, received))
+
+	require.NoError(t, c.Unsubscribe(id))
+	require.Equal(t, ErrSubscriptionNotFound, c.Unsubscribe(id))
+}
+
+func TestSubscribeRaw(t *testing.T) {
+	c := newSubscriptionTestClient(t)
+
+	resp := c.CallRaw(`{"jsonrpc":"2.0","method":"eth_subscribe","params":["newHeads"],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"notifications not supported, no notification handler is set"}}`, resp)
+
+	received := make(chan string, 2)
+	c.SetNotificationHandler(func(id string, result json.RawMessage) {
+		received <- id + " " + string(result)
+	})
+
+	resp = c.CallRaw(`{"jsonrpc":"2.0","method":"eth_subscribe","params":["newHeads"],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x1"}`, resp)
+
+	require.Equal(t, `0x1 {"number":1}`, receiveNotification(t, received))
+	require.Equal(t, `0x1 {"number":2}`, receiveNotification(t, received))
+
+	resp = c.CallRaw(`{"jsonrpc":"2.0","method":"eth_unsubscribe","params":["0x1"],"id":2}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":2,"result":true}`, resp)
+
+	resp = c.CallRaw(`{"jsonrpc":"2.0","method":"eth_unsubscribe","params":["0x1"],"id":3}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":3,"error":{"code":-32000,"message":"subscription not found"}}`, resp)
+}
+
+// dropListener is a net.Listener which can drop accepted connections and refuse new ones.
+type dropListener struct {
+	net.Listener
+
+	mx     sync.Mutex
+	conns  []net.Conn
+	refuse bool
+}
+
+func (l *dropListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		l.mx.Lock()
+		if l.refuse {
+			l.mx.Unlock()
+			conn.Close() // nolint: errcheck
+			continue
+		}
+		l.conns = append(l.conns, conn)
+		l.mx.Unlock()
+
+		return conn, nil
+	}
+}
+
+// drop closes accepted connections, new connections are refused if refuse is set.
+func (l *dropListener) drop(refuse bool) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.refuse = refuse
+	for _, conn := range l.conns {
+		conn.Close() // nolint: errcheck
+	}
+	l.conns = nil
+}
+
+func TestSubscribeReconnect(t *testing.T) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", HeadsService{}))
+
+	upstream := httptest.NewUnstartedServer(server.WebsocketHandler([]string{"*"}))
+	listener := &dropListener{Listener: upstream.Listener}
+	upstream.Listener = listener
+	upstream.Start()
+	defer upstream.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{
+		URL: "ws://" + strings.TrimPrefix(upstream.URL, "http://"),
+	})
+	require.NoError(t, err)
+
+	c := newLocalTestClient()
+	c.upstreamEnabled = true
+	c.upstream = pool
+	c.router = newRouter(true)
+	c.RouteUpstream("eth_subscribe")
+	c.subs.minBackoff = 10 * time.Millisecond
+	c.subs.maxBackoff = 50 * time.Millisecond
+
+	received := make(chan string, 4)
+	c.SetNotificationHandler(func(id string, result json.RawMessage) {
+		received <- id + " " + string(result)
+	})
+
+	resp := c.CallRaw(`{"jsonrpc":"2.0","method":"eth_subscribe","params":["newHeads"],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x1"}`, resp)
+	require.Equal(t, `0x1 {"number":1}`, receiveNotification(t, received))
+	require.Equal(t, `0x1 {"number":2}`, receiveNotification(t, received))
+	require.Equal(t, ConnectionConnected, c.ConnectionState())
+
+	// the upstream drops the connection and is unavailable for a while
+	listener.drop(true)
+
+	deadline := time.Now().Add(time.Second)
+	for c.ConnectionState() != ConnectionReconnecting {
+		require.True(t, time.Now().Before(deadline), "timed out waiting for reconnecting state")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// once the upstream is back, the subscription is recreated under the same id
+	listener.drop(false)
+
+	require.Equal(t, `0x1 {"number":1}`, receiveNotification(t, received))
+	require.Equal(t, `0x1 {"number":2}`, receiveNotification(t, received))
+	require.Equal(t, ConnectionConnected, c.ConnectionState())
+
+	require.NoError(t, c.Unsubscribe("0x1"))
+}
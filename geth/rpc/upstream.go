@@ -0,0 +1,301 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/params"
+)
+
+const (
+	// upstreamMinBackoff is a period a failed upstream endpoint is skipped for.
+	upstreamMinBackoff = time.Second
+	// upstreamMaxBackoff is a limit of the period doubled on every consecutive failure.
+	upstreamMaxBackoff = time.Minute
+	// defaultUpstreamRequestTimeout is used if the upstream config has no request timeout.
+	defaultUpstreamRequestTimeout = 30 * time.Second
+)
+
+// upstreamTimeoutError is returned for upstream calls exceeding the request timeout.
+// It implements gethrpc.Error, so it is reported as a JSON-RPC error.
+type upstreamTimeoutError struct{}
+
+func (e *upstreamTimeoutError) ErrorCode() int { return errCallbackCode }
+
+func (e *upstreamTimeoutError) Error() string {
+	return "upstream request timed out"
+}
+
+// upstreamEndpoint is a connection to a single upstream server.
+type upstreamEndpoint struct {
+	url     string
+	client  upstreamClient
+	backoff time.Duration // zero if the endpoint is healthy
+	retryAt time.Time     // endpoint is skipped until then
+}
+
+// upstreamPool performs calls against a list of upstream endpoints, falling
+// over to the next one when an endpoint is unavailable. The last endpoint that
+// succeeded is tried first.
+//
+// If none of the endpoints is available for a number of consecutive calls,
+// the breaker suspends calls for a while, so that they fail immediately.
+type upstreamPool struct {
+	mx        sync.Mutex // mx guards endpoints state and current
+	endpoints []*upstreamEndpoint
+	current   int // index of the last good endpoint
+	breaker   *circuitBreaker
+}
+
+// newUpstreamPool dials the upstream URL and all fallback URLs of given config.
+// A single HTTP client, configured with headers and connection pooling
+// settings of the config, is shared by all HTTP endpoints. Secret references
+// in URLs and headers are resolved first.
+func newUpstreamPool(config params.UpstreamRPCConfig) (*upstreamPool, error) {
+	config, err := config.Resolved()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &upstreamPool{
+		breaker: newCircuitBreaker(config.BreakerThreshold, time.Duration(config.BreakerCooldown)*time.Second),
+	}
+	httpClient := newUpstreamHTTPClient(config)
+
+	urls := append([]string{config.URL}, config.FallbackURLs...)
+	for _, url := range urls {
+		client, err := dialUpstream(url, httpClient)
+		if err != nil {
+			return nil, err
+		}
+
+		p.endpoints = append(p.endpoints, &upstreamEndpoint{url: url, client: client})
+	}
+
+	return p, nil
+}
+
+// dialUpstream connects to the upstream server, using httpClient for HTTP endpoints.
+//
+// WebSocket endpoints (ws:// and wss:// URLs) keep a single persistent connection,
+// which is reestablished if lost, and calls are multiplexed over it by their ids.
+// They connect on first use, within the request timeout of httpClient, so that
+// an unreachable endpoint is failed over instead of failing the whole pool.
+// Configured headers are not sent to WebSocket endpoints.
+func dialUpstream(url string, httpClient *http.Client) (upstreamClient, error) {
+	switch {
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+		return newHTTPUpstreamClient(url, httpClient), nil
+	case strings.HasPrefix(url, "ws://"), strings.HasPrefix(url, "wss://"):
+		return newWSUpstreamClient(url, httpClient.Timeout), nil
+	}
+
+	return gethrpc.Dial(url)
+}
+
+// newUpstreamHTTPClient returns HTTP client with a keep-alive transport tuned
+// by given config, which sets configured headers on every request. Requests
+// exceeding the configured request timeout are canceled.
+func newUpstreamHTTPClient(config params.UpstreamRPCConfig) *http.Client {
+	// the same settings as http.DefaultTransport has
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if config.MaxIdleConns > 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = time.Duration(config.IdleConnTimeout) * time.Second
+	}
+
+	timeout := defaultUpstreamRequestTimeout
+	if config.RequestTimeout > 0 {
+		timeout = time.Duration(config.RequestTimeout) * time.Second
+	}
+
+	if len(config.Headers) == 0 {
+		return &http.Client{Transport: transport, Timeout: timeout}
+	}
+
+	h := make(http.Header, len(config.Headers))
+	for name, value := range config.Headers {
+		h.Set(name, value)
+	}
+
+	return &http.Client{
+		Transport: &headerTransport{headers: h, base: transport},
+		Timeout:   timeout,
+	}
+}
+
+// headerTransport is a http.RoundTripper adding headers to every request.
+type headerTransport struct {
+	headers http.Header
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper must not modify the request, so work on a copy
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+len(t.headers))
+	for name, values := range req.Header {
+		r.Header[name] = values
+	}
+	for name, values := range t.headers {
+		r.Header[name] = values
+	}
+
+	return t.base.RoundTrip(r)
+}
+
+// CallContext performs a JSON-RPC call against the first available endpoint.
+//
+// An endpoint is considered unavailable if the call fails with anything other
+// than a JSON-RPC error, e.g. connection failure or 5xx response.
+// Unavailable endpoints are skipped for an exponentially growing period.
+// Timed out calls fail over as well, and are reported with upstreamTimeoutError
+// if none of the endpoints served the call.
+func (p *upstreamPool) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if err := p.breaker.allow(); err != nil {
+		return err
+	}
+
+	err := p.callContext(ctx, result, method, args...)
+	if ctx.Err() != nil {
+		// the call was abandoned, so it tells nothing about the upstream
+		p.breaker.abandon()
+	} else {
+		p.breaker.done(isUnavailable(ctx, err))
+	}
+
+	if ctx.Err() == nil && isTimeout(err) {
+		return &upstreamTimeoutError{}
+	}
+
+	return err
+}
+
+// callContext performs a JSON-RPC call against the first available endpoint.
+func (p *upstreamPool) callContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	var err error
+
+	for _, e := range p.candidates() {
+		err = e.client.CallContext(ctx, result, method, args...)
+		if !isUnavailable(ctx, err) {
+			p.markGood(e)
+			return err
+		}
+
+		log.Warn("Upstream endpoint unavailable", "url", e.url, "error", err)
+		p.markFailed(e)
+	}
+
+	return err
+}
+
+// candidates returns endpoints in order they should be tried, starting with
+// the last good one. Endpoints in backoff are skipped, unless all of them are.
+func (p *upstreamPool) candidates() []*upstreamEndpoint {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	now := time.Now()
+	candidates := make([]*upstreamEndpoint, 0, len(p.endpoints))
+	for i := range p.endpoints {
+		e := p.endpoints[(p.current+i)%len(p.endpoints)]
+		if now.Before(e.retryAt) {
+			continue
+		}
+
+		candidates = append(candidates, e)
+	}
+
+	if len(candidates) == 0 && len(p.endpoints) > 0 {
+		candidates = append(candidates, p.endpoints[p.current])
+	}
+
+	return candidates
+}
+
+// setHTTPClient makes all HTTP endpoints send requests with a given HTTP client.
+func (p *upstreamPool) setHTTPClient(httpClient *http.Client) {
+	for _, e := range p.endpoints {
+		if client, ok := e.client.(*httpUpstreamClient); ok {
+			client.setHTTPClient(httpClient)
+		}
+	}
+}
+
+// subscriber returns the first available endpoint supporting subscriptions.
+func (p *upstreamPool) subscriber() (subscriber, bool) {
+	for _, e := range p.candidates() {
+		if s, ok := e.client.(subscriber); ok {
+			return s, true
+		}
+	}
+
+	return nil, false
+}
+
+func (p *upstreamPool) markGood(e *upstreamEndpoint) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	e.backoff = 0
+	e.retryAt = time.Time{}
+
+	for i := range p.endpoints {
+		if p.endpoints[i] == e {
+			p.current = i
+		}
+	}
+}
+
+func (p *upstreamPool) markFailed(e *upstreamEndpoint) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	e.backoff *= 2
+	if e.backoff < upstreamMinBackoff {
+		e.backoff = upstreamMinBackoff
+	}
+	if e.backoff > upstreamMaxBackoff {
+		e.backoff = upstreamMaxBackoff
+	}
+	e.retryAt = time.Now().Add(e.backoff)
+}
+
+// isTimeout returns true if err means that the call has timed out.
+func isTimeout(err error) bool {
+	e, ok := err.(net.Error)
+	return ok && e.Timeout()
+}
+
+// isUnavailable returns true if err means that the endpoint could not serve the call.
+func isUnavailable(ctx context.Context, err error) bool {
+	if err == nil || err == gethrpc.ErrNoResult || ctx.Err() != nil {
+		return false
+	}
+
+	_, ok := err.(gethrpc.Error)
+	return !ok
+}
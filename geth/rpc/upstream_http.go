@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// upstreamClient performs JSON-RPC calls against a single upstream server.
+type upstreamClient interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// httpUpstreamClient is a JSON-RPC over HTTP client sending requests
+// with the given HTTP client, so that its transport and headers are used.
+// go-ethereum's HTTP client always uses a default HTTP client.
+type httpUpstreamClient struct {
+	url    string
+	lastID uint32
+
+	mx         sync.RWMutex // mx guards httpClient
+	httpClient *http.Client
+}
+
+func newHTTPUpstreamClient(url string, httpClient *http.Client) *httpUpstreamClient {
+	return &httpUpstreamClient{url: url, httpClient: httpClient}
+}
+
+// setHTTPClient replaces the HTTP client requests are sent with.
+func (c *httpUpstreamClient) setHTTPClient(httpClient *http.Client) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.httpClient = httpClient
+}
+
+// CallContext performs a JSON-RPC call. JSON-RPC errors are returned
+// as gethrpc.Error, the same way as go-ethereum's client does.
+func (c *httpUpstreamClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	params, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	id := atomic.AddUint32(&c.lastID, 1)
+	body, err := json.Marshal(&jsonrpcMessage{
+		Version: jsonrpcVersion,
+		ID:      json.RawMessage(strconv.FormatUint(uint64(id), 10)),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	c.mx.RLock()
+	httpClient := c.httpClient
+	c.mx.RUnlock()
+
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	// a server error means that the endpoint is unavailable, even if the body
+	// is a valid JSON-RPC error, so it must not be returned as gethrpc.Error
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("upstream server error: %s", resp.Status)
+	}
+
+	var msg jsonrpcMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return err
+	}
+
+	switch {
+	case msg.Error != nil:
+		return msg.Error
+	case len(msg.Result) == 0:
+		return gethrpc.ErrNoResult
+	default:
+		return json.Unmarshal(msg.Result, &result)
+	}
+}
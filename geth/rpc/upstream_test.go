@@ -0,0 +1,318 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+// TestService is an RPC service used by tests, it has to be exported.
+type TestService struct{}
+
+func (TestService) Version() string { return "1.0" }
+
+func TestUpstreamPoolFailover(t *testing.T) {
+	var failedCalls int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&failedCalls, 1)
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("test", TestService{}))
+	working := httptest.NewServer(server)
+	defer working.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{
+		URL:          failing.URL,
+		FallbackURLs: []string{working.URL},
+	})
+	require.NoError(t, err)
+
+	var version string
+	require.NoError(t, pool.CallContext(context.Background(), &version, "test_version"))
+	require.Equal(t, "1.0", version)
+	require.EqualValues(t, 1, atomic.LoadInt32(&failedCalls))
+
+	// last good endpoint is used and the failed one is in backoff
+	require.NoError(t, pool.CallContext(context.Background(), &version, "test_version"))
+	require.EqualValues(t, 1, atomic.LoadInt32(&failedCalls))
+}
+
+func TestUpstreamPoolServerErrorWithJSONBody(t *testing.T) {
+	var failedCalls int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&failedCalls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"overloaded"}}`)) // nolint: errcheck
+	}))
+	defer failing.Close()
+
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("test", TestService{}))
+	working := httptest.NewServer(server)
+	defer working.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{
+		URL:          failing.URL,
+		FallbackURLs: []string{working.URL},
+	})
+	require.NoError(t, err)
+
+	var version string
+	require.NoError(t, pool.CallContext(context.Background(), &version, "test_version"))
+	require.Equal(t, "1.0", version)
+	require.EqualValues(t, 1, atomic.LoadInt32(&failedCalls))
+
+	// the failed endpoint is in backoff, so it's not marked as good
+	require.NoError(t, pool.CallContext(context.Background(), &version, "test_version"))
+	require.EqualValues(t, 1, atomic.LoadInt32(&failedCalls))
+}
+
+func TestUpstreamPoolJSONRPCError(t *testing.T) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("test", TestService{}))
+	working := httptest.NewServer(server)
+	defer working.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{URL: working.URL})
+	require.NoError(t, err)
+
+	err = pool.CallContext(context.Background(), nil, "test_unknown")
+	require.Error(t, err)
+	require.False(t, isUnavailable(context.Background(), err), "JSON-RPC error should not fail over")
+}
+
+func TestUpstreamPoolRequestTimeout(t *testing.T) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("test", TestService{}))
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(3 * time.Second)
+		server.ServeHTTP(w, r)
+	}))
+	defer upstream.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{URL: upstream.URL, RequestTimeout: 1})
+	require.NoError(t, err)
+
+	c := &Client{
+		upstreamEnabled: true,
+		upstream:        pool,
+		router:          newRouter(true),
+		handlers:        make(map[string]Handler),
+	}
+	c.RouteUpstream("test_")
+
+	started := time.Now()
+	resp := c.CallRaw(`{"jsonrpc":"2.0","method":"test_version","params":[],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"upstream request timed out"}}`, resp)
+	require.True(t, time.Since(started) < 2*time.Second, "call should time out promptly, took %s", time.Since(started))
+}
+
+// recordingTransport is a http.RoundTripper recording URLs of all requests.
+type recordingTransport struct {
+	mx   sync.Mutex
+	urls []string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mx.Lock()
+	t.urls = append(t.urls, req.URL.String())
+	t.mx.Unlock()
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestSetHTTPClient(t *testing.T) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("net", NetService{}))
+	upstream := httptest.NewServer(server)
+	defer upstream.Close()
+
+	fallback := httptest.NewServer(server)
+	defer fallback.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{
+		URL:          upstream.URL,
+		FallbackURLs: []string{fallback.URL},
+	})
+	require.NoError(t, err)
+
+	c := &Client{
+		upstreamEnabled: true,
+		upstream:        pool,
+		router:          newRouter(true),
+		handlers:        make(map[string]Handler),
+	}
+
+	transport := &recordingTransport{}
+	c.SetHTTPClient(&http.Client{Transport: transport})
+
+	for i := 0; i < 2; i++ {
+		var version string
+		require.NoError(t, c.Call(&version, "net_version"))
+		require.Equal(t, "3", version)
+	}
+
+	// the fallback endpoint uses the injected client as well
+	upstream.Close()
+	var version string
+	require.NoError(t, c.Call(&version, "net_version"))
+
+	require.Equal(t, []string{upstream.URL, upstream.URL, upstream.URL, fallback.URL}, transport.urls)
+}
+
+func TestUpstreamPoolHeaders(t *testing.T) {
+	require.NoError(t, os.Setenv("STATUS_TEST_UPSTREAM_TOKEN", "secret"))
+	defer os.Unsetenv("STATUS_TEST_UPSTREAM_TOKEN") // nolint: errcheck
+
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("test", TestService{}))
+
+	var authorization string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		server.ServeHTTP(w, r)
+	}))
+	defer upstream.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{
+		URL: upstream.URL,
+		Headers: map[string]string{
+			"Authorization": "Bearer ${STATUS_TEST_UPSTREAM_TOKEN}",
+		},
+	})
+	require.NoError(t, err)
+
+	var version string
+	require.NoError(t, pool.CallContext(context.Background(), &version, "test_version"))
+	require.Equal(t, "Bearer secret", authorization)
+}
+
+func TestUpstreamPoolSecretResolver(t *testing.T) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("net", NetService{}))
+
+	var called bool
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		server.ServeHTTP(w, r)
+	}))
+	defer upstream.Close()
+
+	var resolved []string
+	params.SetSecretResolver(func(ref string) (string, error) {
+		resolved = append(resolved, ref)
+		if ref != "UPSTREAM_URL" {
+			return "", errors.New("unknown secret")
+		}
+		return upstream.URL, nil
+	})
+	defer params.SetSecretResolver(nil)
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{URL: "${UPSTREAM_URL}"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"UPSTREAM_URL"}, resolved)
+
+	var version string
+	require.NoError(t, pool.CallContext(context.Background(), &version, "net_version"))
+	require.Equal(t, "3", version)
+	require.True(t, called)
+
+	_, err = newUpstreamPool(params.UpstreamRPCConfig{URL: "${UNKNOWN}"})
+	require.EqualError(t, err, "failed to resolve secret UNKNOWN: unknown secret")
+}
+
+func TestUpstreamPoolWebsocket(t *testing.T) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("net", NetService{}))
+	upstream := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer upstream.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{
+		URL: "ws://" + strings.TrimPrefix(upstream.URL, "http://"),
+	})
+	require.NoError(t, err)
+	require.IsType(t, &wsUpstreamClient{}, pool.endpoints[0].client)
+
+	// concurrent calls are multiplexed over the same connection
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var version string
+			require.NoError(t, pool.CallContext(context.Background(), &version, "net_version"))
+			require.Equal(t, "3", version)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUpstreamPoolUnreachableWebsocket(t *testing.T) {
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("net", NetService{}))
+	working := httptest.NewServer(server)
+	defer working.Close()
+
+	// an unreachable WebSocket endpoint doesn't fail the pool, but is failed over
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{
+		URL:          "ws://" + strings.TrimPrefix(unreachable.URL, "http://"),
+		FallbackURLs: []string{working.URL},
+	})
+	require.NoError(t, err)
+
+	var version string
+	require.NoError(t, pool.CallContext(context.Background(), &version, "net_version"))
+	require.Equal(t, "3", version)
+}
+
+func BenchmarkUpstreamPooled(b *testing.B) {
+	benchmarkUpstream(b, true)
+}
+
+func BenchmarkUpstreamUnpooled(b *testing.B) {
+	benchmarkUpstream(b, false)
+}
+
+// benchmarkUpstream issues sequential calls to the upstream with and without keep-alive connections.
+func benchmarkUpstream(b *testing.B, pooled bool) {
+	server := gethrpc.NewServer()
+	require.NoError(b, server.RegisterName("test", TestService{}))
+	upstream := httptest.NewServer(server)
+	defer upstream.Close()
+
+	pool, err := newUpstreamPool(params.UpstreamRPCConfig{URL: upstream.URL, MaxIdleConnsPerHost: 10})
+	require.NoError(b, err)
+
+	if !pooled {
+		pool.endpoints[0].client = newHTTPUpstreamClient(upstream.URL, &http.Client{
+			Transport: &http.Transport{DisableKeepAlives: true},
+		})
+	}
+
+	var version string
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := pool.CallContext(context.Background(), &version, "test_version"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// wsUpstreamClient is a JSON-RPC over WebSocket client, which connects on first use,
+// so that an unreachable endpoint doesn't prevent the client from being created.
+// If connecting fails, the call fails and the next one connects again.
+type wsUpstreamClient struct {
+	url         string
+	dialTimeout time.Duration // zero means no timeout
+
+	mx     sync.Mutex // mx guards client
+	client *gethrpc.Client
+}
+
+func newWSUpstreamClient(url string, dialTimeout time.Duration) *wsUpstreamClient {
+	return &wsUpstreamClient{url: url, dialTimeout: dialTimeout}
+}
+
+// CallContext performs a JSON-RPC call, connecting first if needed.
+func (c *wsUpstreamClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	client, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	return client.CallContext(ctx, result, method, args...)
+}
+
+// EthSubscribe creates a subscription with eth_subscribe, connecting first if needed.
+func (c *wsUpstreamClient) EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (*gethrpc.ClientSubscription, error) {
+	client, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.EthSubscribe(ctx, channel, args...)
+}
+
+// connect returns the connected client. Connecting is limited by ctx and the dial
+// timeout, once connected, the connection is reestablished by the client if lost.
+func (c *wsUpstreamClient) connect(ctx context.Context) (*gethrpc.Client, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	if c.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.dialTimeout)
+		defer cancel()
+	}
+
+	client, err := gethrpc.DialWebsocket(ctx, c.url, "")
+	if err != nil {
+		return nil, err
+	}
+	c.client = client
+
+	return client, nil
+}
@@ -8,6 +8,7 @@ extern bool StatusServiceSignalEvent(const char *jsonEvent);
 import "C"
 import (
 	"encoding/json"
+	"sync"
 
 	"github.com/status-im/status-go/geth/log"
 )
@@ -28,6 +29,9 @@ const (
 
 	// EventChainDataRemoved is triggered when node's chain data is removed
 	EventChainDataRemoved = "chaindata.removed"
+
+	// EventEnvelopeStatus is triggered when delivery status of a posted whisper envelope changes
+	EventEnvelopeStatus = "envelope.status"
 )
 
 // Envelope is a general signal sent upward from node to RN app
@@ -41,20 +45,32 @@ type NodeCrashEvent struct {
 	Error string `json:"error"`
 }
 
+// EnvelopeStatusEvent reports a delivery status (queued, sent, delivered or failed)
+// of a whisper envelope with a given hash
+type EnvelopeStatusEvent struct {
+	Hash   string `json:"hash"`
+	Status string `json:"status"`
+}
+
 // NodeNotificationHandler defines a handler able to process incoming node events.
 // Events are encoded as JSON strings.
 type NodeNotificationHandler func(jsonEvent string)
 
-var notificationHandler NodeNotificationHandler = TriggerDefaultNodeNotificationHandler
+var (
+	notificationHandlerMutex sync.RWMutex            // signals are sent from many goroutines
+	notificationHandler      NodeNotificationHandler = TriggerDefaultNodeNotificationHandler
+)
 
 // SetDefaultNodeNotificationHandler sets notification handler to invoke on Send
 func SetDefaultNodeNotificationHandler(fn NodeNotificationHandler) {
+	notificationHandlerMutex.Lock()
 	notificationHandler = fn
+	notificationHandlerMutex.Unlock()
 }
 
 // ResetDefaultNodeNotificationHandler sets notification handler to default one
 func ResetDefaultNodeNotificationHandler() {
-	notificationHandler = TriggerDefaultNodeNotificationHandler
+	SetDefaultNodeNotificationHandler(TriggerDefaultNodeNotificationHandler)
 }
 
 // TriggerDefaultNodeNotificationHandler triggers default notification handler (helpful in tests)
@@ -70,7 +86,11 @@ func Send(signal Envelope) {
 
 //export NotifyNode
 func NotifyNode(jsonEvent *C.char) { // nolint: golint
-	notificationHandler(C.GoString(jsonEvent))
+	notificationHandlerMutex.RLock()
+	handler := notificationHandler
+	notificationHandlerMutex.RUnlock()
+
+	handler(C.GoString(jsonEvent))
 }
 
 //export TriggerTestSignal
@@ -0,0 +1,33 @@
+package signal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend(t *testing.T) {
+	var received []string
+	SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		received = append(received, jsonEvent)
+	})
+	defer ResetDefaultNodeNotificationHandler()
+
+	Send(Envelope{
+		Type:  EventNodeReady,
+		Event: struct{}{},
+	})
+	Send(Envelope{
+		Type:  EventNodeCrashed,
+		Event: NodeCrashEvent{Error: "boom"},
+	})
+
+	require.Len(t, received, 2)
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal([]byte(received[0]), &envelope))
+	require.Equal(t, EventNodeReady, envelope.Type)
+
+	require.JSONEq(t, `{"type": "node.crashed", "event": {"error": "boom"}}`, received[1])
+}
@@ -0,0 +1,137 @@
+package txqueue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/node"
+)
+
+// txHistory keeps records of sent transactions by their senders.
+type txHistory struct {
+	mu      sync.RWMutex
+	records map[gethcommon.Address][]*common.TransactionRecord
+	byHash  map[gethcommon.Hash]*common.TransactionRecord
+}
+
+func newTxHistory() *txHistory {
+	return &txHistory{
+		records: make(map[gethcommon.Address][]*common.TransactionRecord),
+		byHash:  make(map[gethcommon.Hash]*common.TransactionRecord),
+	}
+}
+
+// add records a transaction.
+func (h *txHistory) add(record common.TransactionRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records[record.From] = append(h.records[record.From], &record)
+	h.byHash[record.Hash] = &record
+}
+
+// setStatus updates the status of a recorded transaction with a given hash.
+func (h *txHistory) setStatus(hash gethcommon.Hash, status common.TransactionStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if record, ok := h.byHash[hash]; ok {
+		record.Status = status
+	}
+}
+
+// get returns copies of records of transactions sent from a given address.
+func (h *txHistory) get(address gethcommon.Address) []common.TransactionRecord {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	records := make([]common.TransactionRecord, len(h.records[address]))
+	for i, record := range h.records[address] {
+		records[i] = *record
+	}
+
+	return records
+}
+
+// TransactionHistory returns transactions completed with the manager that were
+// sent from a given address, oldest first. Statuses of pending transactions are
+// updated once their receipts are observed.
+func (m *Manager) TransactionHistory(address string) []common.TransactionRecord {
+	return m.history.get(gethcommon.HexToAddress(address))
+}
+
+// recordTransaction records a returned transaction if it was sent successfully,
+// and watches for its receipt.
+func (m *Manager) recordTransaction(queuedTx *common.QueuedTx, err error) {
+	if err != nil || queuedTx.Hash == (gethcommon.Hash{}) {
+		return
+	}
+
+	m.history.add(common.TransactionRecord{
+		Hash:      queuedTx.Hash,
+		From:      queuedTx.Args.From,
+		To:        queuedTx.Args.To,
+		Value:     queuedTx.Args.Value,
+		Timestamp: time.Now(),
+		Status:    common.TransactionPending,
+	})
+
+	m.watchReceipt(queuedTx.Hash)
+}
+
+// watchReceipt updates the status of a recorded transaction once it's mined
+// or dropped. Watching stops when the manager is stopped.
+func (m *Manager) watchReceipt(hash gethcommon.Hash) {
+	m.receiptsMu.Lock()
+	defer m.receiptsMu.Unlock()
+
+	if m.stopReceipts == nil {
+		return
+	}
+
+	ctx := m.receiptsCtx
+	m.receiptsGroup.Add(1)
+	go func() {
+		defer m.receiptsGroup.Done()
+
+		receipt, err := m.nodeManager.WaitForReceipt(ctx, hash, 0)
+		switch {
+		case err == node.ErrTransactionDropped:
+			m.history.setStatus(hash, common.TransactionDropped)
+		case err != nil:
+			if ctx.Err() == nil {
+				log.Warn("failed to get a transaction receipt", "hash", hash.Hex(), "err", err)
+			}
+		case receipt.Failed:
+			m.history.setStatus(hash, common.TransactionFailed)
+		default:
+			m.history.setStatus(hash, common.TransactionSuccess)
+		}
+	}()
+}
+
+// startWatchingReceipts allows watching receipts of recorded transactions.
+func (m *Manager) startWatchingReceipts() {
+	m.receiptsMu.Lock()
+	defer m.receiptsMu.Unlock()
+
+	if m.stopReceipts == nil {
+		m.receiptsCtx, m.stopReceipts = context.WithCancel(context.Background())
+	}
+}
+
+// stopWatchingReceipts stops watching receipts and waits until watchers return.
+func (m *Manager) stopWatchingReceipts() {
+	m.receiptsMu.Lock()
+	if m.stopReceipts != nil {
+		m.stopReceipts()
+		m.stopReceipts = nil
+	}
+	m.receiptsMu.Unlock()
+
+	m.receiptsGroup.Wait()
+}
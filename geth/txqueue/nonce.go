@@ -0,0 +1,107 @@
+package txqueue
+
+import (
+	"sort"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/geth/common"
+)
+
+// accountNonces holds nonces handed out to queued transactions of an account.
+type accountNonces struct {
+	next    uint64
+	freed   []uint64 // nonces of transactions that left the queue without being sent
+	pending int
+}
+
+type assignedNonce struct {
+	from  gethcommon.Address
+	nonce uint64
+}
+
+// nonceTracker hands out consecutive nonces to transactions of the same account,
+// so that transactions queued at the same time don't collide.
+//
+// A nonce of a transaction that is discarded (or fails) is given to the next queued
+// transaction of the account, so that it doesn't leave a gap blocking the ones after it.
+// Once there are no queued transactions of an account left, its next nonce is fetched
+// from the chain again.
+type nonceTracker struct {
+	mu       sync.Mutex // to guard accounts and assigned maps
+	accounts map[gethcommon.Address]*accountNonces
+	assigned map[common.QueuedTxID]assignedNonce
+}
+
+func newNonceTracker() *nonceTracker {
+	return &nonceTracker{
+		accounts: make(map[gethcommon.Address]*accountNonces),
+		assigned: make(map[common.QueuedTxID]assignedNonce),
+	}
+}
+
+// Next returns a nonce for a given transaction. If the account has no queued
+// transactions, fetch is called to get the nonce from the chain state.
+func (t *nonceTracker) Next(id common.QueuedTxID, from gethcommon.Address, fetch func() (uint64, error)) (uint64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	account, ok := t.accounts[from]
+	if !ok {
+		nonce, err := fetch()
+		if err != nil {
+			return 0, err
+		}
+
+		account = &accountNonces{next: nonce}
+		t.accounts[from] = account
+	}
+
+	var nonce uint64
+	if len(account.freed) > 0 {
+		nonce = account.freed[0]
+		account.freed = account.freed[1:]
+	} else {
+		nonce = account.next
+		account.next++
+	}
+
+	account.pending++
+	t.assigned[id] = assignedNonce{from: from, nonce: nonce}
+
+	return nonce, nil
+}
+
+// Release marks a transaction as no longer queued. If it was not sent, its nonce
+// is reused. It's a no-op if the transaction was not given a nonce by the tracker.
+func (t *nonceTracker) Release(id common.QueuedTxID, sent bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	assigned, ok := t.assigned[id]
+	if !ok {
+		return
+	}
+	delete(t.assigned, id)
+
+	account := t.accounts[assigned.from]
+	account.pending--
+	if account.pending == 0 {
+		delete(t.accounts, assigned.from)
+		return
+	}
+
+	if !sent {
+		account.freed = append(account.freed, assigned.nonce)
+		sort.Slice(account.freed, func(i, j int) bool { return account.freed[i] < account.freed[j] })
+	}
+}
+
+// Reset forgets all handed out nonces.
+func (t *nonceTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.accounts = make(map[gethcommon.Address]*accountNonces)
+	t.assigned = make(map[common.QueuedTxID]assignedNonce)
+}
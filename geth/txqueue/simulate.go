@@ -0,0 +1,85 @@
+package txqueue
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// revertSelector is the selector of Error(string), which return data
+// of a transaction reverted with a reason starts with.
+var revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// SimulationResult is a predicted outcome of a transaction.
+type SimulationResult struct {
+	Result hexutil.Bytes `json:"result"`
+	Gas    *hexutil.Big  `json:"gas"`
+}
+
+// RevertError is returned if a simulated transaction reverts.
+type RevertError struct {
+	Reason string // empty if the contract gave no reason
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return "execution reverted"
+	}
+
+	return "execution reverted: " + e.Reason
+}
+
+// SimulateTransaction runs a transaction with eth_call and estimates its gas
+// with eth_estimateGas, without signing or broadcasting it. A *RevertError
+// carrying the revert reason is returned if the transaction would revert.
+func (m *Manager) SimulateTransaction(ctx context.Context, args common.SendTxArgs) (*SimulationResult, error) {
+	log.Info("simulate transaction", "from", args.From.Hex())
+
+	client := m.nodeManager.RPCClient()
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	var result hexutil.Bytes
+	if err := client.CallContext(ctx, &result, "eth_call", toCallArgs(args), "latest"); err != nil {
+		log.Warn("failed to simulate transaction", "err", err)
+		return nil, err
+	}
+
+	if reason, ok := revertReason(result); ok {
+		return nil, &RevertError{Reason: reason}
+	}
+
+	gas, err := m.estimateGas(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SimulationResult{Result: result, Gas: gas}, nil
+}
+
+// revertReason decodes the reason from return data of a reverted transaction,
+// ABI encoded as Error(string). It returns false if the data is not a reason.
+func revertReason(data []byte) (string, bool) {
+	if len(data) < len(revertSelector)+64 || !bytes.Equal(data[:len(revertSelector)], revertSelector) {
+		return "", false
+	}
+	data = data[len(revertSelector):]
+
+	offset := new(big.Int).SetBytes(data[:32])
+	if offset.BitLen() > 32 || offset.Uint64()+32 > uint64(len(data)) {
+		return "", false
+	}
+	start := offset.Uint64() + 32
+
+	length := new(big.Int).SetBytes(data[start-32 : start])
+	if length.BitLen() > 32 || start+length.Uint64() > uint64(len(data)) {
+		return "", false
+	}
+
+	return string(data[start : start+length.Uint64()]), true
+}
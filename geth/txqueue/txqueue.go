@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"sync"
-	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	gethcommon "github.com/ethereum/go-ethereum/common"
@@ -13,7 +12,7 @@ import (
 )
 
 const (
-	// DefaultTxQueueCap defines how many items can be queued.
+	// DefaultTxQueueCap defines how many items can be queued, unless set with SetCapacity.
 	DefaultTxQueueCap = int(35)
 	// DefaultTxSendQueueCap defines how many items can be passed to sendTransaction() w/o blocking.
 	DefaultTxSendQueueCap = int(70)
@@ -28,15 +27,17 @@ var (
 	ErrQueuedTxInProgress       = errors.New("transaction is in progress")
 	ErrQueuedTxAlreadyProcessed = errors.New("transaction has been already processed")
 	ErrInvalidCompleteTxSender  = errors.New("transaction can only be completed by the same account which created it")
+	ErrQueuedTxQueueFull        = errors.New("transaction queue full")
 )
 
-// TxQueue is capped container that holds pending transactions
+// TxQueue is capped container that holds pending transactions.
+// New transactions are rejected once it's full.
 type TxQueue struct {
-	transactions  map[common.QueuedTxID]*common.QueuedTx
-	mu            sync.RWMutex // to guard transactions map
-	evictableIDs  chan common.QueuedTxID
-	enqueueTicker chan struct{}
-	incomingPool  chan *common.QueuedTx
+	transactions map[common.QueuedTxID]*common.QueuedTx
+	capacity     int
+	mu           sync.RWMutex // to guard transactions map and capacity
+	incomingPool chan *common.QueuedTx
+	nonces       *nonceTracker // nonces handed out to queued transactions
 
 	// when this channel is closed, all queue channels processing must cease (incoming queue, processing queued items etc)
 	stopped      chan struct{}
@@ -45,22 +46,43 @@ type TxQueue struct {
 	// when items are enqueued notify subscriber
 	txEnqueueHandler common.EnqueuedTxHandler
 
+	// when items are enqueued notify subscribers as well
+	enqueueSubs   map[int]common.EnqueuedTxHandler
+	lastEnqueueID int
+	subsMu        sync.RWMutex // to guard enqueueSubs map
+
 	// when tx is returned (either successfully or with error) notify subscriber
 	txReturnHandler common.EnqueuedTxReturnHandler
+
+	// when tx is returned notify subscribers as well, guarded by subsMu
+	returnSubs   map[int]common.EnqueuedTxReturnHandler
+	lastReturnID int
 }
 
 // NewTransactionQueue make new transaction queue
 func NewTransactionQueue() *TxQueue {
 	log.Info("initializing transaction queue")
 	return &TxQueue{
-		transactions:  make(map[common.QueuedTxID]*common.QueuedTx),
-		evictableIDs:  make(chan common.QueuedTxID, DefaultTxQueueCap), // will be used to evict in FIFO
-		enqueueTicker: make(chan struct{}),
-		incomingPool:  make(chan *common.QueuedTx, DefaultTxSendQueueCap),
+		transactions: make(map[common.QueuedTxID]*common.QueuedTx),
+		capacity:     DefaultTxQueueCap,
+		incomingPool: make(chan *common.QueuedTx, DefaultTxSendQueueCap),
+		nonces:       newNonceTracker(),
 	}
 }
 
-// Start starts enqueue and eviction loops
+// SetCapacity sets how many transactions can be queued, DefaultTxQueueCap if zero.
+// Transactions queued already are kept even if there are more of them.
+func (q *TxQueue) SetCapacity(capacity int) {
+	if capacity == 0 {
+		capacity = DefaultTxQueueCap
+	}
+
+	q.mu.Lock()
+	q.capacity = capacity
+	q.mu.Unlock()
+}
+
+// Start starts enqueue loop
 func (q *TxQueue) Start() {
 	log.Info("starting transaction queue")
 
@@ -69,13 +91,12 @@ func (q *TxQueue) Start() {
 	}
 
 	q.stopped = make(chan struct{})
-	q.stoppedGroup.Add(2)
+	q.stoppedGroup.Add(1)
 
-	go q.evictionLoop()
 	go q.enqueueLoop()
 }
 
-// Stop stops transaction enqueue and eviction loops
+// Stop stops transaction enqueue loop
 func (q *TxQueue) Stop() {
 	log.Info("stopping transaction queue")
 
@@ -83,36 +104,13 @@ func (q *TxQueue) Stop() {
 		return
 	}
 
-	close(q.stopped) // stops all processing loops (enqueue, eviction etc)
+	close(q.stopped) // stops all processing loops
 	q.stoppedGroup.Wait()
 	q.stopped = nil
 
 	log.Info("finally stopped transaction queue")
 }
 
-// evictionLoop frees up queue to accommodate another transaction item
-func (q *TxQueue) evictionLoop() {
-	defer HaltOnPanic()
-	evict := func() {
-		if len(q.transactions) >= DefaultTxQueueCap { // eviction is required to accommodate another/last item
-			q.Remove(<-q.evictableIDs)
-		}
-	}
-
-	for {
-		select {
-		case <-time.After(250 * time.Millisecond): // do not wait for manual ticks, check queue regularly
-			evict()
-		case <-q.enqueueTicker: // when manually requested
-			evict()
-		case <-q.stopped:
-			log.Info("transaction queue's eviction loop stopped")
-			q.stoppedGroup.Done()
-			return
-		}
-	}
-}
-
 // enqueueLoop process incoming enqueue requests
 func (q *TxQueue) enqueueLoop() {
 	defer HaltOnPanic()
@@ -138,7 +136,7 @@ func (q *TxQueue) Reset() {
 	defer q.mu.Unlock()
 
 	q.transactions = make(map[common.QueuedTxID]*common.QueuedTx)
-	q.evictableIDs = make(chan common.QueuedTxID, DefaultTxQueueCap)
+	q.nonces.Reset()
 }
 
 // EnqueueAsync enqueues incoming transaction in async manner, returns as soon as possible
@@ -148,28 +146,37 @@ func (q *TxQueue) EnqueueAsync(tx *common.QueuedTx) error {
 	return nil
 }
 
-// Enqueue enqueues incoming transaction
+// Enqueue enqueues incoming transaction. It fails with ErrQueuedTxQueueFull
+// if the queue has reached its capacity.
 func (q *TxQueue) Enqueue(tx *common.QueuedTx) error {
 	log.Info(fmt.Sprintf("enqueue transaction: %s", tx.ID))
 
-	if q.txEnqueueHandler == nil { //discard, until handler is provided
+	subs := q.enqueueSubscribers()
+	if q.txEnqueueHandler == nil && len(subs) == 0 { //discard, until handler is provided
 		log.Info("there is no txEnqueueHandler")
+		q.nonces.Release(tx.ID, false)
 		return nil
 	}
 
-	log.Info("before enqueueTicker")
-	q.enqueueTicker <- struct{}{} // notify eviction loop that we are trying to insert new item
-	log.Info("before evictableIDs")
-	q.evictableIDs <- tx.ID // this will block when we hit DefaultTxQueueCap
-	log.Info("after evictableIDs")
-
 	q.mu.Lock()
+	if capacity := q.capacity; len(q.transactions) >= capacity {
+		q.mu.Unlock()
+		log.Warn("transaction queue is full", "tx", tx.ID, "capacity", capacity)
+		q.nonces.Release(tx.ID, false)
+		return ErrQueuedTxQueueFull
+	}
 	q.transactions[tx.ID] = tx
 	q.mu.Unlock()
 
 	// notify handler
-	log.Info("calling txEnqueueHandler")
-	q.txEnqueueHandler(tx)
+	if q.txEnqueueHandler != nil {
+		log.Info("calling txEnqueueHandler")
+		q.txEnqueueHandler(tx)
+	}
+
+	for _, fn := range subs {
+		fn(tx)
+	}
 
 	return nil
 }
@@ -191,6 +198,9 @@ func (q *TxQueue) Remove(id common.QueuedTxID) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
+	if tx, ok := q.transactions[id]; ok {
+		q.nonces.Release(id, tx.Hash != (gethcommon.Hash{}))
+	}
 	delete(q.transactions, id)
 }
 
@@ -244,6 +254,81 @@ func (q *TxQueue) SetEnqueueHandler(fn common.EnqueuedTxHandler) {
 	q.txEnqueueHandler = fn
 }
 
+// SubscribeEnqueued registers callback handler, that is triggered on enqueue operation
+// in addition to the one set with SetEnqueueHandler. Returned function unsubscribes it.
+func (q *TxQueue) SubscribeEnqueued(fn common.EnqueuedTxHandler) (unsubscribe func()) {
+	q.subsMu.Lock()
+	defer q.subsMu.Unlock()
+
+	if q.enqueueSubs == nil {
+		q.enqueueSubs = make(map[int]common.EnqueuedTxHandler)
+	}
+
+	q.lastEnqueueID++
+	id := q.lastEnqueueID
+	q.enqueueSubs[id] = fn
+
+	return func() {
+		q.subsMu.Lock()
+		defer q.subsMu.Unlock()
+
+		delete(q.enqueueSubs, id)
+	}
+}
+
+// enqueueSubscribers returns handlers registered with SubscribeEnqueued in subscription order
+func (q *TxQueue) enqueueSubscribers() []common.EnqueuedTxHandler {
+	q.subsMu.RLock()
+	defer q.subsMu.RUnlock()
+
+	subs := make([]common.EnqueuedTxHandler, 0, len(q.enqueueSubs))
+	for id := 1; id <= q.lastEnqueueID; id++ {
+		if fn, ok := q.enqueueSubs[id]; ok {
+			subs = append(subs, fn)
+		}
+	}
+
+	return subs
+}
+
+// SubscribeReturned registers callback handler, that is triggered when transaction
+// is finished executing, successfully or not. Unlike the one set with SetTxReturnHandler,
+// it's called on success too. Returned function unsubscribes it.
+func (q *TxQueue) SubscribeReturned(fn common.EnqueuedTxReturnHandler) (unsubscribe func()) {
+	q.subsMu.Lock()
+	defer q.subsMu.Unlock()
+
+	if q.returnSubs == nil {
+		q.returnSubs = make(map[int]common.EnqueuedTxReturnHandler)
+	}
+
+	q.lastReturnID++
+	id := q.lastReturnID
+	q.returnSubs[id] = fn
+
+	return func() {
+		q.subsMu.Lock()
+		defer q.subsMu.Unlock()
+
+		delete(q.returnSubs, id)
+	}
+}
+
+// returnSubscribers returns handlers registered with SubscribeReturned in subscription order
+func (q *TxQueue) returnSubscribers() []common.EnqueuedTxReturnHandler {
+	q.subsMu.RLock()
+	defer q.subsMu.RUnlock()
+
+	subs := make([]common.EnqueuedTxReturnHandler, 0, len(q.returnSubs))
+	for id := 1; id <= q.lastReturnID; id++ {
+		if fn, ok := q.returnSubs[id]; ok {
+			subs = append(subs, fn)
+		}
+	}
+
+	return subs
+}
+
 // SetTxReturnHandler sets callback handler, that is triggered when transaction is finished executing
 func (q *TxQueue) SetTxReturnHandler(fn common.EnqueuedTxReturnHandler) {
 	q.txReturnHandler = fn
@@ -261,18 +346,17 @@ func (q *TxQueue) NotifyOnQueuedTxReturn(queuedTx *common.QueuedTx, err error) {
 		return
 	}
 
+	for _, fn := range q.returnSubscribers() {
+		fn(queuedTx, err)
+	}
+
 	// on success, remove item from the queue and stop propagating
 	if err == nil {
 		q.Remove(queuedTx.ID)
 		return
 	}
 
-	// error occurred, send upward notification
-	if q.txReturnHandler == nil { // discard, until handler is provided
-		return
-	}
-
-	// remove from queue on any error (except for transient ones) and propagate
+	// remove from queue on any error (except for transient ones)
 	transientErrs := map[error]bool{
 		keystore.ErrDecrypt:        true, // wrong password
 		ErrInvalidCompleteTxSender: true, // completing tx create from another account
@@ -281,6 +365,11 @@ func (q *TxQueue) NotifyOnQueuedTxReturn(queuedTx *common.QueuedTx, err error) {
 		q.Remove(queuedTx.ID)
 	}
 
+	// error occurred, send upward notification
+	if q.txReturnHandler == nil { // discard, until handler is provided
+		return
+	}
+
 	// notify handler
 	q.txReturnHandler(queuedTx, err)
 }
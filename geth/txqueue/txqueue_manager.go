@@ -3,6 +3,7 @@ package txqueue
 import (
 	"context"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
@@ -14,6 +15,7 @@ import (
 	"github.com/pborman/uuid"
 	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/params"
 	"github.com/status-im/status-go/geth/signal"
 )
 
@@ -49,27 +51,65 @@ type Manager struct {
 	nodeManager    common.NodeManager
 	accountManager common.AccountManager
 	txQueue        *TxQueue
+
+	// completionTimeout is how long to wait for a queued transaction
+	// to be completed or discarded, see SetCompletionTimeout.
+	completionTimeout time.Duration
+
+	history *txHistory // see TransactionHistory
+
+	// receipts of recorded transactions are watched while the manager is started
+	receiptsMu    sync.Mutex // guards receiptsCtx and stopReceipts
+	receiptsCtx   context.Context
+	stopReceipts  context.CancelFunc
+	receiptsGroup sync.WaitGroup
 }
 
 // NewManager returns a new Manager.
 func NewManager(nodeManager common.NodeManager, accountManager common.AccountManager) *Manager {
-	return &Manager{
+	m := &Manager{
 		nodeManager:    nodeManager,
 		accountManager: accountManager,
 		txQueue:        NewTransactionQueue(),
+
+		completionTimeout: DefaultTxSendCompletionTimeout * time.Second,
+		history:           newTxHistory(),
+	}
+	m.txQueue.SubscribeReturned(m.recordTransaction)
+
+	return m
+}
+
+// SetCompletionTimeout sets how long WaitForTransaction waits for a transaction
+// to be completed or discarded before it's discarded with ErrQueuedTxTimedOut.
+// Zero restores the default of DefaultTxSendCompletionTimeout seconds.
+// It should be called before Start.
+func (m *Manager) SetCompletionTimeout(timeout time.Duration) {
+	if timeout == 0 {
+		timeout = DefaultTxSendCompletionTimeout * time.Second
 	}
+	m.completionTimeout = timeout
+}
+
+// SetQueueCapacity sets how many transactions can be queued at once,
+// DefaultTxQueueCap if zero. Transactions queued above it are rejected
+// with ErrQueuedTxQueueFull.
+func (m *Manager) SetQueueCapacity(capacity int) {
+	m.txQueue.SetCapacity(capacity)
 }
 
 // Start starts accepting new transactions into the queue.
 func (m *Manager) Start() {
 	log.Info("start Manager")
 	m.txQueue.Start()
+	m.startWatchingReceipts()
 }
 
 // Stop stops accepting new transactions into the queue.
 func (m *Manager) Stop() {
 	log.Info("stop Manager")
 	m.txQueue.Stop()
+	m.stopWatchingReceipts()
 }
 
 // TransactionQueue returns a reference to the queue.
@@ -97,9 +137,42 @@ func (m *Manager) QueueTransaction(tx *common.QueuedTx) error {
 	}
 	log.Info("queue a new transaction", "id", tx.ID, "from", tx.Args.From.Hex(), "to", to)
 
+	if err := m.assignNonce(tx); err != nil {
+		return err
+	}
+
 	return m.txQueue.Enqueue(tx)
 }
 
+// assignNonce sets a nonce of a transaction sent via upstream node, unless it's given explicitly.
+// Local node keeps track of nonces of pending transactions on its own.
+func (m *Manager) assignNonce(tx *common.QueuedTx) error {
+	if tx.Args.Nonce != nil {
+		return nil
+	}
+
+	config, err := m.nodeManager.NodeConfig()
+	if err != nil {
+		return err
+	}
+
+	if !config.UpstreamConfig.Enabled {
+		return nil
+	}
+
+	nonce, err := m.txQueue.nonces.Next(tx.ID, tx.Args.From, func() (uint64, error) {
+		return m.transactionCount(tx.Args.From)
+	})
+	if err != nil {
+		log.Warn("failed to get a nonce", "err", err)
+		return err
+	}
+
+	tx.Args.Nonce = (*hexutil.Uint64)(&nonce)
+
+	return nil
+}
+
 // WaitForTransaction adds a transaction to the queue and blocks
 // until it's completed, discarded or times out.
 func (m *Manager) WaitForTransaction(tx *common.QueuedTx) error {
@@ -108,6 +181,7 @@ func (m *Manager) WaitForTransaction(tx *common.QueuedTx) error {
 	// now wait up until transaction is:
 	// - completed (via CompleteQueuedTransaction),
 	// - discarded (via DiscardQueuedTransaction)
+	// - its context is done
 	// - or times out
 	select {
 	case <-tx.Done:
@@ -116,7 +190,10 @@ func (m *Manager) WaitForTransaction(tx *common.QueuedTx) error {
 	case <-tx.Discard:
 		m.NotifyOnQueuedTxReturn(tx, ErrQueuedTxDiscarded)
 		return ErrQueuedTxDiscarded
-	case <-time.After(DefaultTxSendCompletionTimeout * time.Second):
+	case <-tx.Context.Done():
+		m.NotifyOnQueuedTxReturn(tx, tx.Context.Err())
+		return tx.Context.Err()
+	case <-time.After(m.completionTimeout):
 		m.NotifyOnQueuedTxReturn(tx, ErrQueuedTxTimedOut)
 		return ErrQueuedTxTimedOut
 	}
@@ -168,9 +245,9 @@ func (m *Manager) CompleteTransaction(id common.QueuedTxID, password string) (ge
 	var txErr error
 
 	if config.UpstreamConfig.Enabled {
-		hash, txErr = m.completeRemoteTransaction(queuedTx, password)
+		hash, txErr = m.completeRemoteTransaction(queuedTx, password, config)
 	} else {
-		hash, txErr = m.completeLocalTransaction(queuedTx, password)
+		hash, txErr = m.completeLocalTransaction(queuedTx, password, config)
 	}
 
 	// when incorrect sender tries to complete the account,
@@ -190,7 +267,7 @@ func (m *Manager) CompleteTransaction(id common.QueuedTxID, password string) (ge
 	return hash, txErr
 }
 
-func (m *Manager) completeLocalTransaction(queuedTx *common.QueuedTx, password string) (gethcommon.Hash, error) {
+func (m *Manager) completeLocalTransaction(queuedTx *common.QueuedTx, password string, config *params.NodeConfig) (gethcommon.Hash, error) {
 	log.Info("complete transaction using local node", "id", queuedTx.ID)
 
 	les, err := m.nodeManager.LightEthereumService()
@@ -198,22 +275,25 @@ func (m *Manager) completeLocalTransaction(queuedTx *common.QueuedTx, password s
 		return gethcommon.Hash{}, err
 	}
 
+	// LES backend fills missing gas fields on its own, but it knows nothing
+	// about the multiplier and the oracle, so the gas price is filled here if they are set.
+	if queuedTx.Args.GasPrice == nil && (config.GasPriceMultiplier > 0 || config.GasPriceOracle.Enabled) {
+		if err := m.fillGasPrice(&queuedTx.Args, config); err != nil {
+			return gethcommon.Hash{}, err
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
 
 	return les.StatusBackend.SendTransaction(ctx, status.SendTxArgs(queuedTx.Args), password)
 }
 
-func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, password string) (gethcommon.Hash, error) {
+func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, password string, config *params.NodeConfig) (gethcommon.Hash, error) {
 	log.Info("complete transaction using upstream node", "id", queuedTx.ID)
 
 	var emptyHash gethcommon.Hash
 
-	config, err := m.nodeManager.NodeConfig()
-	if err != nil {
-		return emptyHash, err
-	}
-
 	selectedAcct, err := m.accountManager.SelectedAccount()
 	if err != nil {
 		return emptyHash, err
@@ -228,29 +308,24 @@ func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, password
 		return emptyHash, err
 	}
 
-	// We need to request a new transaction nounce from upstream node.
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-	defer cancel()
-
-	var txCount hexutil.Uint
-	client := m.nodeManager.RPCClient()
-	if err := client.CallContext(ctx, &txCount, "eth_getTransactionCount", queuedTx.Args.From, "pending"); err != nil {
+	// fill missing gas fields, so that they are known once tx is completed
+	if err := m.fillGasArgs(&queuedTx.Args, config); err != nil {
 		return emptyHash, err
 	}
 
 	args := queuedTx.Args
 
-	if args.GasPrice == nil {
-		value, err := m.gasPrice()
-		if err != nil {
-			return emptyHash, err
-		}
-
-		args.GasPrice = value
+	// We need to request a new transaction nonce from upstream node,
+	// unless it was given or assigned when the transaction was queued.
+	var nonce uint64
+	if args.Nonce != nil {
+		nonce = uint64(*args.Nonce)
+	} else if nonce, err = m.transactionCount(args.From); err != nil {
+		return emptyHash, err
 	}
 
 	chainID := big.NewInt(int64(config.NetworkID))
-	nonce := uint64(txCount)
+	gas := (*big.Int)(args.Gas)
 	gasPrice := (*big.Int)(args.GasPrice)
 	data := []byte(args.Data)
 	value := (*big.Int)(args.Value)
@@ -259,11 +334,6 @@ func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, password
 		toAddr = *args.To
 	}
 
-	gas, err := m.estimateGas(args)
-	if err != nil {
-		return emptyHash, err
-	}
-
 	log.Info(
 		"preparing raw transaction",
 		"from", args.From.Hex(),
@@ -273,7 +343,7 @@ func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, password
 		"value", value,
 	)
 
-	tx := types.NewTransaction(nonce, toAddr, value, (*big.Int)(gas), gasPrice, data)
+	tx := types.NewTransaction(nonce, toAddr, value, gas, gasPrice, data)
 	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), selectedAcct.AccountKey.PrivateKey)
 	if err != nil {
 		return emptyHash, err
@@ -284,25 +354,104 @@ func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, password
 		return emptyHash, err
 	}
 
-	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Minute)
-	defer cancel2()
+	client := m.nodeManager.RPCClient()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
 
-	if err := client.CallContext(ctx2, nil, "eth_sendRawTransaction", gethcommon.ToHex(txBytes)); err != nil {
+	if err := client.CallContext(ctx, nil, "eth_sendRawTransaction", gethcommon.ToHex(txBytes)); err != nil {
 		return emptyHash, err
 	}
 
 	return signedTx.Hash(), nil
 }
 
-func (m *Manager) estimateGas(args common.SendTxArgs) (*hexutil.Big, error) {
-	if args.Gas != nil {
-		return args.Gas, nil
+// fillGasArgs sets gas price and gas limit of a transaction if they are not
+// given explicitly, using fillGasPrice and eth_estimateGas respectively.
+func (m *Manager) fillGasArgs(args *common.SendTxArgs, config *params.NodeConfig) error {
+	if args.GasPrice == nil {
+		if err := m.fillGasPrice(args, config); err != nil {
+			return err
+		}
 	}
 
+	if args.Gas == nil {
+		gas, err := m.estimateGas(*args)
+		if err != nil {
+			return err
+		}
+
+		args.Gas = gas
+	}
+
+	return nil
+}
+
+// fillGasPrice sets gas price suggested by the gas price oracle if it's enabled,
+// or by the network otherwise, multiplied by the configured multiplier, unless it's zero.
+func (m *Manager) fillGasPrice(args *common.SendTxArgs, config *params.NodeConfig) error {
+	var (
+		gasPrice *hexutil.Big
+		err      error
+	)
+	if config.GasPriceOracle.Enabled {
+		var price *big.Int
+		price, err = m.nodeManager.SuggestGasPrice()
+		gasPrice = (*hexutil.Big)(price)
+	} else {
+		gasPrice, err = m.gasPrice()
+	}
+	if err != nil {
+		return err
+	}
+
+	args.GasPrice = applyGasPriceMultiplier(gasPrice, config.GasPriceMultiplier)
+
+	return nil
+}
+
+func applyGasPriceMultiplier(gasPrice *hexutil.Big, multiplier float64) *hexutil.Big {
+	if multiplier <= 0 || multiplier == 1 {
+		return gasPrice
+	}
+
+	price := new(big.Float).SetInt((*big.Int)(gasPrice))
+	price.Mul(price, big.NewFloat(multiplier))
+	result, _ := price.Int(nil)
+
+	return (*hexutil.Big)(result)
+}
+
+func (m *Manager) estimateGas(args common.SendTxArgs) (*hexutil.Big, error) {
 	client := m.nodeManager.RPCClient()
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
 
+	var estimatedGas hexutil.Big
+	if err := client.CallContext(
+		ctx,
+		&estimatedGas,
+		"eth_estimateGas",
+		toCallArgs(args),
+	); err != nil {
+		log.Warn("failed to estimate gas", "err", err)
+		return nil, err
+	}
+
+	return &estimatedGas, nil
+}
+
+// callArgs are params of eth_call and eth_estimateGas.
+type callArgs struct {
+	From     gethcommon.Address  `json:"from"`
+	To       *gethcommon.Address `json:"to"`
+	Gas      hexutil.Big         `json:"gas"`
+	GasPrice hexutil.Big         `json:"gasPrice"`
+	Value    hexutil.Big         `json:"value"`
+	Data     hexutil.Bytes       `json:"data"`
+}
+
+// toCallArgs converts transaction args to call args, leaving gas unset.
+func toCallArgs(args common.SendTxArgs) callArgs {
 	var gasPrice hexutil.Big
 	if args.GasPrice != nil {
 		gasPrice = (hexutil.Big)(*args.GasPrice)
@@ -313,33 +462,27 @@ func (m *Manager) estimateGas(args common.SendTxArgs) (*hexutil.Big, error) {
 		value = (hexutil.Big)(*args.Value)
 	}
 
-	params := struct {
-		From     gethcommon.Address  `json:"from"`
-		To       *gethcommon.Address `json:"to"`
-		Gas      hexutil.Big         `json:"gas"`
-		GasPrice hexutil.Big         `json:"gasPrice"`
-		Value    hexutil.Big         `json:"value"`
-		Data     hexutil.Bytes       `json:"data"`
-	}{
+	return callArgs{
 		From:     args.From,
 		To:       args.To,
 		GasPrice: gasPrice,
 		Value:    value,
 		Data:     []byte(args.Data),
 	}
+}
 
-	var estimatedGas hexutil.Big
-	if err := client.CallContext(
-		ctx,
-		&estimatedGas,
-		"eth_estimateGas",
-		params,
-	); err != nil {
-		log.Warn("failed to estimate gas", "err", err)
-		return nil, err
+func (m *Manager) transactionCount(address gethcommon.Address) (uint64, error) {
+	client := m.nodeManager.RPCClient()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var txCount hexutil.Uint64
+	if err := client.CallContext(ctx, &txCount, "eth_getTransactionCount", address, "pending"); err != nil {
+		log.Warn("failed to get transaction count", "err", err)
+		return 0, err
 	}
 
-	return &estimatedGas, nil
+	return uint64(txCount), nil
 }
 
 func (m *Manager) gasPrice() (*hexutil.Big, error) {
@@ -371,13 +514,22 @@ func (m *Manager) CompleteTransactions(ids []common.QueuedTxID, password string)
 	return results
 }
 
-// DiscardTransaction discards a given transaction from transaction queue
+// DiscardTransaction discards a given transaction from transaction queue.
+// WaitForTransaction of the transaction returns ErrQueuedTxDiscarded.
+// Unknown and already completed transactions can't be discarded
+// and ErrQueuedTxIDNotFound is returned, ErrQueuedTxInProgress
+// is returned if the transaction is being completed.
 func (m *Manager) DiscardTransaction(id common.QueuedTxID) error {
 	queuedTx, err := m.txQueue.Get(id)
 	if err != nil {
 		return err
 	}
 
+	// a transaction being completed can't be discarded anymore
+	if err := m.txQueue.StartProcessing(queuedTx); err != nil {
+		return err
+	}
+
 	// remove from queue, before notifying SendTransaction
 	m.txQueue.Remove(queuedTx.ID)
 
@@ -388,7 +540,8 @@ func (m *Manager) DiscardTransaction(id common.QueuedTxID) error {
 	return nil
 }
 
-// DiscardTransactions discards given multiple transactions from transaction queue
+// DiscardTransactions discards given multiple transactions from transaction queue.
+// Only transactions which failed to be discarded are present in the results.
 func (m *Manager) DiscardTransactions(ids []common.QueuedTxID) map[common.QueuedTxID]common.RawDiscardTransactionResult {
 	results := make(map[common.QueuedTxID]common.RawDiscardTransactionResult)
 
@@ -432,6 +585,12 @@ func (m *Manager) SetTransactionQueueHandler(fn common.EnqueuedTxHandler) {
 	m.txQueue.SetEnqueueHandler(fn)
 }
 
+// SubscribeTransactionQueued registers a handler that will be called
+// when a new transaction is enqueued, and returns function unsubscribing it.
+func (m *Manager) SubscribeTransactionQueued(fn common.EnqueuedTxHandler) (unsubscribe func()) {
+	return m.txQueue.SubscribeEnqueued(fn)
+}
+
 // ReturnSendTransactionEvent is a JSON returned whenever transaction send is returned
 type ReturnSendTransactionEvent struct {
 	ID           string            `json:"id"`
@@ -484,6 +643,8 @@ func (m *Manager) SetTransactionReturnHandler(fn common.EnqueuedTxReturnHandler)
 
 // SendTransactionRPCHandler is a handler for eth_sendTransaction method.
 // It accepts one param which is a slice with a map of transaction params.
+// If the params have "simulate" set to true, the transaction is not queued,
+// its predicted result and gas are returned instead, see SimulateTransaction.
 func (m *Manager) SendTransactionRPCHandler(ctx context.Context, args ...interface{}) (interface{}, error) {
 	log.Info("SendTransactionRPCHandler called")
 
@@ -491,6 +652,14 @@ func (m *Manager) SendTransactionRPCHandler(ctx context.Context, args ...interfa
 	// We should refactor parsing these params to a separate struct.
 	rpcCall := common.RPCCall{Params: args}
 
+	if rpcCall.ParseSimulate() {
+		result, err := m.SimulateTransaction(ctx, rpcCall.ToSendTxArgs())
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
 	tx := m.CreateTransaction(ctx, rpcCall.ToSendTxArgs())
 
 	if err := m.QueueTransaction(tx); err != nil {
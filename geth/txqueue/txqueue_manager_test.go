@@ -2,17 +2,29 @@ package txqueue
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"math/big"
+	"net/http/httptest"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	gethnode "github.com/ethereum/go-ethereum/node"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/golang/mock/gomock"
 
 	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
+	"github.com/status-im/status-go/geth/signal"
 	. "github.com/status-im/status-go/testing"
 )
 
@@ -43,6 +55,262 @@ func (s *TxQueueTestSuite) TearDownTest() {
 	s.accountManagerMockCtrl.Finish()
 }
 
+// UpstreamEthService is a fake of upstream node eth API used to complete
+// transactions remotely.
+type UpstreamEthService struct{}
+
+// upstreamTxCount is a transaction count of any account on the fake upstream node.
+const upstreamTxCount = 5
+
+// GetTransactionCount returns a transaction count of an account.
+func (UpstreamEthService) GetTransactionCount(address gethcommon.Address, block string) hexutil.Uint64 {
+	return upstreamTxCount
+}
+
+// GasPrice returns a gas price.
+func (UpstreamEthService) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(20000000000))
+}
+
+// EstimateGas returns gas estimation for a transaction.
+func (UpstreamEthService) EstimateGas(args map[string]interface{}) *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(21000))
+}
+
+// SendRawTransaction returns hash of a signed transaction.
+func (UpstreamEthService) SendRawTransaction(data hexutil.Bytes) gethcommon.Hash {
+	return crypto.Keccak256Hash(data)
+}
+
+// setupUpstream makes mocks complete transactions of the returned account
+// using a fake upstream node.
+func (s *TxQueueTestSuite) setupUpstream() (*common.SelectedExtKey, *params.NodeConfig, func()) {
+	server := gethrpc.NewServer()
+	s.NoError(server.RegisterName("eth", UpstreamEthService{}))
+	upstream := httptest.NewServer(server)
+
+	account, config, _, cleanup := s.setupUpstreamURL(upstream.URL)
+
+	return account, config, func() {
+		cleanup()
+		upstream.Close()
+	}
+}
+
+// setupUpstreamURL makes mocks complete transactions of the returned account
+// using an upstream node at a given URL. It also returns the RPC client
+// routing calls to that node.
+func (s *TxQueueTestSuite) setupUpstreamURL(url string) (*common.SelectedExtKey, *params.NodeConfig, *rpc.Client, func()) {
+	// local node is required by RPC client, but not used
+	node, err := gethnode.New(&gethnode.Config{})
+	s.NoError(err)
+	s.NoError(node.Start())
+
+	config, err := params.NewNodeConfig("/tmp", params.RopstenNetworkID, true)
+	s.NoError(err)
+	config.UpstreamConfig.Enabled = true
+	config.UpstreamConfig.URL = url
+
+	client, err := rpc.NewClient(node, config.UpstreamConfig)
+	s.NoError(err)
+
+	key, err := crypto.GenerateKey()
+	s.NoError(err)
+	account := &common.SelectedExtKey{
+		Address:    crypto.PubkeyToAddress(key.PublicKey),
+		AccountKey: &keystore.Key{PrivateKey: key},
+	}
+
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(config, nil).AnyTimes()
+	s.nodeManagerMock.EXPECT().RPCClient().Return(client).AnyTimes()
+	// transactions are mined as soon as they are sent
+	s.nodeManagerMock.EXPECT().WaitForReceipt(gomock.Any(), gomock.Any(), gomock.Any()).Return(&types.Receipt{}, nil).AnyTimes()
+	s.accountManagerMock.EXPECT().SelectedAccount().Return(account, nil).AnyTimes()
+	s.accountManagerMock.EXPECT().VerifyAccountPassword(
+		config.KeyStoreDir, account.Address.String(), TestConfig.Account1.Password,
+	).Return(account.AccountKey, nil).AnyTimes()
+
+	return account, config, client, func() {
+		s.NoError(node.Stop())
+	}
+}
+
+func (s *TxQueueTestSuite) TestSendTransactionRPCHandler() {
+	account, _, cleanup := s.setupUpstream()
+	defer cleanup()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	hashes := make(chan gethcommon.Hash, 1)
+	unsubscribe := txQueueManager.SubscribeTransactionQueued(func(queuedTx *common.QueuedTx) {
+		s.Equal(account.Address, queuedTx.Args.From)
+
+		go func() {
+			hash, err := txQueueManager.CompleteTransaction(queuedTx.ID, TestConfig.Account1.Password)
+			s.NoError(err)
+			hashes <- hash
+		}()
+	})
+	defer unsubscribe()
+
+	result, err := txQueueManager.SendTransactionRPCHandler(context.Background(), map[string]interface{}{
+		"from": account.Address.Hex(),
+		"to":   TestConfig.Account2.Address,
+	})
+	s.NoError(err)
+
+	hash := <-hashes
+	s.NotEqual(gethcommon.Hash{}, hash)
+	s.Equal(hash.Hex(), result)
+	s.Equal(0, txQueueManager.TransactionQueue().Count())
+}
+
+func (s *TxQueueTestSuite) TestSendTransactionSignedLocally() {
+	upstream := NewMockUpstream(map[string]string{
+		"eth_gasPrice":            `"0x4a817c800"`,
+		"eth_estimateGas":         `"0x5208"`,
+		"eth_getTransactionCount": `"0x5"`,
+		"eth_sendRawTransaction":  `"0x0000000000000000000000000000000000000000000000000000000000000000"`,
+	})
+	defer upstream.Close()
+
+	account, _, client, cleanup := s.setupUpstreamURL(upstream.URL())
+	defer cleanup()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	client.RegisterHandler("eth_sendTransaction", txQueueManager.SendTransactionRPCHandler)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	completed := make(chan error, 1)
+	unsubscribe := txQueueManager.SubscribeTransactionQueued(func(queuedTx *common.QueuedTx) {
+		go func() {
+			_, err := txQueueManager.CompleteTransaction(queuedTx.ID, TestConfig.Account1.Password)
+			completed <- err
+		}()
+	})
+	defer unsubscribe()
+
+	var hash string
+	err := client.Call(&hash, "eth_sendTransaction", map[string]interface{}{
+		"from": account.Address.Hex(),
+		"to":   TestConfig.Account2.Address,
+	})
+	s.NoError(err)
+	s.NotEqual(gethcommon.Hash{}.Hex(), hash)
+	s.NoError(<-completed)
+
+	// the transaction is signed with the selected account and only the raw one reaches upstream
+	var methods []string
+	for _, req := range upstream.Requests() {
+		methods = append(methods, req.Method)
+	}
+	s.Contains(methods, "eth_sendRawTransaction")
+	s.NotContains(methods, "eth_sendTransaction")
+}
+
+func (s *TxQueueTestSuite) TestSimulateTransaction() {
+	upstream := NewMockUpstream(map[string]string{
+		"eth_call":        `"0x0000000000000000000000000000000000000000000000000000000000000001"`,
+		"eth_estimateGas": `"0x5208"`,
+	})
+	defer upstream.Close()
+
+	account, _, client, cleanup := s.setupUpstreamURL(upstream.URL())
+	defer cleanup()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	client.RegisterHandler("eth_sendTransaction", txQueueManager.SendTransactionRPCHandler)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	unsubscribe := txQueueManager.SubscribeTransactionQueued(func(queuedTx *common.QueuedTx) {
+		s.Fail("simulated transaction queued")
+	})
+	defer unsubscribe()
+
+	var result *SimulationResult
+	err := client.Call(&result, "eth_sendTransaction", map[string]interface{}{
+		"from":     account.Address.Hex(),
+		"to":       TestConfig.Account2.Address,
+		"simulate": true,
+	})
+	s.NoError(err)
+	s.Equal(gethcommon.LeftPadBytes([]byte{1}, 32), []byte(result.Result))
+	s.Equal(big.NewInt(21000), (*big.Int)(result.Gas))
+
+	var methods []string
+	for _, req := range upstream.Requests() {
+		methods = append(methods, req.Method)
+	}
+	s.NotContains(methods, "eth_sendRawTransaction")
+}
+
+func (s *TxQueueTestSuite) TestSimulateTransactionReverted() {
+	// Error(string) with "not enough tokens" reason
+	upstream := NewMockUpstream(map[string]string{
+		"eth_call": `"0x08c379a0` +
+			`0000000000000000000000000000000000000000000000000000000000000020` +
+			`0000000000000000000000000000000000000000000000000000000000000011` +
+			`6e6f7420656e6f75676820746f6b656e73000000000000000000000000000000"`,
+	})
+	defer upstream.Close()
+
+	account, _, _, cleanup := s.setupUpstreamURL(upstream.URL())
+	defer cleanup()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	_, err := txQueueManager.SendTransactionRPCHandler(context.Background(), map[string]interface{}{
+		"from":     account.Address.Hex(),
+		"to":       TestConfig.Account2.Address,
+		"simulate": true,
+	})
+	s.Equal(&RevertError{Reason: "not enough tokens"}, err)
+	s.EqualError(err, "execution reverted: not enough tokens")
+	s.Equal(0, txQueueManager.TransactionQueue().Count())
+}
+
+func (s *TxQueueTestSuite) TestSubscribeTransactionQueued() {
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	).Times(2)
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	var received []string
+	unsubscribeA := txQueueManager.SubscribeTransactionQueued(func(queuedTx *common.QueuedTx) {
+		received = append(received, "a")
+	})
+	txQueueManager.SubscribeTransactionQueued(func(queuedTx *common.QueuedTx) {
+		received = append(received, "b")
+	})
+
+	newTx := func() *common.QueuedTx {
+		return txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+			From: common.FromAddress(TestConfig.Account1.Address),
+			To:   common.ToAddress(TestConfig.Account2.Address),
+		})
+	}
+
+	tx := newTx()
+	s.NoError(txQueueManager.QueueTransaction(tx))
+	s.True(txQueueManager.TransactionQueue().Has(tx.ID))
+	s.Equal([]string{"a", "b"}, received)
+
+	unsubscribeA()
+	s.NoError(txQueueManager.QueueTransaction(newTx()))
+	s.Equal([]string{"a", "b", "b"}, received)
+}
+
 func (s *TxQueueTestSuite) TestCompleteTransaction() {
 	s.accountManagerMock.EXPECT().SelectedAccount().Return(&common.SelectedExtKey{
 		Address: common.FromAddress(TestConfig.Account1.Address),
@@ -50,7 +318,7 @@ func (s *TxQueueTestSuite) TestCompleteTransaction() {
 
 	s.nodeManagerMock.EXPECT().NodeConfig().Return(
 		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
-	)
+	).Times(2)
 
 	// TODO(adam): StatusBackend as an interface would allow a better solution.
 	// As we want to avoid network connection, we mock LES with a known error
@@ -93,6 +361,180 @@ func (s *TxQueueTestSuite) TestCompleteTransaction() {
 	s.False(txQueueManager.TransactionQueue().Has(tx.ID))
 }
 
+func (s *TxQueueTestSuite) TestCompleteTransactions() {
+	account, _, cleanup := s.setupUpstream()
+	defer cleanup()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	// TransactionQueueHandler is required to enqueue a transaction.
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+
+	var ids []common.QueuedTxID
+	for i := 0; i < 3; i++ {
+		tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+			From: account.Address,
+			To:   common.ToAddress(TestConfig.Account2.Address),
+		})
+		s.NoError(txQueueManager.QueueTransaction(tx))
+		ids = append(ids, tx.ID)
+	}
+
+	discardedID := ids[2]
+	s.NoError(txQueueManager.DiscardTransaction(discardedID))
+
+	results := txQueueManager.CompleteTransactions(ids, TestConfig.Account1.Password)
+	s.Len(results, 3)
+
+	for _, id := range ids[:2] {
+		s.NoError(results[id].Error)
+		s.NotEqual(gethcommon.Hash{}, results[id].Hash)
+	}
+
+	s.Equal(ErrQueuedTxIDNotFound, results[discardedID].Error)
+	s.Equal(gethcommon.Hash{}, results[discardedID].Hash)
+}
+
+func (s *TxQueueTestSuite) TestCompleteTransactionFillsGas() {
+	account, config, cleanup := s.setupUpstream()
+	defer cleanup()
+
+	config.GasPriceMultiplier = 1.5
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	// TransactionQueueHandler is required to enqueue a transaction.
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+
+	// no gas fields, so both are filled in
+	tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From: account.Address,
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	})
+	s.NoError(txQueueManager.QueueTransaction(tx))
+
+	_, err := txQueueManager.CompleteTransaction(tx.ID, TestConfig.Account1.Password)
+	s.NoError(err)
+	s.Equal(big.NewInt(21000), (*big.Int)(tx.Args.Gas))
+	s.Equal(big.NewInt(30000000000), (*big.Int)(tx.Args.GasPrice))
+
+	// explicit gas fields are kept as is
+	gas := (*hexutil.Big)(big.NewInt(50000))
+	gasPrice := (*hexutil.Big)(big.NewInt(1000))
+	tx = txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From:     account.Address,
+		To:       common.ToAddress(TestConfig.Account2.Address),
+		Gas:      gas,
+		GasPrice: gasPrice,
+	})
+	s.NoError(txQueueManager.QueueTransaction(tx))
+
+	_, err = txQueueManager.CompleteTransaction(tx.ID, TestConfig.Account1.Password)
+	s.NoError(err)
+	s.Equal(big.NewInt(50000), (*big.Int)(tx.Args.Gas))
+	s.Equal(big.NewInt(1000), (*big.Int)(tx.Args.GasPrice))
+}
+
+func (s *TxQueueTestSuite) TestCompleteTransactionUsesGasPriceOracle() {
+	account, config, cleanup := s.setupUpstream()
+	defer cleanup()
+
+	config.GasPriceOracle.Enabled = true
+	s.nodeManagerMock.EXPECT().SuggestGasPrice().Return(big.NewInt(4000000000), nil)
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	// TransactionQueueHandler is required to enqueue a transaction.
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+
+	tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From: account.Address,
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	})
+	s.NoError(txQueueManager.QueueTransaction(tx))
+
+	_, err := txQueueManager.CompleteTransaction(tx.ID, TestConfig.Account1.Password)
+	s.NoError(err)
+	s.Equal(big.NewInt(4000000000), (*big.Int)(tx.Args.GasPrice))
+}
+
+func (s *TxQueueTestSuite) TestQueueTransactionNonces() {
+	account, _, cleanup := s.setupUpstream()
+	defer cleanup()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	// TransactionQueueHandler is required to enqueue a transaction.
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+
+	newTx := func() *common.QueuedTx {
+		return txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+			From: account.Address,
+			To:   common.ToAddress(TestConfig.Account2.Address),
+		})
+	}
+
+	txs := []*common.QueuedTx{newTx(), newTx(), newTx()}
+
+	var wg sync.WaitGroup
+	for _, tx := range txs {
+		wg.Add(1)
+		go func(tx *common.QueuedTx) {
+			defer wg.Done()
+			s.NoError(txQueueManager.QueueTransaction(tx))
+		}(tx)
+	}
+	wg.Wait()
+
+	nonces := make(map[uint64]*common.QueuedTx)
+	for _, tx := range txs {
+		s.NotNil(tx.Args.Nonce)
+		nonces[uint64(*tx.Args.Nonce)] = tx
+	}
+	s.Len(nonces, 3)
+	for nonce := uint64(upstreamTxCount); nonce < upstreamTxCount+3; nonce++ {
+		s.Contains(nonces, nonce)
+	}
+
+	// nonce of a discarded transaction is given to the next one
+	s.NoError(txQueueManager.DiscardTransaction(nonces[upstreamTxCount+1].ID))
+	queuedTx := newTx()
+	s.NoError(txQueueManager.QueueTransaction(queuedTx))
+	s.Equal(hexutil.Uint64(upstreamTxCount+1), *queuedTx.Args.Nonce)
+
+	// completed transaction keeps its nonce
+	completedTx := nonces[upstreamTxCount]
+	_, err := txQueueManager.CompleteTransaction(completedTx.ID, TestConfig.Account1.Password)
+	s.NoError(err)
+	s.NoError(txQueueManager.WaitForTransaction(completedTx))
+
+	tx := newTx()
+	s.NoError(txQueueManager.QueueTransaction(tx))
+	s.Equal(hexutil.Uint64(upstreamTxCount+3), *tx.Args.Nonce)
+
+	// once the queue drains, nonce is taken from the chain again
+	for _, tx := range []*common.QueuedTx{nonces[upstreamTxCount+2], tx, queuedTx} {
+		s.NoError(txQueueManager.DiscardTransaction(tx.ID))
+	}
+	s.Equal(0, txQueueManager.TransactionQueue().Count())
+
+	tx = newTx()
+	s.NoError(txQueueManager.QueueTransaction(tx))
+	s.Equal(hexutil.Uint64(upstreamTxCount), *tx.Args.Nonce)
+}
+
 func (s *TxQueueTestSuite) TestCompleteTransactionMultipleTimes() {
 	s.accountManagerMock.EXPECT().SelectedAccount().Return(&common.SelectedExtKey{
 		Address: common.FromAddress(TestConfig.Account1.Address),
@@ -100,7 +542,7 @@ func (s *TxQueueTestSuite) TestCompleteTransactionMultipleTimes() {
 
 	s.nodeManagerMock.EXPECT().NodeConfig().Return(
 		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
-	)
+	).Times(2)
 
 	// TODO(adam): StatusBackend as an interface would allow a better solution.
 	// As we want to avoid network connection, we mock LES with a known error
@@ -161,6 +603,10 @@ func (s *TxQueueTestSuite) TestAccountMismatch() {
 		Address: common.FromAddress(TestConfig.Account2.Address),
 	}, nil)
 
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	)
+
 	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
 
 	txQueueManager.Start()
@@ -202,7 +648,7 @@ func (s *TxQueueTestSuite) TestInvalidPassword() {
 
 	s.nodeManagerMock.EXPECT().NodeConfig().Return(
 		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
-	)
+	).Times(2)
 
 	// Set ErrDecrypt error response as expected with a wrong password.
 	s.nodeManagerMock.EXPECT().LightEthereumService().Return(nil, keystore.ErrDecrypt)
@@ -242,6 +688,10 @@ func (s *TxQueueTestSuite) TestInvalidPassword() {
 }
 
 func (s *TxQueueTestSuite) TestDiscardTransaction() {
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	)
+
 	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
 
 	txQueueManager.Start()
@@ -277,3 +727,222 @@ func (s *TxQueueTestSuite) TestDiscardTransaction() {
 	// Transaction should be already removed from the queue.
 	s.False(txQueueManager.TransactionQueue().Has(tx.ID))
 }
+
+func (s *TxQueueTestSuite) TestTransactionQueuedSignal() {
+	s.accountManagerMock.EXPECT().SelectedAccount().Return(&common.SelectedExtKey{
+		Address: common.FromAddress(TestConfig.Account1.Address),
+	}, nil)
+
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	).Times(2)
+
+	s.nodeManagerMock.EXPECT().LightEthereumService().Return(nil, errTxAssumedSent)
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	txQueueManager.SetTransactionQueueHandler(txQueueManager.TransactionQueueHandler())
+
+	// a UI is prompted with every queued transaction
+	queued := make(chan string, 1)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string
+			Event SendTransactionEvent
+		}
+		if err := json.Unmarshal([]byte(jsonEvent), &envelope); err != nil || envelope.Type != EventTransactionQueued {
+			return
+		}
+		queued <- envelope.Event.ID
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	})
+	s.NoError(txQueueManager.QueueTransaction(tx))
+
+	var id string
+	select {
+	case id = <-queued:
+	case <-time.After(time.Second):
+		s.FailNow("timed out waiting for transaction queued signal")
+	}
+	s.Equal(string(tx.ID), id)
+
+	// and approves it
+	completed := make(chan error, 1)
+	go func() {
+		_, err := txQueueManager.CompleteTransaction(common.QueuedTxID(id), TestConfig.Account1.Password)
+		completed <- err
+	}()
+
+	err := txQueueManager.WaitForTransaction(tx)
+	s.Equal(errTxAssumedSent, err)
+	s.Equal(errTxAssumedSent, <-completed)
+	s.False(txQueueManager.TransactionQueue().Has(tx.ID))
+}
+
+func (s *TxQueueTestSuite) TestTransactionCompletionTimeout() {
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	)
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetCompletionTimeout(100 * time.Millisecond)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	// TransactionQueueHandler is required to enqueue a transaction.
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+
+	tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	})
+	s.NoError(txQueueManager.QueueTransaction(tx))
+
+	err := txQueueManager.WaitForTransaction(tx)
+	s.Equal(ErrQueuedTxTimedOut, err)
+	// Transaction should be discarded even without a return handler.
+	s.False(txQueueManager.TransactionQueue().Has(tx.ID))
+}
+
+func (s *TxQueueTestSuite) TestDiscardTransactions() {
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	).Times(3)
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	// TransactionQueueHandler is required to enqueue a transaction.
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+
+	ids := make(chan common.QueuedTxID, 2)
+	unsubscribe := txQueueManager.SubscribeTransactionQueued(func(queuedTx *common.QueuedTx) {
+		ids <- queuedTx.ID
+	})
+	defer unsubscribe()
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := txQueueManager.SendTransactionRPCHandler(context.Background(), map[string]interface{}{
+				"from": TestConfig.Account1.Address,
+				"to":   TestConfig.Account2.Address,
+			})
+			errs <- err
+		}()
+	}
+
+	queued := []common.QueuedTxID{<-ids, <-ids}
+	results := txQueueManager.DiscardTransactions(append(queued, "unknown"))
+	s.Len(results, 1)
+	s.Equal(ErrQueuedTxIDNotFound, results["unknown"].Error)
+
+	for range queued {
+		s.Equal(ErrQueuedTxDiscarded, <-errs)
+	}
+	s.Equal(0, txQueueManager.TransactionQueue().Count())
+
+	// discarded transactions are gone
+	s.Equal(ErrQueuedTxIDNotFound, txQueueManager.DiscardTransaction(queued[0]))
+
+	// a transaction being completed can't be discarded
+	tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	})
+	s.NoError(txQueueManager.QueueTransaction(tx))
+	s.NoError(txQueueManager.txQueue.StartProcessing(tx))
+	s.Equal(ErrQueuedTxInProgress, txQueueManager.DiscardTransaction(tx.ID))
+	s.True(txQueueManager.TransactionQueue().Has(tx.ID))
+}
+
+func (s *TxQueueTestSuite) TestQueueCapacity() {
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	).Times(5)
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetQueueCapacity(2)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	// TransactionQueueHandler is required to enqueue a transaction.
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+
+	newTx := func() *common.QueuedTx {
+		return txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+			From: common.FromAddress(TestConfig.Account1.Address),
+			To:   common.ToAddress(TestConfig.Account2.Address),
+		})
+	}
+
+	tx := newTx()
+	s.NoError(txQueueManager.QueueTransaction(tx))
+	s.NoError(txQueueManager.QueueTransaction(newTx()))
+	s.Equal(2, txQueueManager.TransactionQueue().Count())
+
+	rejected := newTx()
+	s.Equal(ErrQueuedTxQueueFull, txQueueManager.QueueTransaction(rejected))
+	s.False(txQueueManager.TransactionQueue().Has(rejected.ID))
+
+	// eth_sendTransaction fails without blocking
+	_, err := txQueueManager.SendTransactionRPCHandler(context.Background(), map[string]interface{}{
+		"from": TestConfig.Account1.Address,
+		"to":   TestConfig.Account2.Address,
+	})
+	s.Equal(ErrQueuedTxQueueFull, err)
+	s.Equal(2, txQueueManager.TransactionQueue().Count())
+
+	// discarding a transaction makes room for another one
+	s.NoError(txQueueManager.DiscardTransaction(tx.ID))
+	s.NoError(txQueueManager.QueueTransaction(newTx()))
+}
+
+func (s *TxQueueTestSuite) TestTransactionHistory() {
+	account, _, cleanup := s.setupUpstream()
+	defer cleanup()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	unsubscribe := txQueueManager.SubscribeTransactionQueued(func(queuedTx *common.QueuedTx) {
+		go txQueueManager.CompleteTransaction(queuedTx.ID, TestConfig.Account1.Password) // nolint: errcheck
+	})
+	defer unsubscribe()
+
+	value := (*hexutil.Big)(big.NewInt(1000))
+	result, err := txQueueManager.SendTransactionRPCHandler(context.Background(), map[string]interface{}{
+		"from":  account.Address.Hex(),
+		"to":    TestConfig.Account2.Address,
+		"value": value.String(),
+	})
+	s.NoError(err)
+
+	history := txQueueManager.TransactionHistory(account.Address.Hex())
+	s.Len(history, 1)
+	s.Equal(result, history[0].Hash.Hex())
+	s.Equal(account.Address, history[0].From)
+	s.Equal(gethcommon.HexToAddress(TestConfig.Account2.Address), *history[0].To)
+	s.Equal(value, history[0].Value)
+	s.False(history[0].Timestamp.IsZero())
+
+	// stopping waits until the receipt is observed
+	txQueueManager.Stop()
+	s.Equal(common.TransactionSuccess, txQueueManager.TransactionHistory(account.Address.Hex())[0].Status)
+
+	s.Empty(txQueueManager.TransactionHistory(TestConfig.Account2.Address))
+}
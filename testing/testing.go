@@ -2,15 +2,20 @@ package integration
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/params"
 )
 
+// ErrNodeStartTimeout is returned by WaitForNodeStart if node is not started in time.
+var ErrNodeStartTimeout = errors.New("timed out waiting for node to start")
+
 var (
 	// TestConfig defines the default config usable at package-level.
 	TestConfig *common.TestConfig
@@ -64,3 +69,14 @@ func LoadFromFile(filename string) string {
 
 	return string(buf.Bytes())
 }
+
+// WaitForNodeStart waits until started is closed, as returned by NodeManager.StartNode,
+// or fails with ErrNodeStartTimeout after timeout.
+func WaitForNodeStart(started <-chan struct{}, timeout time.Duration) error {
+	select {
+	case <-started:
+		return nil
+	case <-time.After(timeout):
+		return ErrNodeStartTimeout
+	}
+}
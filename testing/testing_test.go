@@ -0,0 +1,17 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForNodeStart(t *testing.T) {
+	started := make(chan struct{})
+	close(started)
+	require.NoError(t, WaitForNodeStart(started, time.Second))
+
+	never := make(chan struct{})
+	require.Equal(t, ErrNodeStartTimeout, WaitForNodeStart(never, 10*time.Millisecond))
+}
@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// MockRequest is a JSON-RPC request received by MockUpstream.
+type MockRequest struct {
+	Method string          `json:"method"`
+	ID     json.RawMessage `json:"id"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// MockUpstream is an in-process JSON-RPC server to be used as an upstream in tests.
+// It responds to requests with results mapped to their methods and records them.
+type MockUpstream struct {
+	server    *httptest.Server
+	responses map[string]string // method -> JSON encoded result
+
+	mu       sync.Mutex // guards requests
+	requests []MockRequest
+}
+
+// NewMockUpstream starts a server responding with JSON encoded results
+// mapped to methods, e.g. {"net_version": `"4"`}. Requests of other methods
+// are responded with the method not found error.
+func NewMockUpstream(responses map[string]string) *MockUpstream {
+	u := &MockUpstream{responses: responses}
+	u.server = httptest.NewServer(http.HandlerFunc(u.serveHTTP))
+
+	return u
+}
+
+// URL returns the URL of the server.
+func (u *MockUpstream) URL() string {
+	return u.server.URL
+}
+
+// Requests returns all requests received so far.
+func (u *MockUpstream) Requests() []MockRequest {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return append([]MockRequest(nil), u.requests...)
+}
+
+// Close shuts down the server.
+func (u *MockUpstream) Close() {
+	u.server.Close()
+}
+
+func (u *MockUpstream) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var req MockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	u.mu.Lock()
+	u.requests = append(u.requests, req)
+	u.mu.Unlock()
+
+	id := string(req.ID)
+	if id == "" {
+		id = "null"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	result, ok := u.responses[req.Method]
+	if !ok {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + id + `,"error":{"code":-32601,"message":"the method ` + req.Method + ` does not exist/is not available"}}`)) // nolint: errcheck
+		return
+	}
+
+	w.Write([]byte(`{"jsonrpc":"2.0","id":` + id + `,"result":` + result + `}`)) // nolint: errcheck
+}
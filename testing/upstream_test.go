@@ -0,0 +1,45 @@
+package integration
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockUpstream(t *testing.T) {
+	upstream := NewMockUpstream(map[string]string{
+		"net_version": `"4"`,
+		"web3_sha3":   `"0x47173285a8d7341e5e972fc677286384f802f8ef42a5ec5f03bbfa254cb01fad"`,
+	})
+	defer upstream.Close()
+
+	call := func(body string) string {
+		resp, err := http.Post(upstream.URL(), "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		defer resp.Body.Close() // nolint: errcheck
+
+		data, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	require.Equal(t,
+		`{"jsonrpc":"2.0","id":1,"result":"4"}`,
+		call(`{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`))
+	require.Equal(t,
+		`{"jsonrpc":"2.0","id":2,"result":"0x47173285a8d7341e5e972fc677286384f802f8ef42a5ec5f03bbfa254cb01fad"}`,
+		call(`{"jsonrpc":"2.0","method":"web3_sha3","params":["0x68656c6c6f20776f726c64"],"id":2}`))
+	require.Equal(t,
+		`{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"the method eth_unknown does not exist/is not available"}}`,
+		call(`{"jsonrpc":"2.0","method":"eth_unknown","params":[],"id":3}`))
+
+	requests := upstream.Requests()
+	require.Len(t, requests, 3)
+	require.Equal(t, "net_version", requests[0].Method)
+	require.Equal(t, "1", string(requests[0].ID))
+	require.Equal(t, "web3_sha3", requests[1].Method)
+	require.Equal(t, `["0x68656c6c6f20776f726c64"]`, string(requests[1].Params))
+}